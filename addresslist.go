@@ -0,0 +1,123 @@
+package emailguard
+
+import (
+	"context"
+	"net/mail"
+	"strings"
+)
+
+// Address is one mailbox of an address list.
+type Address struct {
+	Name  string `json:"name,omitempty"` // display name, decoded
+	Email string `json:"email"`          // the address itself
+}
+
+// ParseAddressList splits an RFC 5322 address list, as found in CRM
+// exports and To: headers, into mailboxes: "Jane Doe" <jane@company.com>,
+// jane@company.com (Jane Doe) and bare addresses, separated by commas or
+// semicolons. Group names ("Team: a@x.com, b@x.com;") are dropped and
+// their members kept. An entry that is not a valid mailbox is returned as
+// written, for Validate to reject; empty entries are skipped.
+func ParseAddressList(list string) []Address {
+	var out []Address
+	for _, entry := range splitAddressList(list) {
+		if a, ok := parseMailbox(entry); ok {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// ValidateAddressList checks every mailbox of list with the default guard.
+func ValidateAddressList(ctx context.Context, list string, opts BatchOptions) []Result {
+	return defaultGuard.Load().ValidateAddressList(ctx, list, opts)
+}
+
+// ValidateAddressList checks every mailbox of list, split by
+// ParseAddressList, with ValidateBatch. Results are in list order and
+// carry the display names.
+func (g *Guard) ValidateAddressList(ctx context.Context, list string, opts BatchOptions) []Result {
+	addrs := ParseAddressList(list)
+	emails := make([]string, len(addrs))
+	for i, a := range addrs {
+		emails[i] = a.Email
+	}
+	res := g.ValidateBatch(ctx, emails, opts)
+	for i := range res {
+		res[i].Name = addrs[i].Name
+	}
+	return res
+}
+
+// addrSpec returns the address in a single mailbox such as
+// "Jane Doe" <jane@company.com>, or s trimmed if it holds none.
+func addrSpec(s string) string {
+	if a, ok := parseMailbox(s); ok {
+		return a.Email
+	}
+	return strings.TrimSpace(s)
+}
+
+// splitAddressList splits list at commas and semicolons outside quotes,
+// comments and angle brackets, dropping group names.
+func splitAddressList(list string) []string {
+	var (
+		out            []string
+		start          int
+		quoted, escape bool
+		comment, angle int
+	)
+	for i := 0; i < len(list); i++ {
+		c := list[i]
+		switch {
+		case escape:
+			escape = false
+		case c == '\\' && (quoted || comment > 0):
+			escape = true
+		case quoted:
+			quoted = c != '"'
+		case c == '"':
+			quoted = true
+		case c == '(':
+			comment++
+		case c == ')' && comment > 0:
+			comment--
+		case comment > 0:
+		case c == '<':
+			angle++
+		case c == '>' && angle > 0:
+			angle--
+		case angle > 0:
+		case c == ',' || c == ';':
+			out = append(out, list[start:i])
+			start = i + 1
+		case c == ':':
+			start = i + 1 // a group name
+		}
+	}
+	return append(out, list[start:])
+}
+
+// parseMailbox reads one entry of an address list; ok is false if it is
+// empty.
+func parseMailbox(s string) (Address, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Address{}, false
+	}
+	var name string
+	if a, err := mail.ParseAddress(s); err == nil {
+		name = a.Name
+		if !strings.ContainsAny(s, `"<`) {
+			return Address{Name: name, Email: a.Address}, true // comments dropped
+		}
+	}
+	// keep the address as written: net/mail unquotes quoted local parts
+	if i := strings.LastIndexByte(s, '<'); i >= 0 && strings.HasSuffix(s, ">") {
+		if name == "" {
+			name = strings.Trim(strings.TrimSpace(s[:i]), `"`)
+		}
+		return Address{Name: name, Email: strings.TrimSpace(s[i+1 : len(s)-1])}, true
+	}
+	return Address{Email: s}, true
+}
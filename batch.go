@@ -0,0 +1,130 @@
+package emailguard
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+const defaultBatchWorkers = 16
+
+// BatchOptions tunes ValidateBatch.
+type BatchOptions struct {
+	// Concurrency is the number of domains checked at once; zero means 16.
+	// DNS and SMTP work is further bounded by the guard's pools.
+	Concurrency int
+	// CheckOptions apply to every address. Checks run at PriorityBatch
+	// unless these say otherwise.
+	CheckOptions []CheckOption
+
+	progress *atomic.Int64 // counts finished addresses for a Job
+}
+
+// Result is one address's outcome in a batch.
+type Result struct {
+	Email   string
+	Name    string // display name, from ValidateAddressList
+	Verdict Verdict
+	Err     error // as returned by Validate
+}
+
+// ValidateBatch checks emails with the default guard.
+func ValidateBatch(ctx context.Context, emails []string, opts BatchOptions) []Result {
+	return defaultGuard.Load().ValidateBatch(ctx, emails, opts)
+}
+
+// ValidateBatch checks many addresses at once. Addresses are grouped by
+// domain so each domain is resolved once, and domains are spread across a
+// bounded worker pool. When a domain's check fails transiently, the rest
+// of its addresses get the same retry-later verdict without asking the
+// resolver again. Results are in input order. Once ctx is done no new
+// domains are started; their results carry an error wrapping ErrTemporary
+// and ctx.Err().
+func (g *Guard) ValidateBatch(ctx context.Context, emails []string, opts BatchOptions) []Result {
+	workers := opts.Concurrency
+	if workers <= 0 {
+		workers = defaultBatchWorkers
+	}
+	copts := append([]CheckOption{AtPriority(PriorityBatch)}, opts.CheckOptions...)
+
+	// group indexes by domain; malformed addresses form their own groups
+	var groups [][]int
+	byDomain := make(map[string]int)
+	for i, e := range emails {
+		_, domain, ok := splitEmail(e)
+		if !ok {
+			groups = append(groups, []int{i})
+			continue
+		}
+		gi, seen := byDomain[domain]
+		if !seen {
+			gi = len(groups)
+			byDomain[domain] = gi
+			groups = append(groups, nil)
+		}
+		groups[gi] = append(groups[gi], i)
+	}
+
+	results := make([]Result, len(emails))
+	work := make(chan []int)
+	var wg sync.WaitGroup
+	for range min(workers, len(groups)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range work {
+				// the first address warms the caches for the rest, and a
+				// failure is shared instead of waited out again
+				gopts := append(slices.Clip(copts), sharingFailure(&failureMemo{}))
+				for _, i := range idx {
+					v, err := g.Validate(ctx, emails[i], gopts...)
+					results[i] = Result{Email: emails[i], Verdict: v, Err: err}
+				}
+				if opts.progress != nil {
+					opts.progress.Add(int64(len(idx)))
+				}
+			}
+		}()
+	}
+	next := 0
+feed:
+	for ; next < len(groups) && ctx.Err() == nil; next++ {
+		select {
+		case work <- groups[next]:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	cctx := withCheckOptions(ctx, copts)
+	for _, idx := range groups[next:] {
+		for _, i := range idx {
+			v := g.cancelledVerdict(cctx, emails[i])
+			results[i] = Result{Email: emails[i], Verdict: v, Err: fmt.Errorf("%w: %w", ErrTemporary, ctx.Err())}
+		}
+		if opts.progress != nil {
+			opts.progress.Add(int64(len(idx)))
+		}
+	}
+	return results
+}
+
+// cancelledVerdict is the verdict of an address a batch gave up on before
+// checking it, with the address redacted as Validate would.
+func (g *Guard) cancelledVerdict(ctx context.Context, email string) Verdict {
+	local, domain, ok := splitEmail(email)
+	v := Verdict{Email: g.redactEmail(email, local, domain), Classification: ClassUnknown}
+	v.CorrelationID, v.Campaign = checkOptsFrom(ctx).corrID, checkOptsFrom(ctx).campaign
+	fillVerdict(&v, LayerResult{Reason: ReasonTemporary, Detail: "batch cancelled", Err: ctx.Err()})
+	if ok {
+		v.Domain = domain
+		fillSuffix(&v)
+		v.Classification = g.classify(&v)
+	}
+	g.settle(&v)
+	return v
+}
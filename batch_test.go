@@ -0,0 +1,105 @@
+package emailguard
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+)
+
+// timeoutResolver fails every lookup as timed out, counting them.
+type timeoutResolver struct {
+	lookups atomic.Int64
+}
+
+func (r *timeoutResolver) fail(name string) error {
+	r.lookups.Add(1)
+	return &net.DNSError{Err: "i/o timeout", Name: name, IsTimeout: true, IsTemporary: true}
+}
+
+func (r *timeoutResolver) LookupMX(_ context.Context, name string) ([]*net.MX, error) {
+	return nil, r.fail(name)
+}
+
+func (r *timeoutResolver) LookupNS(_ context.Context, name string) ([]*net.NS, error) {
+	return nil, r.fail(name)
+}
+
+func (r *timeoutResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	return nil, r.fail(name)
+}
+
+func (r *timeoutResolver) LookupNetIP(_ context.Context, _, host string) ([]netip.Addr, error) {
+	return nil, r.fail(host)
+}
+
+// newTestGuard returns a guard with a fixture blocklist, so it needs no
+// network, and opts.
+func newTestGuard(t *testing.T, opts ...Option) *Guard {
+	t.Helper()
+	fsys := fstest.MapFS{"blocklist.conf": {Data: []byte("mailinator.com\n")}}
+	g, err := New(append([]Option{WithBlocklistFS(fsys, "blocklist.conf")}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestValidateBatchSharesDomainFailure(t *testing.T) {
+	ctx := context.Background()
+	// With the fingerprint stage racing the MX stage to the failed lookup,
+	// whether it joins or is cancelled first varies from run to run.
+	newGuard := func(r Resolver) *Guard {
+		g := newTestGuard(t, WithResolver(r))
+		if err := g.SetKillSwitches(LayerFingerprint); err != nil {
+			t.Fatal(err)
+		}
+		return g
+	}
+	one := &timeoutResolver{}
+	if _, err := newGuard(one).Validate(ctx, "a@example.org"); !errors.Is(err, ErrTemporary) {
+		t.Fatalf("Validate: got %v, want ErrTemporary", err)
+	}
+
+	r := &timeoutResolver{}
+	g := newGuard(r)
+	emails := []string{"a@example.org", "b@example.org", "c@example.org"}
+	for _, res := range g.ValidateBatch(ctx, emails, BatchOptions{}) {
+		if res.Verdict.Outcome != OutcomeRetryLater || !errors.Is(res.Err, ErrTemporary) {
+			t.Errorf("%s: got %s, %v; want retry_later, ErrTemporary", res.Email, res.Verdict.Outcome, res.Err)
+		}
+	}
+	if got, want := r.lookups.Load(), one.lookups.Load(); got != want {
+		t.Errorf("batch of %d made %d lookups, want %d as for one address", len(emails), got, want)
+	}
+}
+
+func TestValidateBatchCancelledRedacts(t *testing.T) {
+	g := newTestGuard(t, WithPrivacy([]byte("0123456789abcdef")))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	emails := []string{"jane.doe@gmail.com", "john@example.org", "not an address"}
+	for _, res := range g.ValidateBatch(ctx, emails, BatchOptions{}) {
+		v := res.Verdict
+		if v.Outcome != OutcomeRetryLater || !errors.Is(res.Err, ErrTemporary) {
+			t.Errorf("%s: got %s, %v; want retry_later, ErrTemporary", res.Email, v.Outcome, res.Err)
+		}
+		local, domain, _ := strings.Cut(res.Email, "@")
+		if strings.Contains(v.Email, local) {
+			t.Errorf("%s: verdict carries the plaintext address %q", res.Email, v.Email)
+		}
+		if want, _ := g.Pseudonymize(res.Email); v.Email != want {
+			t.Errorf("%s: Email = %q, want pseudonym %q", res.Email, v.Email, want)
+		}
+		if v.Domain != domain {
+			t.Errorf("%s: Domain = %q, want %q", res.Email, v.Domain, domain)
+		}
+	}
+	if v := g.ValidateBatch(ctx, emails[:1], BatchOptions{})[0].Verdict; v.Classification != ClassFree {
+		t.Errorf("cancelled gmail.com verdict classified %s, want free", v.Classification)
+	}
+}
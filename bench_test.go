@@ -0,0 +1,103 @@
+package emailguard
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// benchGoroutines is the concurrency the read paths are measured at.
+const benchGoroutines = 64
+
+// runParallel64 runs body from benchGoroutines goroutines.
+func runParallel64(b *testing.B, body func(pb *testing.PB)) {
+	b.SetParallelism((benchGoroutines + runtime.GOMAXPROCS(0) - 1) / runtime.GOMAXPROCS(0))
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(body)
+}
+
+// benchGuard returns a guard whose allowlist and blocklist hold n domains
+// each, and those domains.
+func benchGuard(b *testing.B, n int) (*Guard, []string, []string) {
+	b.Helper()
+	allow, block := make([]string, n), make([]string, n)
+	for i := range n {
+		allow[i], block[i] = fmt.Sprintf("provider%d.com", i), fmt.Sprintf("burner%d.net", i)
+	}
+	fsys := fstest.MapFS{
+		"allow.conf":     {Data: []byte(strings.Join(allow, "\n"))},
+		"blocklist.conf": {Data: []byte(strings.Join(block, "\n"))},
+	}
+	g, err := New(WithBlocklistFS(fsys, "blocklist.conf"), WithAllowlistFS(fsys, "allow.conf"), WithResolver(mxResolver{}))
+	if err != nil {
+		b.Fatal(err)
+	}
+	g.blocklist.load()
+	return g, allow, block
+}
+
+func BenchmarkAllowlist(b *testing.B) {
+	g, allow, _ := benchGuard(b, 10000)
+	ctx := context.Background()
+	runParallel64(b, func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			if f, _ := g.evalAllowlist(ctx, allow[i%len(allow)]); f.Action != ActionAllow {
+				b.Fatalf("%s not allowlisted", allow[i%len(allow)])
+			}
+		}
+	})
+}
+
+func BenchmarkBlocklist(b *testing.B) {
+	g, _, block := benchGuard(b, 10000)
+	ctx := context.Background()
+	runParallel64(b, func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			if f, _ := g.evalBlocklist(ctx, block[i%len(block)]); f.Action != ActionDeny {
+				b.Fatalf("%s not blocklisted", block[i%len(block)])
+			}
+		}
+	})
+}
+
+// BenchmarkVerdictCache reads cached verdicts, the path every repeat check
+// of a domain takes.
+func BenchmarkVerdictCache(b *testing.B) {
+	g, allow, _ := benchGuard(b, 10000)
+	for _, d := range allow {
+		g.setVerdictCached(d, LayerResult{Layer: LayerAllowlist, Action: ActionAllow, Reason: ReasonAllowlisted})
+	}
+	runParallel64(b, func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			if _, ok := g.getVerdictCached(allow[i%len(allow)]); !ok {
+				b.Fatalf("%s not cached", allow[i%len(allow)])
+			}
+		}
+	})
+}
+
+// BenchmarkOverrideLookup reads the override store's published snapshot.
+func BenchmarkOverrideLookup(b *testing.B) {
+	s, err := NewOverrideStore("")
+	if err != nil {
+		b.Fatal(err)
+	}
+	domains := make([]string, 1000)
+	for i := range domains {
+		domains[i] = fmt.Sprintf("blocked%d.org", i)
+		if err := s.Deny(domains[i], "bench", "", 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+	runParallel64(b, func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			if _, ok := s.Lookup(domains[i%len(domains)]); !ok {
+				b.Fatalf("no override for %s", domains[i%len(domains)])
+			}
+		}
+	})
+}
@@ -0,0 +1,390 @@
+package emailguard
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	probeTimeout    = 5 * time.Second  // upstream health check
+	downloadTimeout = time.Minute      // whole download of a plain-text list
+	maxListBytes    = 64 << 20         // larger lists are refused
+	sourceCooldown  = 10 * time.Minute // skip a failed upstream for this long
+)
+
+// WithBlocklistSources sets the upstream disposable lists, preferred first.
+// Each source is either a git repository (URL ending in ".git") containing
+// disposable_email_blocklist.conf, or a plain-text list served over HTTP(S).
+// Sources are health-checked and tried in order, so a mirror keeps new
+// deployments protected when the primary is unreachable.
+func WithBlocklistSources(urls ...string) Option {
+	return func(c *config) error {
+		if len(urls) == 0 {
+			return errors.New("WithBlocklistSources: no sources")
+		}
+		for _, u := range urls {
+			if isGitSource(u) && errNoGit != nil {
+				return fmt.Errorf("WithBlocklistSources: %s: %w", u, errNoGit)
+			}
+		}
+		c.sources = append([]string(nil), urls...)
+		return nil
+	}
+}
+
+// blocklist is a disposable-domain set synced from the first healthy of
+// several upstream sources. Each source gets its own directory so failing
+// over to a mirror never replaces the primary's copy.
+type blocklist struct {
+	sources []string
+	dir     string
+
+	once sync.Once
+	set  atomic.Pointer[domainSet] // frozen; readers never lock
+
+	// runtime edits are laid over every synced list
+	editMu  sync.Mutex
+	base    *domainSet // last synced list, before edits
+	overlay *overlay
+
+	healthMu  sync.Mutex
+	downUntil map[string]time.Time // source -> skip until
+
+	diskMu sync.Mutex // fetches and garbage collection take turns on disk
+
+	store Store // optional snapshot used when every source and disk copy fails
+
+	fsys   fs.FS // non-nil: the only source, see WithBlocklistFS
+	fsName string
+
+	staleFault bool // refreshes fail; see Faults.StaleList
+
+	statusMu sync.Mutex
+	status   refreshStatus
+}
+
+// refreshStatus tracks upstream syncs for alerting.
+type refreshStatus struct {
+	lastSuccess  time.Time
+	failingSince time.Time // zero while healthy
+	failures     int       // consecutive
+	lastErr      error
+}
+
+func newBlocklist(dir string, sources []string) *blocklist {
+	return &blocklist{sources: sources, dir: dir, downUntil: make(map[string]time.Time)}
+}
+
+// load returns the disposable set, syncing it on first use.
+func (b *blocklist) load() *domainSet {
+	if s := b.set.Load(); s != nil {
+		return s
+	}
+	b.once.Do(func() {
+		b.install(b.sync())
+	})
+	return b.set.Load()
+}
+
+// version is the loaded list's content hash, "" before the first sync.
+func (b *blocklist) version() string {
+	if s := b.set.Load(); s != nil {
+		return s.version
+	}
+	return ""
+}
+
+// sourceDir is where source i is materialized; the primary keeps the
+// historical location.
+func (b *blocklist) sourceDir(i int) string {
+	if i == 0 {
+		return b.dir
+	}
+	return fmt.Sprintf("%s-mirror%d", b.dir, i)
+}
+
+// sync returns the first list it can read from the sources. If every
+// upstream fails it falls back to the freshest copy already on disk and,
+// failing that, to an empty set.
+func (b *blocklist) sync() *domainSet {
+	set, err := b.fetch()
+	if err == nil {
+		return set
+	}
+	fmt.Fprintf(os.Stderr, "WARN: cannot refresh blocklist from any source: %v\n", err)
+	if set, fp, ok := b.freshestOnDisk(); ok {
+		fmt.Fprintf(os.Stderr, "WARN: using stale blocklist %s\n", fp)
+		return set
+	}
+	if b.store != nil {
+		if domains, err := b.store.LoadList(blocklistListName); err == nil {
+			fmt.Fprintf(os.Stderr, "WARN: using blocklist snapshot from store (%d domains)\n", len(domains))
+			return newDomainSet(domains)
+		}
+	}
+	return newDomainSet(nil)
+}
+
+// refresh re-syncs a loaded list. On failure the current list stays.
+func (b *blocklist) refresh() error {
+	if b.set.Load() == nil {
+		b.load()
+		return b.refreshStatus().lastErr
+	}
+	set, err := b.fetch()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "WARN: cannot refresh blocklist from any source: %v\n", err)
+		return err
+	}
+	b.install(set)
+	return nil
+}
+
+// install makes a synced list current, with the runtime edits applied.
+func (b *blocklist) install(base *domainSet) {
+	b.editMu.Lock()
+	defer b.editMu.Unlock()
+	b.base = base
+	b.set.Store(b.overlay.apply(base))
+}
+
+// setOverlay replaces the runtime edits, re-applying them to the current
+// list if one is loaded.
+func (b *blocklist) setOverlay(o *overlay) {
+	b.editMu.Lock()
+	defer b.editMu.Unlock()
+	b.overlay = o
+	if b.base != nil {
+		b.set.Store(o.apply(b.base))
+	}
+}
+
+func (b *blocklist) refreshStatus() refreshStatus {
+	b.statusMu.Lock()
+	defer b.statusMu.Unlock()
+	return b.status
+}
+
+// fetch walks the sources in order and returns the first list it can read.
+func (b *blocklist) fetch() (set *domainSet, err error) {
+	b.diskMu.Lock()
+	defer b.diskMu.Unlock()
+	defer func() {
+		b.statusMu.Lock()
+		defer b.statusMu.Unlock()
+		st := &b.status
+		if err == nil {
+			*st = refreshStatus{lastSuccess: time.Now()}
+			return
+		}
+		if st.failures == 0 {
+			st.failingSince = time.Now()
+		}
+		st.failures++
+		st.lastErr = err
+	}()
+
+	if b.staleFault && b.set.Load() != nil {
+		return nil, fmt.Errorf("refresh: %w", errInjected)
+	}
+	if b.fsys != nil {
+		return readBlocklistFS(b.fsys, b.fsName)
+	}
+	unlock, err := lockDir(b.dir)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	var errs []error
+	for i, src := range b.sources {
+		dir := b.sourceDir(i)
+		fp := filepath.Join(dir, blocklistFile)
+
+		// recently refreshed copy: no network at all
+		if fresh(filepath.Join(dir, ".lastpull"), pullCooldown) {
+			if set, err := readBlocklist(fp); err == nil {
+				b.snapshot(set)
+				return set, nil
+			}
+		}
+
+		if !b.healthy(src) {
+			errs = append(errs, fmt.Errorf("%s: skipped, marked down", src))
+			continue
+		}
+		if err := probeSource(src); err != nil {
+			b.markDown(src)
+			errs = append(errs, fmt.Errorf("%s: health check: %w", src, err))
+			continue
+		}
+		if err := fetchSource(src, dir); err != nil {
+			b.markDown(src)
+			errs = append(errs, fmt.Errorf("%s: %w", src, err))
+			continue
+		}
+		set, err := readBlocklist(fp)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		b.snapshot(set)
+		return set, nil
+	}
+	if len(errs) == 0 {
+		return nil, errors.New("no sources")
+	}
+	return nil, errors.Join(errs...)
+}
+
+// snapshot saves a freshly synced set to the store, if any.
+func (b *blocklist) snapshot(set *domainSet) {
+	if b.store == nil {
+		return
+	}
+	domains := make([]string, 0, set.Len())
+	set.all(func(d string) { domains = append(domains, d) })
+	warnStore("save blocklist", b.store.SaveList(blocklistListName, domains))
+}
+
+func (b *blocklist) freshestOnDisk() (*domainSet, string, bool) {
+	best, ok := b.freshestCopy()
+	if !ok {
+		return nil, "", false
+	}
+	set, err := readBlocklist(best)
+	if err != nil {
+		return nil, "", false
+	}
+	return set, best, true
+}
+
+// freshestCopy returns the path of the most recently written list on disk.
+func (b *blocklist) freshestCopy() (string, bool) {
+	var best string
+	var bestMod time.Time
+	for i := range b.sources {
+		fp := filepath.Join(b.sourceDir(i), blocklistFile)
+		if fi, err := os.Stat(fp); err == nil && fi.ModTime().After(bestMod) {
+			best, bestMod = fp, fi.ModTime()
+		}
+	}
+	return best, best != ""
+}
+
+func (b *blocklist) healthy(src string) bool {
+	b.healthMu.Lock()
+	defer b.healthMu.Unlock()
+	return time.Now().After(b.downUntil[src])
+}
+
+func (b *blocklist) markDown(src string) {
+	b.healthMu.Lock()
+	b.downUntil[src] = time.Now().Add(sourceCooldown)
+	b.healthMu.Unlock()
+}
+
+// --- sources ---
+
+func isGitSource(src string) bool {
+	return strings.HasSuffix(src, ".git")
+}
+
+// probeSource is a cheap reachability check: ls-remote for git, HEAD for
+// plain lists.
+func probeSource(src string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+
+	if isGitSource(src) {
+		return probeGit(ctx, src)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, src, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("HEAD %s: %s", src, resp.Status)
+	}
+	return nil
+}
+
+func fetchSource(src, dir string) error {
+	if isGitSource(src) {
+		return ensureRepo(src, dir, "", "")
+	}
+	return downloadList(src, dir)
+}
+
+// downloadList fetches a plain-text list into dir/blocklistFile. A stalled
+// or oversized download fails, so the next source gets its turn.
+func downloadList(url, dir string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), downloadTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxListBytes+1))
+	if err != nil {
+		return fmt.Errorf("GET %s: %w", url, err)
+	}
+	if len(b) > maxListBytes {
+		return fmt.Errorf("GET %s: list larger than %d bytes", url, maxListBytes)
+	}
+	if err := writeFileAtomic(filepath.Join(dir, blocklistFile), b, 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, ".lastpull"), []byte(time.Now().Format(time.RFC3339Nano)), 0o644)
+}
+
+// readBlocklist parses a one-domain-per-line list, skipping comments.
+func readBlocklist(fp string) (*domainSet, error) {
+	f, err := os.Open(fp)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	lines, err := readList(f)
+	if err != nil {
+		return nil, fmt.Errorf("scanning blocklist %s: %w", fp, err)
+	}
+	return newDomainSet(lines), nil
+}
+
+// readList reads a one-domain-per-line list, skipping comments.
+func readList(r io.Reader) ([]string, error) {
+	lines := make([]string, 0, 40000)
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, sc.Err()
+}
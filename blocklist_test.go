@@ -0,0 +1,47 @@
+package emailguard
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDownloadList(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/list.txt":
+			w.Write([]byte("mailinator.com\n"))
+		case "/huge.txt":
+			line := bytes.Repeat([]byte("x"), 1<<20)
+			for range maxListBytes>>20 + 1 {
+				if _, err := w.Write(line); err != nil {
+					return
+				}
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	if err := downloadList(srv.URL+"/list.txt", dir); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, blocklistFile)); string(b) != "mailinator.com\n" {
+		t.Errorf("list reads back %q", b)
+	}
+	if err := downloadList(srv.URL+"/huge.txt", dir); err == nil || !strings.Contains(err.Error(), "larger than") {
+		t.Errorf("oversized list: got %v, want a size error", err)
+	}
+	if err := downloadList(srv.URL+"/missing.txt", dir); err == nil {
+		t.Error("404: got nil error")
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, blocklistFile)); string(b) != "mailinator.com\n" {
+		t.Errorf("failed downloads replaced the list: %.40q", b)
+	}
+}
@@ -0,0 +1,72 @@
+package emailguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrBudgetExhausted means the calling tenant used up its DNS/SMTP budget.
+// It wraps ErrTemporary: the check is inconclusive, not a rejection.
+var ErrBudgetExhausted = fmt.Errorf("%w: tenant budget exhausted", ErrTemporary)
+
+// TenantBudget is a token bucket: one token per DNS lookup or SMTP
+// conversation, refilled every Every, holding at most Burst.
+type TenantBudget struct {
+	Every time.Duration
+	Burst int
+}
+
+// WithTenantBudget gives every tenant (see ForTenant) its own budget of
+// network work, so one tenant's bulk import cannot starve another's live
+// signups. Cache hits are free; checks without a tenant are not budgeted.
+// perTenant overrides the default for specific tenants.
+func WithTenantBudget(def TenantBudget, perTenant map[string]TenantBudget) Option {
+	return func(c *config) error {
+		if err := def.validate(); err != nil {
+			return fmt.Errorf("WithTenantBudget: %w", err)
+		}
+		for t, b := range perTenant {
+			if err := b.validate(); err != nil {
+				return fmt.Errorf("WithTenantBudget: tenant %q: %w", t, err)
+			}
+		}
+		c.tenantBudget = &def
+		c.tenantBudgets = make(map[string]TenantBudget, len(perTenant))
+		for t, b := range perTenant {
+			c.tenantBudgets[t] = b
+		}
+		return nil
+	}
+}
+
+func (b TenantBudget) validate() error {
+	if b.Every <= 0 || b.Burst <= 0 {
+		return errors.New("Every and Burst must be positive")
+	}
+	return nil
+}
+
+// spend charges one unit of network work to the tenant in ctx.
+func (g *Guard) spend(ctx context.Context) error {
+	if g.cfg.tenantBudget == nil {
+		return nil
+	}
+	tenant := checkOptsFrom(ctx).tenant
+	if tenant == "" {
+		return nil
+	}
+	v, ok := g.budgets.Load(tenant)
+	if !ok {
+		b, ok := g.cfg.tenantBudgets[tenant]
+		if !ok {
+			b = *g.cfg.tenantBudget
+		}
+		v, _ = g.budgets.LoadOrStore(tenant, newTokenBucket(b.Every, b.Burst))
+	}
+	if !v.(*tokenBucket).allow() {
+		return ErrBudgetExhausted
+	}
+	return nil
+}
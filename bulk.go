@@ -0,0 +1,130 @@
+package emailguard
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// bulkChunk is how many rows BulkValidate holds at once.
+const bulkChunk = 512
+
+// bulkColumns are appended to every output row.
+var bulkColumns = []string{"outcome", "reason", "detail"}
+
+// BulkValidate cleans a list with the default guard.
+func BulkValidate(r io.Reader, w io.Writer) error {
+	return defaultGuard.Load().BulkValidate(r, w)
+}
+
+// BulkValidate reads addresses from r, one per line or as CSV, and streams
+// every row to w as CSV with outcome, reason and detail columns appended.
+// For CSV the email column is the one headed "email", or else the first
+// field holding an '@'; input without a header gets one. Rows are checked
+// in chunks with ValidateBatch, so memory stays flat however long the list
+// is. A display name around the address, as in "Jane Doe"
+// <jane@company.com>, is dropped. In privacy mode the address is written
+// pseudonymized.
+func (g *Guard) BulkValidate(r io.Reader, w io.Writer) error {
+	return g.BulkValidateContext(context.Background(), r, w, BatchOptions{})
+}
+
+// BulkValidateContext is BulkValidate bounded by ctx, with opts passed to
+// ValidateBatch.
+func (g *Guard) BulkValidateContext(ctx context.Context, r io.Reader, w io.Writer, opts BatchOptions) error {
+	t := NewCSVTable(w)
+	if err := g.BulkValidateTable(ctx, r, t, opts); err != nil {
+		return err
+	}
+	return t.Close()
+}
+
+// BulkValidateTable is BulkValidateContext writing to t, e.g. an XLSX or
+// Parquet table from NewTableWriter. It does not close t.
+func (g *Guard) BulkValidateTable(ctx context.Context, r io.Reader, t TableWriter, opts BatchOptions) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	cr.TrimLeadingSpace = true
+	cr.Comment = '#'
+
+	col := -1
+	rows := make([][]string, 0, bulkChunk)
+	flush := func() error {
+		emails := make([]string, len(rows))
+		for i, row := range rows {
+			if col < len(row) {
+				emails[i] = addrSpec(row[col])
+			}
+		}
+		for i, res := range g.ValidateBatch(ctx, emails, opts) {
+			row := rows[i]
+			if col < len(row) {
+				row[col] = res.Verdict.Email
+			}
+			v := res.Verdict
+			if err := t.WriteRow(append(row, v.Outcome.String(), string(v.Reason), v.Detail)); err != nil {
+				return err
+			}
+		}
+		rows = rows[:0]
+		return nil
+	}
+
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if col < 0 {
+			var header bool
+			col, header = emailColumn(rec)
+			hdr := rec
+			if !header {
+				hdr = make([]string, len(rec))
+				for i := range hdr {
+					hdr[i] = "column" + strconv.Itoa(i+1)
+				}
+				hdr[col] = "email"
+			}
+			if err := t.WriteRow(append(hdr[:len(hdr):len(hdr)], bulkColumns...)); err != nil {
+				return err
+			}
+			if header {
+				continue
+			}
+		}
+		rows = append(rows, rec)
+		if len(rows) == bulkChunk {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+	return flush()
+}
+
+// emailColumn finds the email column from the first record and reports
+// whether that record is a header.
+func emailColumn(rec []string) (col int, header bool) {
+	for i, f := range rec {
+		if strings.Contains(f, "@") {
+			return i, false
+		}
+	}
+	for i, f := range rec {
+		if strings.EqualFold(strings.TrimSpace(f), "email") {
+			return i, true
+		}
+	}
+	return 0, false
+}
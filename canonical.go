@@ -0,0 +1,154 @@
+package emailguard
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ProviderRule describes how a mail provider maps addresses to mailboxes,
+// for Canonicalize, and which mailbox names it allows at all.
+type ProviderRule struct {
+	Domains    []string // domains the provider receives mail for
+	Canonical  string   // domain all of them are rewritten to; "" keeps each
+	TagSep     string   // starts a subaddress tag, e.g. "+"; "" for none
+	IgnoreDots bool     // dots in the local part are insignificant
+	// CaseSensitive keeps the local part's case; most providers ignore it
+	CaseSensitive bool
+
+	// The rest constrain the mailbox name, the local part less any tag.
+	// Addresses breaking them cannot exist and are rejected as syntax
+	// errors before any DNS work. Zero values impose nothing.
+	MinLocal, MaxLocal int    // length bounds, not counting ignored dots
+	LocalChars         string // allowed besides ASCII letters and digits; "" for anything RFC 5321 allows
+	LetterFirst        bool   // the name must start with a letter
+}
+
+// defaultProviderRules only list behavior the providers document.
+var defaultProviderRules = []ProviderRule{
+	{Domains: []string{"gmail.com", "googlemail.com"}, Canonical: "gmail.com", TagSep: "+", IgnoreDots: true,
+		MinLocal: 6, MaxLocal: 30, LocalChars: "."},
+	{Domains: []string{"outlook.com", "hotmail.com", "live.com", "msn.com"}, TagSep: "+",
+		LocalChars: "._-", LetterFirst: true},
+	{Domains: []string{"icloud.com", "me.com", "mac.com"}, Canonical: "icloud.com", TagSep: "+"},
+	{Domains: []string{"fastmail.com", "fastmail.fm"}, TagSep: "+"},
+	{Domains: []string{"proton.me", "protonmail.com", "pm.me"}, TagSep: "+"},
+	{Domains: []string{"yahoo.com"}, MinLocal: 4, MaxLocal: 32, LocalChars: "._-", LetterFirst: true},
+	{Domains: []string{"yandex.ru", "yandex.com", "ya.ru"}, Canonical: "yandex.ru", TagSep: "+"},
+}
+
+// WithProviderRules adds rules for Canonicalize. A rule replaces any
+// earlier rule, built-in or not, for the same domain.
+func WithProviderRules(rules ...ProviderRule) Option {
+	return func(c *config) error {
+		for _, r := range rules {
+			if len(r.Domains) == 0 {
+				return errors.New("WithProviderRules: rule without domains")
+			}
+			if len(r.TagSep) > 1 {
+				return fmt.Errorf("WithProviderRules: tag separator %q must be a single character", r.TagSep)
+			}
+			if r.MinLocal < 0 || r.MaxLocal < 0 || r.MaxLocal > 0 && r.MaxLocal < r.MinLocal {
+				return fmt.Errorf("WithProviderRules: bad local part length bounds %d..%d", r.MinLocal, r.MaxLocal)
+			}
+		}
+		c.providerRules = append(c.providerRules, rules...)
+		return nil
+	}
+}
+
+// providerIndex maps each domain to its rule, later rules winning.
+func providerIndex(rules []ProviderRule) map[string]ProviderRule {
+	m := make(map[string]ProviderRule)
+	for _, r := range rules {
+		for _, d := range r.Domains {
+			m[normDomain(d)] = r
+		}
+	}
+	return m
+}
+
+// Canonicalize canonicalizes email with the default guard's rules.
+func Canonicalize(email string) string {
+	return defaultGuard.Load().Canonicalize(email)
+}
+
+// Canonicalize returns the form of email that identifies its mailbox, so
+// deduplication catches j.doe+spam@gmail.com as jdoe@gmail.com. The domain
+// is always lowercased; the local part is rewritten only for providers in
+// the rules table (see WithProviderRules), since elsewhere tags, dots and
+// case may be significant. Malformed addresses are returned trimmed but
+// otherwise unchanged.
+func (g *Guard) Canonicalize(email string) string {
+	local, domain, ok := splitEmail(email)
+	if !ok {
+		return strings.TrimSpace(email)
+	}
+	r, known := g.providers[domain]
+	if !known || strings.HasPrefix(local, `"`) {
+		return local + "@" + domain
+	}
+	if r.TagSep != "" {
+		if base, _, _ := strings.Cut(local, r.TagSep); base != "" {
+			local = base
+		}
+	}
+	if r.IgnoreDots {
+		local = strings.ReplaceAll(local, ".", "")
+	}
+	if !r.CaseSensitive {
+		local = strings.ToLower(local)
+	}
+	if r.Canonical != "" {
+		domain = normDomain(r.Canonical)
+	}
+	return local + "@" + domain
+}
+
+// checkProviderLocal checks local against the mailbox naming rules of
+// domain's provider, if it has any.
+func (g *Guard) checkProviderLocal(local, domain string) error {
+	r, known := g.providers[domain]
+	if !known {
+		return nil
+	}
+	name := local
+	if r.TagSep != "" {
+		if base, _, _ := strings.Cut(local, r.TagSep); base != "" {
+			name = base
+		}
+	}
+	if r.LocalChars != "" {
+		for i := 0; i < len(name); i++ {
+			if c := name[i]; !isAlnum(c) && !strings.ContainsRune(r.LocalChars, rune(c)) {
+				return fmt.Errorf("%s does not allow %q in addresses", domain, c)
+			}
+		}
+	}
+	if r.LetterFirst && !isLetter(name[0]) {
+		return fmt.Errorf("%s addresses start with a letter", domain)
+	}
+	n := len(name)
+	if r.IgnoreDots {
+		n -= strings.Count(name, ".")
+	}
+	if r.MinLocal > 0 && n < r.MinLocal || r.MaxLocal > 0 && n > r.MaxLocal {
+		return fmt.Errorf("%s addresses are %s characters long", domain, lengthRange(r.MinLocal, r.MaxLocal))
+	}
+	return nil
+}
+
+func lengthRange(lo, hi int) string {
+	switch {
+	case lo > 0 && hi > 0:
+		return fmt.Sprintf("%d to %d", lo, hi)
+	case lo > 0:
+		return fmt.Sprintf("at least %d", lo)
+	default:
+		return fmt.Sprintf("at most %d", hi)
+	}
+}
+
+func isLetter(c byte) bool { return c|0x20 >= 'a' && c|0x20 <= 'z' }
+
+func isAlnum(c byte) bool { return isLetter(c) || c >= '0' && c <= '9' }
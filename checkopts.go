@@ -0,0 +1,113 @@
+package emailguard
+
+import "context"
+
+// CheckOption adjusts a single Validate call.
+type CheckOption func(*checkOptions)
+
+type checkOptions struct {
+	tenant   string // "" for untracked callers
+	priority Priority
+	trace    bool
+	locale   string // BCP 47 tag for typo suggestions
+	dns      map[string]ResolvedDNS
+	corrID   string
+	campaign string
+	failure  *failureMemo // shared by a ValidateBatch domain group
+}
+
+// failureMemo carries a domain's failed evaluation from one address of a
+// ValidateBatch group to the next, so a domain whose lookups time out is
+// waited out once rather than once per address.
+type failureMemo struct {
+	res *LayerResult
+}
+
+// sharingFailure makes checks of one domain share fm.
+func sharingFailure(fm *failureMemo) CheckOption {
+	return func(o *checkOptions) { o.failure = fm }
+}
+
+// ForTenant attributes the check to tenant, so its DNS and SMTP work is
+// charged to that tenant's budget (see WithTenantBudget).
+func ForTenant(tenant string) CheckOption {
+	return func(o *checkOptions) { o.tenant = tenant }
+}
+
+// CorrelationID tags the check with the caller's request or correlation ID.
+// It is copied to Verdict.CorrelationID and from there to decision history,
+// recheck callbacks and warnings logged while recording the verdict, so a
+// rejected signup can be followed from the app through the guard.
+func CorrelationID(id string) CheckOption {
+	return func(o *checkOptions) { o.corrID = id }
+}
+
+// Campaign attributes the check to a marketing campaign or other lead
+// source. It is copied to Verdict.Campaign and decision history, where
+// FunnelReport breaks the funnel down by it.
+func Campaign(name string) CheckOption {
+	return func(o *checkOptions) { o.campaign = name }
+}
+
+// checkOptsKey carries checkOptions down to the lookups through the context.
+type checkOptsKey struct{}
+
+func withCheckOptions(ctx context.Context, opts []CheckOption) context.Context {
+	if len(opts) == 0 {
+		return ctx
+	}
+	var o checkOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return context.WithValue(ctx, checkOptsKey{}, &o)
+}
+
+func checkOptsFrom(ctx context.Context) *checkOptions {
+	if o, ok := ctx.Value(checkOptsKey{}).(*checkOptions); ok {
+		return o
+	}
+	return &checkOptions{}
+}
+
+// ResolvedDNS is DNS data the caller already holds for Domain, e.g. from
+// an upstream mail gateway. A nil field is looked up as usual; an empty
+// non-nil one means the domain has no such records.
+type ResolvedDNS struct {
+	Domain string
+	MX     []string // MX hosts in preference order
+	TXT    []string
+}
+
+// UsingDNS supplies pre-resolved records so the check skips its own
+// lookups for them, avoiding duplicate work and answers that disagree with
+// the caller's. Pass it once per domain (the address's, or its parent's
+// under WithParentDomainMX). Checks with injected data neither read nor
+// fill the verdict cache.
+func UsingDNS(r ResolvedDNS) CheckOption {
+	domain := normDomain(r.Domain)
+	return func(o *checkOptions) {
+		if o.dns == nil {
+			o.dns = make(map[string]ResolvedDNS)
+		}
+		o.dns[domain] = r
+	}
+}
+
+// injectedMX returns MX hosts supplied with UsingDNS.
+func injectedMX(ctx context.Context, domain string) ([]string, bool) {
+	r, ok := checkOptsFrom(ctx).dns[domain]
+	if !ok || r.MX == nil {
+		return nil, false
+	}
+	return r.MX, true
+}
+
+// injectedTXT returns TXT records supplied with UsingDNS.
+func injectedTXT(ctx context.Context, domain string) ([]string, bool) {
+	r, ok := checkOptsFrom(ctx).dns[domain]
+	if !ok || r.TXT == nil {
+		return nil, false
+	}
+	return r.TXT, true
+}
@@ -0,0 +1,161 @@
+package emailguard
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Layers of the individual checks, for pipelines assembled with
+// WithPipeline.
+const (
+	LayerSyntax       = "syntax"
+	LayerMX           = "mx"
+	LayerMXKeywords   = "mx_keywords"
+	LayerDisposableMX = "disposable_mx"
+	LayerParked       = "parked"
+)
+
+// Individual checks. The default pipeline runs the MX checks together as
+// the heuristics stage; WithPipeline arranges them freely. Each only ever
+// denies, so a custom pipeline allows what none of its stages objects to.
+var (
+	// SyntaxCheck rejects domains that are not valid hostnames.
+	SyntaxCheck Stage = methodStage(LayerSyntax, (*Guard).evalSyntax)
+	// DisposableCheck rejects domains on the disposable blocklist.
+	DisposableCheck Stage = methodStage(LayerBlocklist, (*Guard).evalBlocklist)
+	// MXCheck rejects domains without MX records, or with fewer than
+	// WithMinMX.
+	MXCheck Stage = methodStage(LayerMX, (*Guard).evalMX)
+	// MXKeywordCheck rejects domains whose MX hosts look like masking or
+	// forwarding services.
+	MXKeywordCheck Stage = methodStage(LayerMXKeywords, (*Guard).evalMXKeywords)
+	// DisposableMXCheck rejects domains whose MX hosts belong to a
+	// disposable domain or are on the MX blocklist.
+	DisposableMXCheck Stage = methodStage(LayerDisposableMX, (*Guard).evalDisposableMX)
+	// ParkedCheck rejects parked domains under
+	// WithParkedDomains(ActionDeny).
+	ParkedCheck Stage = methodStage(LayerParked, (*Guard).evalParked)
+	// ReputationCheck rejects domains on a domain blocklist under
+	// WithDomainBlocklists(ActionDeny).
+	ReputationCheck Stage = methodStage(LayerReputation, (*Guard).evalReputation)
+)
+
+// DefaultStages returns the default pipeline's stages in precedence order,
+// as a starting point for WithPipeline.
+func DefaultStages() []Stage {
+	p := defaultPipeline()
+	out := make([]Stage, len(p))
+	for i, s := range p {
+		out[i] = s.Stage
+	}
+	return out
+}
+
+func (g *Guard) evalSyntax(ctx context.Context, domain string) (Finding, error) {
+	if err := checkHostname(domain); err != nil {
+		return Finding{ActionDeny, ReasonSyntax, domain, err.Error()}, nil
+	}
+	return noOpinion("valid hostname"), nil
+}
+
+// checkHostname applies the RFC 1035 limits to a normalized domain. IDNs
+// must already be in their ASCII (xn--) form.
+func checkHostname(domain string) error {
+	if len(domain) > 253 {
+		return fmt.Errorf("domain longer than 253 characters")
+	}
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return fmt.Errorf("domain %q has no TLD", domain)
+	}
+	for _, l := range labels {
+		switch {
+		case l == "":
+			return fmt.Errorf("empty label in %q", domain)
+		case len(l) > 63:
+			return fmt.Errorf("label %.20q... longer than 63 characters", l)
+		case l[0] == '-' || l[len(l)-1] == '-':
+			return fmt.Errorf("label %q starts or ends with a hyphen", l)
+		}
+		for i := 0; i < len(l); i++ {
+			if c := l[i]; !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+				return fmt.Errorf("invalid character %q in %q", c, domain)
+			}
+		}
+	}
+	return nil
+}
+
+// resolveMX returns domain's MX hosts or, under WithParentDomainMX, its
+// parent's when it has none; via names the parent in that case.
+func (g *Guard) resolveMX(ctx context.Context, domain string) (hosts []string, via string, err error) {
+	if hosts, err = g.lookupMX(ctx, domain); err != nil || len(hosts) > 0 || !g.cfg.parentMX {
+		return hosts, "", err
+	}
+	if via = parentDomain(domain); via == "" {
+		return nil, "", nil
+	}
+	if hosts, err = g.lookupMX(ctx, via); err != nil || len(hosts) == 0 {
+		return nil, "", err
+	}
+	return hosts, via, nil
+}
+
+func (g *Guard) evalMX(ctx context.Context, domain string) (Finding, error) {
+	mxHosts, _, err := g.resolveMX(ctx, domain)
+	if err != nil {
+		return noOpinion("MX lookup failed"), err
+	}
+	if len(mxHosts) == 0 {
+		return Finding{ActionDeny, ReasonNoMX, "", "no MX records"}, nil
+	}
+	if n := distinctHosts(mxHosts); n < g.cfg.minMX {
+		return Finding{ActionDeny, ReasonInsufficientMX, "", fmt.Sprintf("%d MX host(s), policy requires %d", n, g.cfg.minMX)}, nil
+	}
+	return noOpinion("MX " + strings.Join(mxHosts, ", ")), nil
+}
+
+func (g *Guard) evalMXKeywords(ctx context.Context, domain string) (Finding, error) {
+	mxHosts, via, err := g.resolveMX(ctx, domain)
+	if err != nil {
+		return noOpinion("MX lookup failed"), err
+	}
+	mxHosts = g.scopedMX(ctx, cmp.Or(via, domain), mxHosts)
+	for _, h := range mxHosts {
+		lh := normDomain(h)
+		for _, kw := range g.cfg.mxBadKeywords {
+			if strings.Contains(lh, kw) {
+				return Finding{ActionDeny, ReasonMaskedMX, lh, fmt.Sprintf("MX %s matches %q", lh, kw)}, nil
+			}
+		}
+	}
+	return noOpinion("no masking keywords in MX"), nil
+}
+
+func (g *Guard) evalDisposableMX(ctx context.Context, domain string) (Finding, error) {
+	mxHosts, via, err := g.resolveMX(ctx, domain)
+	if err != nil {
+		return noOpinion("MX lookup failed"), err
+	}
+	mxHosts = g.scopedMX(ctx, cmp.Or(via, domain), mxHosts)
+	for _, h := range mxHosts {
+		lh := normDomain(h)
+		if m, ok := g.blockedMX(lh); ok {
+			return Finding{ActionDeny, ReasonBlockedMX, m, fmt.Sprintf("MX %s is on the MX blocklist as %s", lh, m)}, nil
+		}
+	}
+	set := g.blocklist.load()
+	for _, h := range mxHosts {
+		lh := normDomain(h)
+		// MX hosts are named under their domain, so this always inherits
+		if m, ok := g.cfg.subdomains.match(set, lh, true); ok {
+			if isListRule(m) {
+				return Finding{ActionDeny, ReasonDisposableMX, m, fmt.Sprintf("MX %s matches disposable rule %s", lh, m)}, nil
+			}
+			return Finding{ActionDeny, ReasonDisposableMX, m, fmt.Sprintf("MX %s belongs to disposable %s", lh, m)}, nil
+		}
+	}
+	return noOpinion("no disposable MX"), nil
+}
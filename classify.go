@@ -0,0 +1,88 @@
+package emailguard
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Classification says what kind of mailbox provider serves a domain, for
+// policies a yes/no verdict cannot express, e.g. requiring a corporate
+// address for enterprise trials while accepting free ones elsewhere.
+type Classification string
+
+const (
+	ClassCorporate  Classification = "corporate"  // an organisation's own mail domain
+	ClassFree       Classification = "free"       // free or consumer provider, e.g. gmail.com
+	ClassDisposable Classification = "disposable" // temporary mail
+	ClassPlatform   Classification = "platform"   // subdomain a platform assigned, e.g. tenant.onmicrosoft.com
+	ClassUnknown    Classification = "unknown"    // not enough evidence, e.g. no MX known
+)
+
+// extraFreeProviders are consumer providers not already listed for typo
+// suggestions or Canonicalize.
+var extraFreeProviders = []string{
+	"ymail.com", "rocketmail.com", "mail.ru", "tutanota.com", "tuta.io",
+	"hushmail.com", "inbox.com", "rediffmail.com", "seznam.cz", "gmx.at",
+	"gmx.ch", "yahoo.de", "hotmail.de", "outlook.de", "zohomail.com",
+}
+
+// defaultFreeProviders collects the built-in consumer providers.
+func defaultFreeProviders() []string {
+	out := slices.Clone(popularDomains)
+	for _, ds := range localeDomains {
+		out = append(out, ds...)
+	}
+	for _, r := range defaultProviderRules {
+		out = append(out, r.Domains...)
+	}
+	out = append(out, extraFreeProviders...)
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+// WithFreeProviders adds domains to classify as ClassFree, e.g. regional
+// ISPs that hand out mailboxes with every connection.
+func WithFreeProviders(domains ...string) Option {
+	return func(c *config) error {
+		for _, d := range domains {
+			d = normDomain(d)
+			if err := checkHostname(d); err != nil {
+				return fmt.Errorf("WithFreeProviders: %w", err)
+			}
+			c.freeProviders = append(c.freeProviders, d)
+		}
+		return nil
+	}
+}
+
+// classify derives v's Classification from its outcome and MX data.
+func (g *Guard) classify(v *Verdict) Classification {
+	if disposableReason(v.Reason) {
+		return ClassDisposable
+	}
+	if _, ok := g.platformSuffix(v.Domain); ok {
+		return ClassPlatform
+	}
+	if g.isFreeProvider(v.Domain) {
+		return ClassFree
+	}
+	if v.Outcome == OutcomeAllow && (len(v.MXHosts) > 0 || v.Reason == ReasonAllowlisted) {
+		return ClassCorporate
+	}
+	return ClassUnknown
+}
+
+// disposableReason reports whether r rejects a disposable address.
+func disposableReason(r Reason) bool {
+	return r == ReasonDisposable || r == ReasonTempMailInfra || r == ReasonDisposableMX
+}
+
+// isFreeProvider reports whether domain or its registrable domain is a
+// free provider, so mail.yahoo.com counts as well.
+func (g *Guard) isFreeProvider(domain string) bool {
+	if g.free.contains(domain) {
+		return true
+	}
+	rd, err := registrableDomain(domain)
+	return err == nil && rd != domain && g.free.contains(rd)
+}
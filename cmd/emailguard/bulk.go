@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"io"
+	"os"
+
+	"github.com/vandit1604/emailguard"
+)
+
+// runBulk cleans a list of addresses, one per line or CSV, streaming
+// annotated rows as CSV, XLSX or Parquet.
+func runBulk(args []string) error {
+	fs := flag.NewFlagSet("bulk", flag.ExitOnError)
+	in := fs.String("in", "-", "input list (- for stdin)")
+	out := fs.String("out", "-", "output CSV (- for stdout)")
+	workers := fs.Int("concurrency", 16, "domains checked at once")
+	format := fs.String("format", "", "csv, xlsx or parquet (default: from -out extension)")
+	fs.Parse(args)
+	if *format == "" {
+		*format = emailguard.TableFormat(*out)
+	}
+
+	var r io.Reader = os.Stdin
+	if *in != "-" {
+		f, err := os.Open(*in)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	var w io.Writer = os.Stdout
+	if *out != "-" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	bw := bufio.NewWriter(w)
+	t, err := emailguard.NewTableWriter(bw, *format)
+	if err != nil {
+		return err
+	}
+
+	g, err := emailguard.New()
+	if err != nil {
+		return err
+	}
+	if err := g.BulkValidateTable(context.Background(), bufio.NewReader(r), t, emailguard.BatchOptions{Concurrency: *workers}); err != nil {
+		return err
+	}
+	if err := t.Close(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/vandit1604/emailguard"
+)
+
+func runCheck(args []string) error {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: emailguard check <email>...")
+		fmt.Fprintln(fs.Output(), `each argument may be an address list, e.g. '"Jane Doe" <jane@company.com>, bob@company.com'`)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("no addresses given")
+	}
+
+	g, err := emailguard.New()
+	if err != nil {
+		return err
+	}
+	for _, arg := range fs.Args() {
+		for _, a := range emailguard.ParseAddressList(arg) {
+			v, err := g.Check(a.Email)
+			if err != nil {
+				fmt.Printf("%s\t%s\t%v\n", a.Email, v.Outcome, err)
+				continue
+			}
+			fmt.Printf("%s\t%s\t%s\n", a.Email, v.Outcome, v.Detail)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/vandit1604/emailguard"
+)
+
+const configUsage = `usage: emailguard config print-defaults
+       emailguard config validate <file.json>`
+
+// runConfig prints the default configuration, or validates a configuration
+// file and prints it fully resolved.
+func runConfig(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%s", configUsage)
+	}
+	switch args[0] {
+	case "print-defaults":
+		return printConfig(emailguard.DefaultConfig())
+	case "validate":
+		if len(args) != 2 {
+			return fmt.Errorf("%s", configUsage)
+		}
+		c, err := loadConfig(args[1])
+		if err != nil {
+			return err
+		}
+		return printConfig(c)
+	default:
+		return fmt.Errorf("unknown config command %q\n%s", args[0], configUsage)
+	}
+}
+
+func loadConfig(path string) (emailguard.Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return emailguard.Config{}, err
+	}
+	defer f.Close()
+	c, err := emailguard.LoadConfig(f)
+	if err != nil {
+		return emailguard.Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+func printConfig(c emailguard.Config) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(c)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/vandit1604/emailguard"
+	"github.com/vandit1604/emailguard/corpus"
+)
+
+// runConformance checks the corpus against the current build and prints
+// every case whose verdict changed. With -write it saves the corpus with
+// the new verdicts, so an intended change is reviewed as a diff.
+func runConformance(args []string) error {
+	fs := flag.NewFlagSet("conformance", flag.ExitOnError)
+	path := fs.String("corpus", "", "corpus file (default: the built-in corpus)")
+	write := fs.String("write", "", "write the corpus with current verdicts to this file")
+	dataDir := fs.String("data-dir", "", "also check this blocklist directory on this platform")
+	fs.Parse(args)
+
+	if *dataDir != "" {
+		if err := emailguard.CheckDataDir(*dataDir); err != nil {
+			return fmt.Errorf("data directory %s: %w", *dataDir, err)
+		}
+		fmt.Printf("%-22s ok\n", "data directory")
+	}
+
+	cases := corpus.Default()
+	if *path != "" {
+		f, err := os.Open(*path)
+		if err != nil {
+			return err
+		}
+		cases, err = corpus.Read(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", *path, err)
+		}
+	}
+	g, err := corpus.NewGuard()
+	if err != nil {
+		return err
+	}
+	results := corpus.Run(context.Background(), g, cases)
+
+	type tally struct{ pass, total int }
+	byCategory := make(map[string]*tally)
+	var categories []string
+	failed := 0
+	for _, r := range results {
+		t := byCategory[r.Category]
+		if t == nil {
+			t = &tally{}
+			byCategory[r.Category] = t
+			categories = append(categories, r.Category)
+		}
+		t.total++
+		if r.OK() {
+			t.pass++
+			continue
+		}
+		failed++
+		fmt.Printf("%s %s\n  - %s\n  + %s\n", r.Category, r.Email, r.Want, r.Got)
+	}
+	slices.Sort(categories)
+	for _, c := range categories {
+		fmt.Printf("%-22s %d/%d\n", c, byCategory[c].pass, byCategory[c].total)
+	}
+
+	if *write != "" {
+		f, err := os.Create(*write)
+		if err != nil {
+			return err
+		}
+		if err := corpus.Write(f, corpus.Updated(results)); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d cases changed", failed, len(results))
+	}
+	return nil
+}
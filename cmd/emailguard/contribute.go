@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/vandit1604/emailguard"
+)
+
+const (
+	upstreamBlocklist = "disposable_email_blocklist.conf"
+	upstreamAllowlist = "allowlist.conf"
+)
+
+// runContribute turns locally learned disposable domains into a patch and PR
+// body for the upstream disposable-email-domains repository.
+func runContribute(args []string) error {
+	fs := flag.NewFlagSet("contribute", flag.ExitOnError)
+	learnedPath := fs.String("learned", "-", "learned domains: JSON from Guard.WriteLearned or one domain per line (- for stdin)")
+	repo := fs.String("repo", filepath.Join(os.TempDir(), "disposable-email-domains"), "checkout of the upstream repository")
+	patchOut := fs.String("patch", "emailguard-contribution.patch", "where to write the patch")
+	bodyOut := fs.String("body", "emailguard-contribution.md", "where to write the PR body")
+	fs.Parse(args)
+
+	learned, err := readLearned(*learnedPath)
+	if err != nil {
+		return err
+	}
+	listBytes, err := os.ReadFile(filepath.Join(*repo, upstreamBlocklist))
+	if err != nil {
+		return err
+	}
+	upstream := splitLines(listBytes)
+	allow, err := readSet(filepath.Join(*repo, upstreamAllowlist))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	accepted, rejected := vetContribution(learned, upstream, allow)
+	for _, r := range rejected {
+		fmt.Fprintf(os.Stderr, "skip %s: %s\n", r.domain, r.reason)
+	}
+	if len(accepted) == 0 {
+		return fmt.Errorf("nothing to contribute (%d candidates rejected)", len(rejected))
+	}
+
+	patch := insertionPatch(upstreamBlocklist, upstream, accepted)
+	if err := os.WriteFile(*patchOut, patch, 0o644); err != nil {
+		return err
+	}
+	if err := os.WriteFile(*bodyOut, prBody(accepted), 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("%d domains ready: %s, %s\n", len(accepted), *patchOut, *bodyOut)
+	return nil
+}
+
+type rejection struct {
+	domain, reason string
+}
+
+// vetContribution applies the upstream contribution rules: lowercase,
+// registrable domains only (no subdomains, no bare public suffixes), not
+// already listed, not on the upstream allowlist, no duplicates.
+func vetContribution(learned []emailguard.LearnedDomain, upstream []string, allow map[string]struct{}) ([]emailguard.LearnedDomain, []rejection) {
+	listed := make(map[string]struct{}, len(upstream))
+	for _, l := range upstream {
+		listed[strings.TrimSpace(l)] = struct{}{}
+	}
+
+	var ok []emailguard.LearnedDomain
+	var bad []rejection
+	seen := make(map[string]struct{})
+	for _, d := range learned {
+		name := strings.TrimSuffix(strings.TrimSpace(d.Domain), ".")
+		switch rd, err := emailguard.RegistrableDomain(name); {
+		case name != strings.ToLower(name):
+			bad = append(bad, rejection{name, "must be lowercase"})
+		case err != nil:
+			bad = append(bad, rejection{name, "not a registrable domain: " + err.Error()})
+		case rd != name:
+			bad = append(bad, rejection{name, "subdomain; contribute " + rd + " instead"})
+		case contains(listed, name):
+			bad = append(bad, rejection{name, "already listed upstream"})
+		case contains(allow, name):
+			bad = append(bad, rejection{name, "on upstream allowlist"})
+		case contains(seen, name):
+			bad = append(bad, rejection{name, "duplicate"})
+		default:
+			seen[name] = struct{}{}
+			d.Domain = name
+			ok = append(ok, d)
+		}
+	}
+	slices.SortFunc(ok, func(a, b emailguard.LearnedDomain) int { return strings.Compare(a.Domain, b.Domain) })
+	return ok, bad
+}
+
+func contains(m map[string]struct{}, k string) bool {
+	_, ok := m[k]
+	return ok
+}
+
+// insertionPatch renders a unified diff inserting domains into the sorted
+// upstream list, with three lines of context per hunk.
+func insertionPatch(name string, old []string, add []emailguard.LearnedDomain) []byte {
+	const ctx = 3
+
+	// merge, remembering which new lines are insertions
+	var merged []string
+	var inserted []bool
+	i := 0
+	for _, d := range add {
+		for i < len(old) && old[i] < d.Domain {
+			merged, inserted = append(merged, old[i]), append(inserted, false)
+			i++
+		}
+		merged, inserted = append(merged, d.Domain), append(inserted, true)
+	}
+	for ; i < len(old); i++ {
+		merged, inserted = append(merged, old[i]), append(inserted, false)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "--- a/%s\n+++ b/%s\n", name, name)
+	for start := 0; start < len(merged); {
+		// find next insertion
+		for start < len(merged) && !inserted[start] {
+			start++
+		}
+		if start == len(merged) {
+			break
+		}
+		lo := max(0, start-ctx)
+		end := start
+		for {
+			for end < len(merged) && inserted[end] {
+				end++
+			}
+			// extend the hunk if another insertion is within 2*ctx lines
+			next := end
+			for next < len(merged) && next < end+2*ctx && !inserted[next] {
+				next++
+			}
+			if next < len(merged) && inserted[next] {
+				end = next
+				continue
+			}
+			break
+		}
+		hi := min(len(merged), end+ctx)
+
+		oldStart := 1
+		for k := 0; k < lo; k++ {
+			if !inserted[k] {
+				oldStart++
+			}
+		}
+		var oldN, newN int
+		for k := lo; k < hi; k++ {
+			newN++
+			if !inserted[k] {
+				oldN++
+			}
+		}
+		fmt.Fprintf(&buf, "@@ -%d,%d +%d,%d @@\n", oldStart, oldN, lo+1, newN)
+		for k := lo; k < hi; k++ {
+			if inserted[k] {
+				buf.WriteString("+" + merged[k] + "\n")
+			} else {
+				buf.WriteString(" " + merged[k] + "\n")
+			}
+		}
+		start = hi
+	}
+	return buf.Bytes()
+}
+
+func prBody(add []emailguard.LearnedDomain) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Add %d disposable domain(s)\n\n", len(add))
+	buf.WriteString("These domains are rotated in by API-driven temporary mail services. Each was\n")
+	buf.WriteString("identified by mail infrastructure shared with the provider's known domains.\n\n")
+	buf.WriteString("| Domain | Provider | Evidence | First seen |\n|---|---|---|---|\n")
+	for _, d := range add {
+		seen := "-"
+		if !d.FirstSeen.IsZero() {
+			seen = d.FirstSeen.UTC().Format("2006-01-02")
+		}
+		fmt.Fprintf(&buf, "| %s | %s | %s | %s |\n", d.Domain, orDash(d.Provider), orDash(d.Indicator), seen)
+	}
+	return buf.Bytes()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func readLearned(path string) ([]emailguard.LearnedDomain, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if trimmed := bytes.TrimSpace(b); len(trimmed) > 0 && (trimmed[0] == '[' || trimmed[0] == '{') {
+		out, err := emailguard.ReadLearned(bytes.NewReader(trimmed))
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+		return out, nil
+	}
+	var out []emailguard.LearnedDomain
+	for _, l := range splitLines(b) {
+		if l = strings.TrimSpace(l); l != "" && !strings.HasPrefix(l, "#") {
+			out = append(out, emailguard.LearnedDomain{Domain: l})
+		}
+	}
+	return out, nil
+}
+
+func readSet(path string) (map[string]struct{}, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	set := make(map[string]struct{})
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if l := strings.TrimSpace(sc.Text()); l != "" && !strings.HasPrefix(l, "#") {
+			set[l] = struct{}{}
+		}
+	}
+	return set, sc.Err()
+}
+
+func splitLines(b []byte) []string {
+	s := strings.TrimSuffix(string(b), "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/vandit1604/emailguard"
+)
+
+const dataUsage = `usage: emailguard data gc [-config file.json] [-max-bytes n] [-temp-age 1h] [-dry-run]`
+
+// runData maintains the blocklist directory a guard materializes its
+// sources in.
+func runData(args []string) error {
+	if len(args) == 0 || args[0] != "gc" {
+		return fmt.Errorf("%s", dataUsage)
+	}
+	fs := flag.NewFlagSet("data gc", flag.ExitOnError)
+	cfgPath := fs.String("config", "", "JSON configuration file naming the blocklist directory and sources")
+	maxBytes := fs.Int64("max-bytes", 0, "cap the directory at this many bytes (0: no cap)")
+	tempAge := fs.Duration("temp-age", 0, "remove temp files and interrupted clones older than this (0: 1h)")
+	dryRun := fs.Bool("dry-run", false, "only report what would be removed")
+	fs.Parse(args[1:])
+
+	var opts []emailguard.Option
+	if *cfgPath != "" {
+		c, err := loadConfig(*cfgPath)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, emailguard.WithConfig(c))
+	}
+	g, err := emailguard.New(opts...)
+	if err != nil {
+		return err
+	}
+	rep, err := g.CollectGarbage(emailguard.DataGC{MaxBytes: *maxBytes, TempAge: *tempAge, DryRun: *dryRun})
+	verb := "removed"
+	if *dryRun {
+		verb = "would remove"
+	}
+	for _, it := range rep.Removed {
+		fmt.Printf("%s %s (%s, %d bytes)\n", verb, it.Path, it.Reason, it.Bytes)
+	}
+	fmt.Printf("%d bytes freed, %d bytes left\n", rep.Freed, rep.Size)
+	return err
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/vandit1604/emailguard"
+)
+
+const dbUsage = `usage: emailguard db <vacuum|export> -path emailguard.db
+       emailguard db history -path emailguard.db <domain>
+       emailguard db funnel -path emailguard.db [-since 168h] [-format text|markdown|json]`
+
+// runDB maintains the SQLite datastore used with serve -db. The binary needs
+// a SQLite driver linked in: build with -tags sqlite.
+func runDB(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%s", dbUsage)
+	}
+	sub := args[0]
+	fs := flag.NewFlagSet("db "+sub, flag.ExitOnError)
+	path := fs.String("path", "emailguard.db", "SQLite database file")
+	since := fs.Duration("since", 7*24*time.Hour, "funnel: how far back to look")
+	format := fs.String("format", "text", "funnel: text, markdown or json")
+	fs.Parse(args[1:])
+
+	s, err := emailguard.OpenSQLite(*path)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	switch sub {
+	case "vacuum":
+		return s.Vacuum()
+	case "export":
+		return s.Export(os.Stdout)
+	case "history":
+		if fs.NArg() != 1 {
+			return fmt.Errorf("%s", dbUsage)
+		}
+		recs, err := s.History(fs.Arg(0), 1000)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(os.Stdout)
+		for _, r := range recs {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "funnel":
+		to := time.Now()
+		recs, err := s.Decisions(to.Add(-*since), to)
+		if err != nil {
+			return err
+		}
+		rep := emailguard.SummarizeFunnel(recs, to.Add(-*since), to)
+		switch *format {
+		case "text":
+			fmt.Print(rep.Text())
+		case "markdown":
+			fmt.Print(rep.Markdown())
+		case "json":
+			return json.NewEncoder(os.Stdout).Encode(rep)
+		default:
+			return fmt.Errorf("unknown format %q; use text, markdown or json", *format)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown db command %q\n%s", sub, dbUsage)
+	}
+}
@@ -0,0 +1,60 @@
+// Command emailguard checks email addresses from the command line or serves
+// checks over HTTP.
+//
+//	emailguard check user@example.com ...
+//	emailguard serve -listen unix:/run/emailguard/emailguard.sock
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+const usage = `usage: emailguard <command> [flags]
+
+commands:
+  check       check one or more addresses
+  bulk        clean a list of addresses (lines or CSV) into annotated CSV
+  serve       serve checks over HTTP (TCP or Unix socket)
+  contribute  turn learned disposable domains into an upstream patch
+  db          vacuum, export or show history from the SQLite datastore (build with -tags sqlite)
+  config      print the default configuration or validate a configuration file
+  conformance check the categorized test corpus and report changed verdicts
+  data        garbage-collect the blocklist directory
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	var err error
+	switch cmd, args := os.Args[1], os.Args[2:]; cmd {
+	case "check":
+		err = runCheck(args)
+	case "bulk":
+		err = runBulk(args)
+	case "serve":
+		err = runServe(args)
+	case "contribute":
+		err = runContribute(args)
+	case "db":
+		err = runDB(args)
+	case "config":
+		err = runConfig(args)
+	case "conformance":
+		err = runConformance(args)
+	case "data":
+		err = runData(args)
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "emailguard: unknown command %q\n\n%s", cmd, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "emailguard: %v\n", err)
+		os.Exit(1)
+	}
+}
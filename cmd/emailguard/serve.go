@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/vandit1604/emailguard"
+	"github.com/vandit1604/emailguard/server"
+)
+
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	listen := fs.String("listen", "127.0.0.1:8080", `address to listen on: "host:port" or "unix:/path/to.sock"`)
+	mode := fs.String("socket-mode", "0660", "permissions for the Unix socket file (octal)")
+	debug := fs.Bool("debug", false, "serve /debug/emailguard and /debug/vars")
+	ui := fs.Bool("ui", false, "serve the support UI at /ui/ (basic auth: $EMAILGUARD_UI_USER, default admin, and $EMAILGUARD_UI_PASSWORD)")
+	dbPath := fs.String("db", "", "persist state in this SQLite file (build with -tags sqlite)")
+	cfgPath := fs.String("config", "", "JSON configuration file (see emailguard config print-defaults)")
+	refresh := fs.Duration("refresh", 30*time.Minute, "how often to refresh the blocklist")
+	webhook := fs.String("alert-webhook", "", "POST a webhook here when blocklist refreshes keep failing (secret in $EMAILGUARD_WEBHOOK_SECRET)")
+	alertAfter := fs.Duration("alert-after", 24*time.Hour, "how long refreshes may fail before -alert-webhook fires")
+	dataGC := fs.Bool("data-gc", false, "garbage-collect the blocklist directory after each refresh")
+	dataMax := fs.Int64("data-max-bytes", 0, "with -data-gc, cap the blocklist directory at this many bytes")
+	faults := fs.String("faults", "", `inject faults, for staging only: e.g. "dns=0.2,smtp=0.1,stale" (see emailguard.ParseFaults)`)
+	kills := fs.String("kill-switches", "", "switch off the checks named in this file, one per line, re-reading it every 30s")
+	pslRefresh := fs.Duration("psl-refresh", 0, "download the public suffix list this often (0: use the built-in copy)")
+	pslCache := fs.String("psl-cache", "", "with -psl-refresh, keep the downloaded public suffix list in this file")
+	fs.Parse(args)
+
+	m, err := strconv.ParseUint(*mode, 8, 32)
+	if err != nil {
+		return fmt.Errorf("invalid -socket-mode %q: %w", *mode, err)
+	}
+	uiUser, uiPassword := os.Getenv("EMAILGUARD_UI_USER"), os.Getenv("EMAILGUARD_UI_PASSWORD")
+	if uiUser == "" {
+		uiUser = "admin"
+	}
+	if *ui && uiPassword == "" {
+		return errors.New("-ui needs a password in $EMAILGUARD_UI_PASSWORD")
+	}
+
+	var opts []emailguard.Option
+	if *cfgPath != "" {
+		c, err := loadConfig(*cfgPath)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, emailguard.WithConfig(c))
+	}
+	if *dbPath != "" {
+		s, err := emailguard.OpenSQLite(*dbPath)
+		if err != nil {
+			return err
+		}
+		defer s.Close()
+		opts = append(opts, emailguard.WithStore(s))
+	}
+	if *webhook != "" {
+		opts = append(opts, emailguard.WithRefreshWebhook(*webhook, os.Getenv("EMAILGUARD_WEBHOOK_SECRET"), *alertAfter))
+	}
+	if *dataGC {
+		opts = append(opts, emailguard.WithDataGC(emailguard.DataGC{MaxBytes: *dataMax}))
+	}
+	if *faults != "" {
+		f, err := emailguard.ParseFaults(*faults)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, emailguard.WithFaultInjection(f))
+		log.Printf("emailguard: injecting faults (%s); do not run this in production", *faults)
+	}
+	g, err := emailguard.New(opts...)
+	if err != nil {
+		return err
+	}
+	ln, err := server.ListenConfig{Addr: *listen, SocketMode: os.FileMode(m)}.Listen()
+	if err != nil {
+		return err
+	}
+
+	h := server.New(g)
+	if *debug {
+		g.PublishExpvar("emailguard")
+		h.EnableDebug()
+	}
+	if *ui {
+		h.EnableUI(server.BasicAuth(uiUser, uiPassword))
+	}
+	srv := &http.Server{
+		Handler:           h,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go g.RunBlocklistRefresh(ctx, *refresh)
+	if *kills != "" {
+		go g.WatchKillSwitches(ctx, *kills, 30*time.Second)
+	}
+	if *pslRefresh > 0 {
+		go emailguard.RunPublicSuffixRefresh(ctx, "", *pslCache, *pslRefresh)
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("emailguard: listening on %s", *listen)
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
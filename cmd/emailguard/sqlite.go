@@ -0,0 +1,9 @@
+//go:build sqlite
+
+package main
+
+// Links the pure-Go SQLite driver used by "serve -db" and "emailguard db".
+// go.mod requires it, but only builds with the tag link it:
+//
+//	go build -tags sqlite ./cmd/emailguard
+import _ "modernc.org/sqlite"
@@ -0,0 +1,79 @@
+package emailguard
+
+import (
+	"fmt"
+	"math"
+)
+
+// reasonConfidence is how sure a decision with the given reason is, before
+// flags and signals are weighed. Reasons without an entry, e.g. from custom
+// stages, count defaultConfidence.
+var reasonConfidence = map[Reason]float64{
+	ReasonSyntax:         1,
+	ReasonSubaddressed:   1,
+	ReasonOverride:       1,
+	ReasonTenantPolicy:   1,
+	ReasonTLDPolicy:      1,
+	ReasonHook:           1,
+	ReasonDisposable:     0.98,
+	ReasonAllowlisted:    0.95,
+	ReasonTempMailInfra:  0.95,
+	ReasonPlatform:       0.9,
+	ReasonNoMX:           0.9,
+	ReasonDisposableMX:   0.9,
+	ReasonBlockedMX:      0.95,
+	ReasonParked:         0.85,
+	ReasonListedDomain:   0.85,
+	ReasonValidMX:        0.8,
+	ReasonMaskedMX:       0.75,
+	ReasonInsufficientMX: 0.6,
+	ReasonDefaultPolicy:  0.5, // nothing objected, nothing vouched
+	ReasonTemporary:      0,
+}
+
+const defaultConfidence = 0.7
+
+// WithAbstainBelow makes the guard answer OutcomeUnknown instead of allow or
+// reject when a verdict's Confidence is below min, so integrators can send
+// those addresses through link verification rather than trusting a guess.
+// Reason and Detail still say which way the guard leaned.
+func WithAbstainBelow(min float64) Option {
+	return func(c *config) error {
+		if min < 0 || min > 1 {
+			return fmt.Errorf("WithAbstainBelow: need a value between 0 and 1, got %g", min)
+		}
+		c.abstainBelow = min
+		return nil
+	}
+}
+
+// confidence rates how sure v's outcome is, from 0 to 1. Allows lose
+// confidence with every risk point their signals and flags add up to;
+// stale verdicts lose a fifth.
+func confidence(v Verdict) float64 {
+	if v.Outcome == OutcomeRetryLater {
+		return 0
+	}
+	c, ok := reasonConfidence[v.Reason]
+	if !ok {
+		c = defaultConfidence
+	}
+	if v.Outcome == OutcomeAllow {
+		c *= 1 - float64(breakdown(v).Score)/200
+	}
+	for _, f := range v.Flags {
+		if f == FlagStale {
+			c *= 0.8
+		}
+	}
+	return math.Round(c*100) / 100
+}
+
+// settle sets v's Confidence and abstains below the WithAbstainBelow
+// threshold.
+func (g *Guard) settle(v *Verdict) {
+	v.Confidence = confidence(*v)
+	if v.Outcome != OutcomeRetryLater && v.Confidence < g.cfg.abstainBelow {
+		v.Outcome, v.Allowed = OutcomeUnknown, false
+	}
+}
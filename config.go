@@ -0,0 +1,523 @@
+package emailguard
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Config is the declarative part of a Guard's configuration, for
+// deployments that keep it in a file. Hooks, stores, keys and custom stages
+// are still set with options. Durations are strings like "1s" in JSON.
+type Config struct {
+	BlocklistDir          string           `json:"blocklist_dir"`
+	BlocklistSources      []string         `json:"blocklist_sources"`
+	Allowlist             []string         `json:"allowlist"`
+	BadMXKeywords         []string         `json:"bad_mx_keywords"`
+	MXTimeout             time.Duration    `json:"mx_timeout"`
+	CacheTTL              time.Duration    `json:"cache_ttl"`
+	AllowTTL              time.Duration    `json:"allow_ttl"`   // zero: cache_ttl
+	RejectTTL             time.Duration    `json:"reject_ttl"`  // zero: cache_ttl
+	FailureTTL            time.Duration    `json:"failure_ttl"` // zero: failures are not cached
+	NegativeCacheTTL      time.Duration    `json:"negative_cache_ttl"`
+	RuleTTLs              []RuleTTL        `json:"rule_ttls"` // first match wins
+	Reevaluate            time.Duration    `json:"reevaluate"`
+	MinMX                 int              `json:"min_mx"` // zero: any MX will do
+	ParentDomainMX        bool             `json:"parent_domain_mx"`
+	ImplicitMX            bool             `json:"implicit_mx"`
+	MXScope               MXScope          `json:"mx_scope"`
+	MXBlocklist           []string         `json:"mx_blocklist"`
+	SPFCheck              bool             `json:"spf_check"`
+	DMARCCheck            bool             `json:"dmarc_check"`
+	DKIMSelectors         []string         `json:"dkim_selectors"` // empty: no DKIM probing
+	DNSBLs                []DNSBL          `json:"dnsbls"`
+	ReverseDNS            bool             `json:"reverse_dns"`
+	DomainBlocklists      []DNSBL          `json:"domain_blocklists"`
+	DomainBlocklistAction Action           `json:"domain_blocklist_action"` // none: flag only
+	Subdomains            SubdomainPolicy  `json:"subdomains"`
+	DNSAttempts           int              `json:"dns_attempts"` // 1: no retries
+	DNSRetryBackoff       time.Duration    `json:"dns_retry_backoff"`
+	DNSRetryJitter        float64          `json:"dns_retry_jitter"`
+	DNSConcurrency        int              `json:"dns_concurrency"`
+	SMTPConcurrency       int              `json:"smtp_concurrency"`
+	BatchDNSConcurrency   int              `json:"batch_dns_concurrency"`
+	BatchSMTPConcurrency  int              `json:"batch_smtp_concurrency"`
+	SequenceWindow        time.Duration    `json:"sequence_window"` // zero: no sequence detection
+	SequenceThreshold     int              `json:"sequence_threshold"`
+	Quarantine            time.Duration    `json:"quarantine"`     // zero: no quarantine
+	StaleIfError          time.Duration    `json:"stale_if_error"` // zero: never serve expired verdicts
+	AbstainBelow          float64          `json:"abstain_below"`  // zero: never answer unknown
+	DisabledChecks        []string         `json:"disabled_checks"`
+	KillSwitches          []string         `json:"kill_switches"` // switched off at start; see Guard.SetKillSwitch
+	AirGapped             bool             `json:"air_gapped"`
+	DNSServers            []string         `json:"dns_servers"`    // empty: the system resolver
+	DNSOverHTTPS          []string         `json:"dns_over_https"` // empty: the system resolver
+	SearchDomains         bool             `json:"search_domains"`
+	IPPolicy              IPPolicy         `json:"ip_policy"`
+	RoleAccounts          []string         `json:"role_accounts"` // empty: no role-account flag
+	Subaddressing         SubaddressPolicy `json:"subaddressing"`
+	PopularDomains        []string         `json:"popular_domains"`
+	FreeProviders         []string         `json:"free_providers"`
+	PlatformSuffixes      []string         `json:"platform_suffixes"`
+	PlatformSubdomains    Action           `json:"platform_subdomains"` // none: flag only
+	ParkedCheck           bool             `json:"parked_check"`
+	ParkedAction          Action           `json:"parked_action"` // none: flag only
+	ParkingHosts          []string         `json:"parking_hosts"`
+}
+
+// DefaultConfig returns the configuration New uses without options.
+func DefaultConfig() Config {
+	return configOf(defaultConfig())
+}
+
+// Config returns g's effective configuration.
+func (g *Guard) Config() Config {
+	return configOf(g.cfg)
+}
+
+func configOf(c config) Config {
+	sources := c.sources
+	if len(sources) == 0 {
+		sources = []string{defaultSource}
+	}
+	return Config{
+		BlocklistDir:          c.blocklistDir,
+		BlocklistSources:      slices.Clone(sources),
+		Allowlist:             slices.Clone(c.allowlist),
+		BadMXKeywords:         slices.Clone(c.mxBadKeywords),
+		MXTimeout:             c.mxTimeout,
+		CacheTTL:              c.cacheTTL,
+		AllowTTL:              c.allowTTL,
+		RejectTTL:             c.rejectTTL,
+		FailureTTL:            c.failureTTL,
+		NegativeCacheTTL:      c.negativeTTL,
+		RuleTTLs:              slices.Clone(c.ruleTTLs),
+		Reevaluate:            c.reevaluate,
+		MinMX:                 c.minMX,
+		ParentDomainMX:        c.parentMX,
+		ImplicitMX:            c.implicitMX,
+		MXScope:               c.mxScope,
+		MXBlocklist:           slices.Clone(c.mxBlocklist),
+		SPFCheck:              c.spf,
+		DMARCCheck:            c.dmarc,
+		DKIMSelectors:         slices.Clone(c.dkimSelectors),
+		DNSBLs:                slices.Clone(c.dnsbls),
+		ReverseDNS:            c.reverseDNS,
+		DomainBlocklists:      slices.Clone(c.domainLists),
+		DomainBlocklistAction: c.domainListAction,
+		Subdomains:            c.subdomains,
+		DNSAttempts:           c.dnsAttempts,
+		DNSRetryBackoff:       c.dnsBackoff,
+		DNSRetryJitter:        c.dnsJitter,
+		DNSConcurrency:        c.dnsConcurrency,
+		SMTPConcurrency:       c.smtpConcurrency,
+		BatchDNSConcurrency:   c.batchDNSConcurrency,
+		BatchSMTPConcurrency:  c.batchSMTPConcurrency,
+		SequenceWindow:        c.seqWindow,
+		SequenceThreshold:     c.seqThreshold,
+		Quarantine:            c.quarantine,
+		StaleIfError:          c.staleIfError,
+		AbstainBelow:          c.abstainBelow,
+		DisabledChecks:        slices.Sorted(maps.Keys(c.disabled)),
+		KillSwitches:          slices.Clone(c.killSwitches),
+		AirGapped:             c.airGapped,
+		DNSServers:            dnsServers(c.resolver),
+		DNSOverHTTPS:          dohEndpoints(c.resolver),
+		SearchDomains:         c.searchDomains,
+		IPPolicy:              c.ipPolicy,
+		RoleAccounts:          slices.Clone(c.roleAccounts),
+		Subaddressing:         c.subaddress,
+		PopularDomains:        slices.Clone(c.popular),
+		FreeProviders:         slices.Clone(c.freeProviders),
+		PlatformSuffixes:      slices.Clone(c.platformSuffixes),
+		PlatformSubdomains:    c.platformAction,
+		ParkedCheck:           c.parked,
+		ParkedAction:          c.parkedAction,
+		ParkingHosts:          slices.Clone(c.parkingHosts),
+	}
+}
+
+// knownChecks are the names DisabledChecks accepts.
+var knownChecks = []string{
+	LayerTLD, LayerAllowlist, LayerBlocklist, LayerReputation, LayerFingerprint, LayerHeuristics,
+	LayerSyntax, LayerMX, LayerMXKeywords, LayerDisposableMX, LayerParked,
+}
+
+// Validate reports every problem with c at once, each naming the field
+// and what to do about it. WithConfig and LoadConfig call it.
+func (c Config) Validate() error {
+	var errs []error
+	bad := func(field, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", field, fmt.Sprintf(format, args...)))
+	}
+
+	switch {
+	case c.BlocklistDir == "":
+		bad("blocklist_dir", "empty; set a directory the process can write, e.g. /var/lib/emailguard/blocklist")
+	case !filepath.IsAbs(c.BlocklistDir):
+		bad("blocklist_dir", "%q is relative; use an absolute path so it does not depend on the working directory", c.BlocklistDir)
+	default:
+		if fi, err := os.Stat(c.BlocklistDir); err == nil && !fi.IsDir() {
+			bad("blocklist_dir", "%q exists and is not a directory", c.BlocklistDir)
+		} else if os.IsNotExist(err) {
+			if _, err := os.Stat(filepath.Dir(c.BlocklistDir)); err != nil {
+				bad("blocklist_dir", "parent of %q does not exist; create it first", c.BlocklistDir)
+			}
+		}
+	}
+	if len(c.BlocklistSources) == 0 {
+		bad("blocklist_sources", "empty; list at least one git repository or HTTP(S) list, or omit the field for the default")
+	}
+	for i, src := range c.BlocklistSources {
+		if u, err := url.Parse(src); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			bad(fmt.Sprintf("blocklist_sources[%d]", i), "%q is not an http(s) URL", src)
+		} else if isGitSource(src) && errNoGit != nil {
+			bad(fmt.Sprintf("blocklist_sources[%d]", i), "%v", errNoGit)
+		}
+	}
+	for i, d := range c.Allowlist {
+		if err := checkListEntry(normEntry(d)); err != nil {
+			bad(fmt.Sprintf("allowlist[%d]", i), "%v", err)
+		}
+	}
+	for i, e := range c.MXBlocklist {
+		if err := checkListEntry(normEntry(e)); err != nil {
+			bad(fmt.Sprintf("mx_blocklist[%d]", i), "%v", err)
+		}
+	}
+	for i, e := range c.DNSServers {
+		if _, err := dnsServerAddr(e); err != nil {
+			bad(fmt.Sprintf("dns_servers[%d]", i), "%v", err)
+		}
+	}
+	if len(c.DNSServers) > 0 && len(c.DNSOverHTTPS) > 0 {
+		bad("dns_servers", "cannot be combined with dns_over_https")
+	}
+	for i, e := range c.DNSOverHTTPS {
+		if u, err := url.Parse(e); err != nil || u.Scheme != "https" || u.Host == "" {
+			bad(fmt.Sprintf("dns_over_https[%d]", i), "%q is not an https URL", e)
+		}
+	}
+	for i, d := range c.PopularDomains {
+		if err := checkHostname(normDomain(d)); err != nil {
+			bad(fmt.Sprintf("popular_domains[%d]", i), "%v", err)
+		}
+	}
+	for i, d := range c.FreeProviders {
+		if err := checkHostname(normDomain(d)); err != nil {
+			bad(fmt.Sprintf("free_providers[%d]", i), "%v", err)
+		}
+	}
+	for i, s := range c.PlatformSuffixes {
+		if err := checkHostname(strings.TrimPrefix(normDomain(s), ".")); err != nil {
+			bad(fmt.Sprintf("platform_suffixes[%d]", i), "%v", err)
+		}
+	}
+	for i, r := range c.RoleAccounts {
+		if strings.TrimSpace(r) == "" {
+			bad(fmt.Sprintf("role_accounts[%d]", i), "empty prefix would flag every address; remove it")
+		}
+	}
+	for i, k := range c.BadMXKeywords {
+		if strings.TrimSpace(k) == "" {
+			bad(fmt.Sprintf("bad_mx_keywords[%d]", i), "empty keyword would match every MX host; remove it")
+		}
+	}
+
+	positive := func(field string, d time.Duration) {
+		if d <= 0 {
+			bad(field, "must be positive, got %q", d)
+		}
+	}
+	positive("mx_timeout", c.MXTimeout)
+	positive("cache_ttl", c.CacheTTL)
+	positive("negative_cache_ttl", c.NegativeCacheTTL)
+	positive("reevaluate", c.Reevaluate)
+	if c.MXTimeout > time.Minute {
+		bad("mx_timeout", "%s would stall signups on a dead resolver; keep it to a few seconds", c.MXTimeout)
+	}
+	if c.DNSAttempts < 1 || c.DNSAttempts > maxDNSAttempts {
+		bad("dns_attempts", "must be between 1 and %d, got %d; use 1 for no retries", maxDNSAttempts, c.DNSAttempts)
+	}
+	if c.DNSRetryBackoff < 0 {
+		bad("dns_retry_backoff", "must not be negative")
+	}
+	if c.DNSRetryJitter < 0 || c.DNSRetryJitter > 1 {
+		bad("dns_retry_jitter", "%g is outside 0 to 1", c.DNSRetryJitter)
+	}
+	if c.MinMX < 0 {
+		bad("min_mx", "must not be negative, got %d", c.MinMX)
+	}
+	for _, f := range []struct {
+		field string
+		n     int
+	}{
+		{"dns_concurrency", c.DNSConcurrency}, {"smtp_concurrency", c.SMTPConcurrency},
+		{"batch_dns_concurrency", c.BatchDNSConcurrency}, {"batch_smtp_concurrency", c.BatchSMTPConcurrency},
+	} {
+		if f.n <= 0 {
+			bad(f.field, "must be positive, got %d", f.n)
+		}
+	}
+	switch {
+	case c.SequenceWindow < 0:
+		bad("sequence_window", "must not be negative; use 0 to disable sequence detection")
+	case c.SequenceWindow > 0 && c.SequenceThreshold < 2:
+		bad("sequence_threshold", "must be at least 2 when sequence_window is set, got %d", c.SequenceThreshold)
+	}
+	if c.Quarantine < 0 {
+		bad("quarantine", "must not be negative; use 0 to disable quarantine")
+	}
+	if c.PlatformSubdomains < ActionNone || c.PlatformSubdomains > ActionDeny {
+		bad("platform_subdomains", "unknown action %d; use none, allow or deny", c.PlatformSubdomains)
+	}
+	if c.DomainBlocklistAction != ActionNone && c.DomainBlocklistAction != ActionDeny {
+		bad("domain_blocklist_action", "must be none or deny, got %d", c.DomainBlocklistAction)
+	}
+	if c.ParkedAction != ActionNone && c.ParkedAction != ActionDeny {
+		bad("parked_action", "must be none or deny, got %d", c.ParkedAction)
+	}
+	for i, h := range c.ParkingHosts {
+		if err := checkHostname(normDomain(h)); err != nil {
+			bad(fmt.Sprintf("parking_hosts[%d]", i), "%v", err)
+		}
+	}
+	if c.MXScope != MXScopeAll && c.MXScope != MXScopePrimary {
+		bad("mx_scope", "unknown scope %d; use all or primary", c.MXScope)
+	}
+	if c.IPPolicy < DualStack || c.IPPolicy > IPv6Only {
+		bad("ip_policy", "unknown policy %d; use dual_stack, ipv4_only or ipv6_only", c.IPPolicy)
+	}
+	if c.Subaddressing < SubaddressAllow || c.Subaddressing > SubaddressReject {
+		bad("subaddressing", "unknown policy %d; use allow, flag or reject", c.Subaddressing)
+	}
+	for _, f := range []struct {
+		field string
+		d     time.Duration
+	}{{"allow_ttl", c.AllowTTL}, {"reject_ttl", c.RejectTTL}, {"failure_ttl", c.FailureTTL}} {
+		if f.d < 0 {
+			bad(f.field, "must not be negative; use 0 for the default")
+		}
+	}
+	for i, r := range c.RuleTTLs {
+		if err := r.validate(); err != nil {
+			bad(fmt.Sprintf("rule_ttls[%d]", i), "%v", err)
+		}
+	}
+	if c.StaleIfError < 0 {
+		bad("stale_if_error", "must not be negative; use 0 to never serve expired verdicts")
+	}
+	if c.AbstainBelow < 0 || c.AbstainBelow > 1 {
+		bad("abstain_below", "%g is outside 0 to 1; use 0 to never answer unknown", c.AbstainBelow)
+	}
+	for i, n := range c.DisabledChecks {
+		if !slices.Contains(knownChecks, n) {
+			bad(fmt.Sprintf("disabled_checks[%d]", i), "unknown check %q; known checks: %s", n, strings.Join(knownChecks, ", "))
+		}
+	}
+	for i, s := range c.DKIMSelectors {
+		if !validDKIMSelector(s) {
+			bad(fmt.Sprintf("dkim_selectors[%d]", i), "%q is not a valid selector", s)
+		}
+	}
+	for i, b := range c.DNSBLs {
+		if err := b.validate(); err != nil {
+			bad(fmt.Sprintf("dnsbls[%d]", i), "%v", err)
+		}
+	}
+	for i, b := range c.DomainBlocklists {
+		if err := b.validate(); err != nil {
+			bad(fmt.Sprintf("domain_blocklists[%d]", i), "%v", err)
+		}
+	}
+	for i, n := range c.KillSwitches {
+		if !slices.Contains(knownChecks, n) && !slices.Contains(postChecks, n) {
+			bad(fmt.Sprintf("kill_switches[%d]", i), "unknown check %q; known checks: %s", n, strings.Join(append(slices.Clone(knownChecks), postChecks...), ", "))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WithConfig applies c after validating it.
+func WithConfig(c Config) Option {
+	return func(cfg *config) error {
+		if err := c.Validate(); err != nil {
+			return fmt.Errorf("WithConfig: %w", err)
+		}
+		cfg.blocklistDir = filepath.Clean(c.BlocklistDir)
+		cfg.sources = slices.Clone(c.BlocklistSources)
+		cfg.allowlist = slices.Clone(c.Allowlist)
+		cfg.mxBadKeywords = cfg.mxBadKeywords[:0:0]
+		for _, k := range c.BadMXKeywords {
+			cfg.mxBadKeywords = append(cfg.mxBadKeywords, strings.ToLower(strings.TrimSpace(k)))
+		}
+		cfg.mxTimeout, cfg.cacheTTL, cfg.reevaluate = c.MXTimeout, c.CacheTTL, c.Reevaluate
+		cfg.allowTTL, cfg.rejectTTL, cfg.failureTTL = c.AllowTTL, c.RejectTTL, c.FailureTTL
+		cfg.negativeTTL, cfg.ruleTTLs = c.NegativeCacheTTL, slices.Clone(c.RuleTTLs)
+		cfg.minMX, cfg.parentMX, cfg.subdomains = c.MinMX, c.ParentDomainMX, c.Subdomains
+		cfg.implicitMX, cfg.mxBlocklist = c.ImplicitMX, slices.Clone(c.MXBlocklist)
+		cfg.mxScope = c.MXScope
+		cfg.spf, cfg.dmarc = c.SPFCheck, c.DMARCCheck
+		cfg.dkimSelectors = slices.Clone(c.DKIMSelectors)
+		cfg.dnsbls = nil
+		if err := WithDNSBL(c.DNSBLs...)(cfg); err != nil {
+			return err
+		}
+		cfg.reverseDNS = c.ReverseDNS
+		cfg.domainLists = nil
+		if err := WithDomainBlocklists(c.DomainBlocklistAction, c.DomainBlocklists...)(cfg); err != nil {
+			return err
+		}
+		cfg.dnsAttempts, cfg.dnsBackoff, cfg.dnsJitter = c.DNSAttempts, c.DNSRetryBackoff, c.DNSRetryJitter
+		cfg.dnsConcurrency, cfg.smtpConcurrency = c.DNSConcurrency, c.SMTPConcurrency
+		cfg.batchDNSConcurrency, cfg.batchSMTPConcurrency = c.BatchDNSConcurrency, c.BatchSMTPConcurrency
+		cfg.seqWindow, cfg.seqThreshold = c.SequenceWindow, c.SequenceThreshold
+		cfg.quarantine, cfg.staleIfError = c.Quarantine, c.StaleIfError
+		cfg.abstainBelow = c.AbstainBelow
+		cfg.roleAccounts = nil
+		if len(c.RoleAccounts) > 0 {
+			if err := WithRoleAccounts(c.RoleAccounts...)(cfg); err != nil {
+				return err
+			}
+		}
+		if err := WithPopularDomains(c.PopularDomains...)(cfg); err != nil {
+			return err
+		}
+		cfg.freeProviders = nil
+		if err := WithFreeProviders(c.FreeProviders...)(cfg); err != nil {
+			return err
+		}
+		cfg.subaddress, cfg.ipPolicy, cfg.searchDomains = c.Subaddressing, c.IPPolicy, c.SearchDomains
+		cfg.platformSuffixes, cfg.platformAction = nil, c.PlatformSubdomains
+		cfg.parked, cfg.parkedAction = c.ParkedCheck, c.ParkedAction
+		cfg.parkingHosts = nil
+		if err := WithParkingHosts(c.ParkingHosts...)(cfg); err != nil {
+			return err
+		}
+		if err := WithPlatformSuffixes(c.PlatformSuffixes...)(cfg); err != nil {
+			return err
+		}
+		if len(c.DNSServers) > 0 {
+			if err := WithDNSServers(c.DNSServers...)(cfg); err != nil {
+				return err
+			}
+		}
+		if len(c.DNSOverHTTPS) > 0 {
+			if err := WithDoH(c.DNSOverHTTPS...)(cfg); err != nil {
+				return err
+			}
+		}
+		cfg.disabled = nil
+		cfg.disable(c.DisabledChecks...)
+		cfg.killSwitches = slices.Clone(c.KillSwitches)
+		if c.AirGapped {
+			return WithAirGapped()(cfg)
+		}
+		return nil
+	}
+}
+
+// LoadConfig reads a JSON configuration. Fields left out keep their
+// defaults; unknown fields are errors, so typos do not silently fall back.
+func LoadConfig(r io.Reader) (Config, error) {
+	c := DefaultConfig()
+	if err := json.NewDecoder(r).Decode(&c); err != nil {
+		return Config{}, fmt.Errorf("config: %w", err)
+	}
+	if err := c.Validate(); err != nil {
+		return Config{}, fmt.Errorf("config: %w", err)
+	}
+	return c, nil
+}
+
+// configJSON is Config with durations as strings. Decoding rejects
+// unknown fields.
+type configJSON struct {
+	configAlias
+	MXTimeout      string `json:"mx_timeout"`
+	CacheTTL       string `json:"cache_ttl"`
+	AllowTTL       string `json:"allow_ttl"`
+	RejectTTL      string `json:"reject_ttl"`
+	FailureTTL     string `json:"failure_ttl"`
+	NegativeTTL    string `json:"negative_cache_ttl"`
+	DNSBackoff     string `json:"dns_retry_backoff"`
+	Reevaluate     string `json:"reevaluate"`
+	SequenceWindow string `json:"sequence_window"`
+	Quarantine     string `json:"quarantine"`
+	StaleIfError   string `json:"stale_if_error"`
+}
+
+type configAlias Config // without the methods
+
+func (c Config) MarshalJSON() ([]byte, error) {
+	return json.Marshal(configJSON{
+		configAlias:    configAlias(c),
+		MXTimeout:      c.MXTimeout.String(),
+		CacheTTL:       c.CacheTTL.String(),
+		AllowTTL:       c.AllowTTL.String(),
+		RejectTTL:      c.RejectTTL.String(),
+		FailureTTL:     c.FailureTTL.String(),
+		NegativeTTL:    c.NegativeCacheTTL.String(),
+		DNSBackoff:     c.DNSRetryBackoff.String(),
+		Reevaluate:     c.Reevaluate.String(),
+		SequenceWindow: c.SequenceWindow.String(),
+		Quarantine:     c.Quarantine.String(),
+		StaleIfError:   c.StaleIfError.String(),
+	})
+}
+
+func (c *Config) UnmarshalJSON(b []byte) error {
+	j := configJSON{
+		configAlias:    configAlias(*c),
+		MXTimeout:      c.MXTimeout.String(),
+		CacheTTL:       c.CacheTTL.String(),
+		AllowTTL:       c.AllowTTL.String(),
+		RejectTTL:      c.RejectTTL.String(),
+		FailureTTL:     c.FailureTTL.String(),
+		NegativeTTL:    c.NegativeCacheTTL.String(),
+		DNSBackoff:     c.DNSRetryBackoff.String(),
+		Reevaluate:     c.Reevaluate.String(),
+		SequenceWindow: c.SequenceWindow.String(),
+		Quarantine:     c.Quarantine.String(),
+		StaleIfError:   c.StaleIfError.String(),
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&j); err != nil {
+		return err
+	}
+	*c = Config(j.configAlias)
+	for _, d := range []struct {
+		field string
+		s     string
+		dst   *time.Duration
+	}{
+		{"mx_timeout", j.MXTimeout, &c.MXTimeout},
+		{"cache_ttl", j.CacheTTL, &c.CacheTTL},
+		{"allow_ttl", j.AllowTTL, &c.AllowTTL},
+		{"reject_ttl", j.RejectTTL, &c.RejectTTL},
+		{"failure_ttl", j.FailureTTL, &c.FailureTTL},
+		{"negative_cache_ttl", j.NegativeTTL, &c.NegativeCacheTTL},
+		{"dns_retry_backoff", j.DNSBackoff, &c.DNSRetryBackoff},
+		{"reevaluate", j.Reevaluate, &c.Reevaluate},
+		{"sequence_window", j.SequenceWindow, &c.SequenceWindow},
+		{"quarantine", j.Quarantine, &c.Quarantine},
+		{"stale_if_error", j.StaleIfError, &c.StaleIfError},
+	} {
+		v, err := time.ParseDuration(d.s)
+		if err != nil {
+			return fmt.Errorf("%s: %q is not a duration like \"1s\" or \"5m\"", d.field, d.s)
+		}
+		*d.dst = v
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package emailguard
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+// Consistency controls how a guard shares cached verdicts through its Store.
+type Consistency int
+
+const (
+	// ConsistencyLocal caches in memory and writes through to the store on
+	// a best-effort basis. Replicas may briefly disagree.
+	ConsistencyLocal Consistency = iota
+	// ConsistencyShared makes the store the arbiter: the first replica to
+	// cache a verdict for a domain wins and every other replica adopts it
+	// until it expires, so a user retrying against another region gets the
+	// same decision. The store must implement ConsistentStore.
+	ConsistencyShared
+)
+
+// ConsistentStore is a Store that can arbitrate concurrent verdict writes.
+type ConsistentStore interface {
+	Store
+	// PutCachedVerdictIfAbsent stores r under key unless an unexpired entry
+	// exists, and returns whichever entry is now current.
+	PutCachedVerdictIfAbsent(key string, r LayerResult, exp time.Time) (LayerResult, time.Time, error)
+}
+
+// WithConsistency selects how cached verdicts are shared through the store
+// configured with WithStore.
+func WithConsistency(c Consistency) Option {
+	return func(cfg *config) error {
+		if c != ConsistencyLocal && c != ConsistencyShared {
+			return fmt.Errorf("WithConsistency: unknown mode %d", c)
+		}
+		cfg.consistency = c
+		return nil
+	}
+}
+
+// checkConsistency validates the consistency mode against the store.
+func checkConsistency(cfg config) error {
+	if cfg.consistency != ConsistencyShared {
+		return nil
+	}
+	if cfg.store == nil {
+		return errors.New("WithConsistency(ConsistencyShared) requires WithStore")
+	}
+	if _, ok := cfg.store.(ConsistentStore); !ok {
+		return fmt.Errorf("WithConsistency(ConsistencyShared): %T cannot arbitrate writes", cfg.store)
+	}
+	return nil
+}
+
+// policyVersion fingerprints every setting that influences cached verdicts.
+// It prefixes the keys of shared cache entries, so replicas (or restarts)
+// running a different policy never serve each other's decisions.
+func policyVersion(cfg config) string {
+	h := sha256.New()
+	allow := slices.Sorted(slices.Values(cfg.allowlist))
+	kw := slices.Sorted(slices.Values(cfg.mxBadKeywords))
+	fmt.Fprintf(h, "allow=%q\nkw=%q\nsources=%q\nminmx=%d\n", allow, kw, cfg.sources, cfg.minMX)
+	skip := slices.Sorted(maps.Keys(cfg.skipStages))
+	fmt.Fprintf(h, "skip=%q\nfallback=%d\nparentmx=%t\nimplicitmx=%t\n", skip, cfg.fallback, cfg.parentMX, cfg.implicitMX)
+	if cfg.listFS != nil {
+		fmt.Fprintf(h, "listfs=%s\n", cfg.listFSName)
+	}
+	fmt.Fprintf(h, "disabled=%q\n", slices.Sorted(maps.Keys(cfg.disabled)))
+	fmt.Fprintf(h, "mxblock=%q\n", slices.Sorted(slices.Values(cfg.mxBlocklist)))
+	if cfg.mxScope != MXScopeAll {
+		fmt.Fprintf(h, "mxscope=%s\n", cfg.mxScope)
+	}
+	if cfg.parked {
+		fmt.Fprintf(h, "parked=%d,%q\n", cfg.parkedAction, slices.Sorted(slices.Values(cfg.parkingHosts)))
+	}
+	if cfg.domainListAction == ActionDeny {
+		for _, b := range cfg.domainLists {
+			fmt.Fprintf(h, "dbl=%s\n", b.Zone)
+		}
+	}
+	fmt.Fprintf(h, "subdomains=%+v\n", cfg.subdomains)
+	fmt.Fprintf(h, "platforms=%q,%d\n", slices.Sorted(slices.Values(cfg.platformSuffixes)), cfg.platformAction)
+	for _, s := range cfg.stages {
+		fmt.Fprintf(h, "stage=%s\n", s.Name())
+	}
+	for _, r := range cfg.tldRules {
+		fmt.Fprintf(h, "tld=%q,%d\n", r.Suffixes, r.Action)
+	}
+	for _, fp := range cfg.fingerprints {
+		fmt.Fprintf(h, "fp=%s,%q,%q,%q\n", fp.Provider, fp.MXHosts, fp.NSHosts, fp.IPs)
+	}
+	return hex.EncodeToString(h.Sum(nil)[:6])
+}
+
+// storeKey is the store key of domain's cached verdict.
+func (g *Guard) storeKey(domain string) string {
+	return g.policy() + "|" + domain
+}
@@ -0,0 +1,163 @@
+// Package corpus is a curated, versioned set of categorized addresses with
+// the verdicts emailguard gives them, and a runner that reports where a
+// Guard now disagrees. Each case carries its MX records, which are handed
+// to the guard with emailguard.UsingDNS, and the disposable blocklist is a
+// fixture, so runs are deterministic and need no network. A behavior
+// change shows up as a diff of corpus.jsonl when it is rewritten.
+package corpus
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/vandit1604/emailguard"
+)
+
+// Version is bumped when cases are added or removed, as opposed to
+// expectations changing with emailguard's behavior.
+const Version = 2
+
+//go:embed corpus.jsonl blocklist.conf
+var files embed.FS
+
+// Case is one address and the verdict expected for it.
+type Case struct {
+	Category string   `json:"category"` // e.g. disposable, edu, idn
+	Email    string   `json:"email"`
+	MX       []string `json:"mx,omitempty"` // the domain's MX hosts; none if empty
+	Want     Want     `json:"want"`
+}
+
+// Want is the part of a verdict the corpus pins down.
+type Want struct {
+	Outcome        emailguard.Outcome        `json:"outcome"`
+	Reason         emailguard.Reason         `json:"reason,omitempty"`
+	Classification emailguard.Classification `json:"classification,omitempty"`
+	Flags          []string                  `json:"flags,omitempty"`
+	Suggestion     string                    `json:"suggestion,omitempty"`
+}
+
+func (w Want) String() string {
+	s := fmt.Sprintf("%s %s %s", w.Outcome, w.Reason, w.Classification)
+	if len(w.Flags) > 0 {
+		s += " [" + strings.Join(w.Flags, ",") + "]"
+	}
+	if w.Suggestion != "" {
+		s += " ->" + w.Suggestion
+	}
+	return s
+}
+
+func (w Want) equal(o Want) bool {
+	return w.Outcome == o.Outcome && w.Reason == o.Reason && w.Classification == o.Classification &&
+		slices.Equal(w.Flags, o.Flags) && w.Suggestion == o.Suggestion
+}
+
+// Default returns the built-in corpus.
+func Default() []Case {
+	f, err := files.Open("corpus.jsonl")
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	cases, err := Read(f)
+	if err != nil {
+		panic("corpus: built-in corpus: " + err.Error())
+	}
+	return cases
+}
+
+// Read parses a corpus: one JSON case per line, with blank lines and lines
+// starting with # ignored.
+func Read(r io.Reader) ([]Case, error) {
+	var cases []Case
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		var c Case
+		dec := json.NewDecoder(bytes.NewReader(line))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&c); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		cases = append(cases, c)
+	}
+	return cases, sc.Err()
+}
+
+// Write writes cases in the format Read parses, under a version header.
+func Write(w io.Writer, cases []Case) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# emailguard conformance corpus, version %d\n", Version)
+	for _, c := range cases {
+		b, err := json.Marshal(c)
+		if err != nil {
+			return err
+		}
+		bw.Write(b)
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// NewGuard returns a guard set up for the corpus: the fixture blocklist as
+// the only list source, then opts.
+func NewGuard(opts ...emailguard.Option) (*emailguard.Guard, error) {
+	return emailguard.New(append([]emailguard.Option{emailguard.WithBlocklistFS(files, "blocklist.conf")}, opts...)...)
+}
+
+// Result is a case and the verdict the guard gave it.
+type Result struct {
+	Case
+	Got Want
+}
+
+// OK reports whether the guard gave the expected verdict.
+func (r Result) OK() bool {
+	return r.Want.equal(r.Got)
+}
+
+// Run checks every case with g.
+func Run(ctx context.Context, g *emailguard.Guard, cases []Case) []Result {
+	out := make([]Result, len(cases))
+	for i, c := range cases {
+		var opts []emailguard.CheckOption
+		if at := strings.LastIndexByte(c.Email, '@'); at >= 0 {
+			domain, mx := c.Email[at+1:], c.MX
+			if mx == nil {
+				mx = []string{} // no MX, rather than unknown
+			}
+			opts = append(opts, emailguard.UsingDNS(emailguard.ResolvedDNS{Domain: domain, MX: mx}))
+		}
+		v, _ := g.Validate(ctx, c.Email, opts...)
+		out[i] = Result{Case: c, Got: Want{
+			Outcome:        v.Outcome,
+			Reason:         v.Reason,
+			Classification: v.Classification,
+			Flags:          v.Flags,
+			Suggestion:     v.Suggestion,
+		}}
+	}
+	return out
+}
+
+// Updated returns the cases with their expectations replaced by what the
+// guard gave, for rewriting the corpus after an intended change.
+func Updated(results []Result) []Case {
+	out := make([]Case, len(results))
+	for i, r := range results {
+		out[i] = r.Case
+		out[i].Want = r.Got
+	}
+	return out
+}
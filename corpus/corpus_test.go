@@ -0,0 +1,25 @@
+package corpus
+
+import (
+	"context"
+	"testing"
+)
+
+// TestDefault runs the built-in corpus and fails on every case whose
+// verdict changed. After an intended change, rewrite the corpus with
+// "emailguard conformance -write" and review the diff.
+func TestDefault(t *testing.T) {
+	g, err := NewGuard()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := Default()
+	if len(cases) == 0 {
+		t.Fatal("empty corpus")
+	}
+	for _, r := range Run(context.Background(), g, cases) {
+		if !r.OK() {
+			t.Errorf("%s %s\n  - %s\n  + %s", r.Category, r.Email, r.Want, r.Got)
+		}
+	}
+}
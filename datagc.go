@@ -0,0 +1,189 @@
+package emailguard
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultTempAge is how old a temp file or half-cloned checkout must be
+// before garbage collection treats it as abandoned rather than in progress.
+const defaultTempAge = time.Hour
+
+// DataGC bounds what the guard keeps under its blocklist directory: the
+// checkout of each source (see WithBlocklistDir) and of each mirror.
+type DataGC struct {
+	// MaxBytes caps the total size of the copies; zero means no cap. Over
+	// it, copies other than the freshest go, least recently pulled first.
+	// Every copy is re-fetched when its source is next needed.
+	MaxBytes int64
+	// TempAge is how old temp files and interrupted clones must be before
+	// they are removed; zero means an hour.
+	TempAge time.Duration
+	// DryRun reports what would be removed without removing it.
+	DryRun bool
+}
+
+// GCItem is one path removed by garbage collection.
+type GCItem struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+	Bytes  int64  `json:"bytes"`
+}
+
+// GCReport is the outcome of a garbage collection.
+type GCReport struct {
+	Removed []GCItem `json:"removed"`
+	Freed   int64    `json:"freed_bytes"`
+	Size    int64    `json:"size_bytes"` // what is left
+}
+
+// WithDataGC collects garbage in the blocklist directory after every
+// refresh made by RunBlocklistRefresh.
+func WithDataGC(opts DataGC) Option {
+	return func(c *config) error {
+		if opts.MaxBytes < 0 || opts.TempAge < 0 {
+			return fmt.Errorf("WithDataGC: need non-negative limits, got %d bytes, %v", opts.MaxBytes, opts.TempAge)
+		}
+		c.dataGC = &opts
+		return nil
+	}
+}
+
+// CollectGarbage cleans up the blocklist directory: copies of sources that
+// are no longer configured, temp files and clones left behind by
+// interrupted refreshes, and, with MaxBytes, whole copies until the rest
+// fits. It never removes the freshest list on disk. Refreshes wait while it
+// runs.
+func (g *Guard) CollectGarbage(opts DataGC) (GCReport, error) {
+	if g.blocklist.fsys != nil {
+		return GCReport{}, nil // nothing on disk
+	}
+	return g.blocklist.gc(opts)
+}
+
+func (b *blocklist) gc(opts DataGC) (GCReport, error) {
+	b.diskMu.Lock()
+	defer b.diskMu.Unlock()
+	unlock, err := lockDir(b.dir)
+	if err != nil {
+		return GCReport{}, err
+	}
+	defer unlock()
+
+	age := opts.TempAge
+	if age == 0 {
+		age = defaultTempAge
+	}
+	cutoff := time.Now().Add(-age)
+	var rep GCReport
+	var errs []error
+	remove := func(path, reason string) bool {
+		n := diskUsage(path)
+		if !opts.DryRun {
+			if err := removeAll(path); err != nil {
+				errs = append(errs, err)
+				return false
+			}
+		}
+		rep.Removed = append(rep.Removed, GCItem{Path: path, Reason: reason, Bytes: n})
+		rep.Freed += n
+		return true
+	}
+
+	// mirrors dropped from the configuration
+	mirrors, _ := filepath.Glob(b.dir + "-mirror*")
+	for _, m := range mirrors {
+		if i, err := strconv.Atoi(strings.TrimPrefix(m, b.dir+"-mirror")); err == nil && i >= len(b.sources) {
+			remove(m, "source no longer configured")
+		}
+	}
+
+	sizes := make(map[int]int64) // copies still present
+	for i, src := range b.sources {
+		dir := b.sourceDir(i)
+		fi, err := os.Stat(dir)
+		if err != nil {
+			continue
+		}
+		if isGitSource(src) && fi.ModTime().Before(cutoff) && lastPull(dir).IsZero() {
+			if !isGitCheckout(dir) {
+				remove(dir, "interrupted clone")
+				continue
+			}
+		}
+		size := diskUsage(dir)
+		filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !isTempFile(d.Name()) {
+				return nil
+			}
+			if info, err := d.Info(); err == nil && info.ModTime().Before(cutoff) && remove(p, "orphaned temp file") {
+				size -= info.Size()
+			}
+			return nil
+		})
+		sizes[i] = size
+	}
+	for _, n := range sizes {
+		rep.Size += n
+	}
+
+	if opts.MaxBytes > 0 && rep.Size > opts.MaxBytes {
+		keep, _ := b.freshestCopy()
+		var drop []int
+		for i := range sizes {
+			if filepath.Join(b.sourceDir(i), blocklistFile) != keep {
+				drop = append(drop, i)
+			}
+		}
+		slices.SortFunc(drop, func(i, j int) int {
+			return lastPull(b.sourceDir(i)).Compare(lastPull(b.sourceDir(j)))
+		})
+		for _, i := range drop {
+			if rep.Size <= opts.MaxBytes {
+				break
+			}
+			if remove(b.sourceDir(i), "over disk cap") {
+				rep.Size -= sizes[i]
+			}
+		}
+		if rep.Size > opts.MaxBytes {
+			errs = append(errs, fmt.Errorf("data directory still holds %d bytes, over the %d byte cap, with only the freshest list left", rep.Size, opts.MaxBytes))
+		}
+	}
+	return rep, errors.Join(errs...)
+}
+
+// isTempFile matches temp files written by writeFileAtomic and by git.
+func isTempFile(name string) bool {
+	return strings.HasPrefix(name, ".") && strings.Contains(name, ".tmp") ||
+		strings.HasPrefix(name, "tmp_pack_") || strings.HasPrefix(name, "tmp_obj_") || strings.HasPrefix(name, "tmp_idx_")
+}
+
+// lastPull is when the copy in dir was last refreshed, zero if unknown.
+func lastPull(dir string) time.Time {
+	if fi, err := os.Stat(filepath.Join(dir, blocklistFile)); err == nil {
+		return fi.ModTime()
+	}
+	return time.Time{}
+}
+
+// diskUsage sums the sizes of the files under path.
+func diskUsage(path string) int64 {
+	var n int64
+	filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			if info, err := d.Info(); err == nil {
+				n += info.Size()
+			}
+		}
+		return nil
+	})
+	return n
+}
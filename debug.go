@@ -0,0 +1,251 @@
+package emailguard
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	hotDomainsCap   = 10000 // distinct domains tracked for top-N
+	recentRejectCap = 100   // ring buffer size
+	defaultTopN     = 20
+)
+
+// debugStats collects cheap runtime telemetry for DebugStats.
+type debugStats struct {
+	// domain -> *atomic.Int64; bounded by hotDomainsCap
+	hot     sync.Map
+	hotSize atomic.Int64
+
+	rejectMu   sync.Mutex
+	rejects    [recentRejectCap]RecentReject
+	rejectNext int
+	rejectN    int
+}
+
+// RecentReject is a rejected check with the address hashed, so debug output
+// never exposes raw emails. The hash is keyed: in privacy mode it is the
+// local part's pseudonym, otherwise an HMAC under a random key drawn at
+// process start, so repeat rejects of one address match within a run but
+// guessed addresses cannot be hashed to find them.
+type RecentReject struct {
+	Time      time.Time `json:"time"`
+	EmailHash string    `json:"email_hash"` // 16 hex chars, or a pseudonym in privacy mode
+	Domain    string    `json:"domain"`
+	Layer     string    `json:"layer"`
+	Detail    string    `json:"detail"`
+}
+
+// debugKey keys RecentReject.EmailHash outside privacy mode.
+var debugKey = func() []byte {
+	k := make([]byte, 32)
+	rand.Read(k)
+	return k
+}()
+
+// record counts v; pseudonymized says v.Email is already in privacy form.
+func (s *debugStats) record(v Verdict, pseudonymized bool) {
+	if v.Domain != "" {
+		s.hit(v.Domain)
+	}
+	if v.Outcome == OutcomeReject {
+		s.reject(v, pseudonymized)
+	}
+}
+
+func (s *debugStats) hit(domain string) {
+	if c, ok := s.hot.Load(domain); ok {
+		c.(*atomic.Int64).Add(1)
+		return
+	}
+	if s.hotSize.Load() >= hotDomainsCap {
+		return // table full; new domains are not tracked
+	}
+	c, loaded := s.hot.LoadOrStore(domain, new(atomic.Int64))
+	if !loaded {
+		s.hotSize.Add(1)
+	}
+	c.(*atomic.Int64).Add(1)
+}
+
+func (s *debugStats) reject(v Verdict, pseudonymized bool) {
+	hash := macHex(debugKey, v.Email)
+	if pseudonymized {
+		hash, _, _ = strings.Cut(v.Email, "@")
+	}
+	r := RecentReject{
+		Time:      time.Now(),
+		EmailHash: hash,
+		Domain:    v.Domain,
+		Layer:     v.Layer,
+		Detail:    v.Detail,
+	}
+	s.rejectMu.Lock()
+	s.rejects[s.rejectNext] = r
+	s.rejectNext = (s.rejectNext + 1) % recentRejectCap
+	s.rejectN = min(s.rejectN+1, recentRejectCap)
+	s.rejectMu.Unlock()
+}
+
+// recentRejects returns the buffered rejects, newest first.
+func (s *debugStats) recentRejects() []RecentReject {
+	s.rejectMu.Lock()
+	defer s.rejectMu.Unlock()
+	out := make([]RecentReject, 0, s.rejectN)
+	for i := 1; i <= s.rejectN; i++ {
+		out = append(out, s.rejects[(s.rejectNext-i+recentRejectCap)%recentRejectCap])
+	}
+	return out
+}
+
+// DomainCount is a domain and how often it was checked.
+type DomainCount struct {
+	Domain string `json:"domain"`
+	Count  int64  `json:"count"`
+}
+
+// CacheStats summarizes a cache.
+type CacheStats struct {
+	Entries int `json:"entries"`
+	Expired int `json:"expired"` // still held, awaiting overwrite
+	Allow   int `json:"allow,omitempty"`
+	Deny    int `json:"deny,omitempty"`
+}
+
+// NegativeCacheStats summarizes DNS answers cached as nonexistent; see
+// WithNegativeCacheTTL.
+type NegativeCacheStats struct {
+	Entries int     `json:"entries"`  // unexpired MX, NS and TXT entries
+	Hits    int64   `json:"hits"`     // lookups they answered
+	Lookups int64   `json:"lookups"`  // MX, NS and TXT lookups, cached or not
+	HitRate float64 `json:"hit_rate"` // Hits over Lookups
+}
+
+// DebugStats is a point-in-time summary of a Guard's internal state.
+type DebugStats struct {
+	VerdictCache  CacheStats         `json:"verdict_cache"`
+	MXCache       CacheStats         `json:"mx_cache"`
+	NegativeCache NegativeCacheStats `json:"negative_cache"`
+	BlocklistSize int                `json:"blocklist_size"`
+	// BlocklistAge is how long ago the list was last refreshed from a
+	// source, zero until a refresh succeeds; alert on it, or on
+	// BlocklistRefreshFailures
+	BlocklistAge             time.Duration  `json:"blocklist_age_ns"`
+	BlocklistRefreshFailures int            `json:"blocklist_refresh_failures"` // consecutive
+	PendingRechecks          int            `json:"pending_rechecks"`
+	DNSInUse                 int            `json:"dns_in_use"`
+	SMTPInUse                int            `json:"smtp_in_use"`
+	BatchDNSInUse            int            `json:"batch_dns_in_use"`
+	BatchSMTPInUse           int            `json:"batch_smtp_in_use"`
+	DNSQueued                int            `json:"dns_queued"` // waiting for a DNS slot
+	SMTPQueued               int            `json:"smtp_queued"`
+	BatchDNSQueued           int            `json:"batch_dns_queued"`
+	BatchSMTPQueued          int            `json:"batch_smtp_queued"`
+	KillSwitches             []string       `json:"kill_switches,omitempty"` // checks switched off at runtime
+	HotDomains               []DomainCount  `json:"hot_domains"`
+	RecentRejects            []RecentReject `json:"recent_rejects"`
+}
+
+// DebugStats summarizes caches, the topN most-checked domains and recent
+// rejects (with addresses hashed).
+func (g *Guard) DebugStats(topN int) DebugStats {
+	now := time.Now()
+	var st DebugStats
+
+	g.verdicts.Range(func(_, v any) bool {
+		e := v.(verdictEntry)
+		st.VerdictCache.Entries++
+		if now.After(e.exp) {
+			st.VerdictCache.Expired++
+		}
+		if e.res.Action == ActionAllow {
+			st.VerdictCache.Allow++
+		} else {
+			st.VerdictCache.Deny++
+		}
+		return true
+	})
+	g.mxCache.Range(func(_, v any) bool {
+		e := v.(mxEntry)
+		st.MXCache.Entries++
+		if now.After(e.exp) {
+			st.MXCache.Expired++
+		} else if e.hosts == nil {
+			st.NegativeCache.Entries++
+		}
+		return true
+	})
+	for _, c := range []*sync.Map{&g.nsCache, &g.txtCache} {
+		c.Range(func(_, v any) bool {
+			if e := v.(dnsCacheEntry); len(e.hosts) == 0 && now.Before(e.exp) {
+				st.NegativeCache.Entries++
+			}
+			return true
+		})
+	}
+	st.NegativeCache.Hits, st.NegativeCache.Lookups = g.negativeHits.Load(), g.dnsLookups.Load()
+	if st.NegativeCache.Lookups > 0 {
+		st.NegativeCache.HitRate = float64(st.NegativeCache.Hits) / float64(st.NegativeCache.Lookups)
+	}
+	st.BlocklistSize = g.blocklist.load().Len()
+	rs := g.blocklist.refreshStatus()
+	if !rs.lastSuccess.IsZero() {
+		st.BlocklistAge = now.Sub(rs.lastSuccess)
+	}
+	st.BlocklistRefreshFailures = rs.failures
+	st.PendingRechecks = len(g.PendingRechecks())
+	st.DNSInUse = g.dnsPool.inUse()
+	st.SMTPInUse = g.smtpPool.inUse()
+	st.BatchDNSInUse = g.batchDNSPool.inUse()
+	st.BatchSMTPInUse = g.batchSMTPPool.inUse()
+	st.DNSQueued = g.dnsPool.queued()
+	st.SMTPQueued = g.smtpPool.queued()
+	st.BatchDNSQueued = g.batchDNSPool.queued()
+	st.BatchSMTPQueued = g.batchSMTPPool.queued()
+	st.KillSwitches = g.KillSwitches()
+
+	g.stats.hot.Range(func(k, v any) bool {
+		st.HotDomains = append(st.HotDomains, DomainCount{Domain: k.(string), Count: v.(*atomic.Int64).Load()})
+		return true
+	})
+	sort.Slice(st.HotDomains, func(i, j int) bool {
+		if st.HotDomains[i].Count != st.HotDomains[j].Count {
+			return st.HotDomains[i].Count > st.HotDomains[j].Count
+		}
+		return st.HotDomains[i].Domain < st.HotDomains[j].Domain
+	})
+	if topN > 0 && len(st.HotDomains) > topN {
+		st.HotDomains = st.HotDomains[:topN]
+	}
+	st.RecentRejects = g.stats.recentRejects()
+	return st
+}
+
+// DebugHandler serves DebugStats as JSON. The optional "top" query parameter
+// sets how many hot domains are listed.
+func (g *Guard) DebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		topN := defaultTopN
+		if n, err := strconv.Atoi(r.URL.Query().Get("top")); err == nil && n > 0 {
+			topN = n
+		}
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(g.DebugStats(topN))
+	})
+}
+
+// PublishExpvar publishes DebugStats under name in the expvar registry
+// (served at /debug/vars). Like expvar.Publish it panics if name is taken.
+func (g *Guard) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() any { return g.DebugStats(defaultTopN) }))
+}
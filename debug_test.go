@@ -0,0 +1,37 @@
+package emailguard
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestRecentRejectHash(t *testing.T) {
+	const email = "jane@mailinator.com"
+	ctx := context.Background()
+
+	g := newTestGuard(t)
+	g.Validate(ctx, email)
+	g.Validate(ctx, email)
+	rr := g.stats.recentRejects()
+	if len(rr) != 2 {
+		t.Fatalf("%d recent rejects, want 2", len(rr))
+	}
+	sum := sha256.Sum256([]byte(email))
+	if h := rr[0].EmailHash; h == hex.EncodeToString(sum[:8]) || strings.Contains(h, "jane") {
+		t.Errorf("EmailHash %q can be recomputed from the address", h)
+	}
+	if rr[0].EmailHash != rr[1].EmailHash {
+		t.Errorf("repeat rejects hash differently: %q, %q", rr[0].EmailHash, rr[1].EmailHash)
+	}
+
+	p := newTestGuard(t, WithPrivacy([]byte("0123456789abcdef")))
+	p.Validate(ctx, email)
+	pseudonym, _ := p.Pseudonymize(email)
+	want, _, _ := strings.Cut(pseudonym, "@")
+	if h := p.stats.recentRejects()[0].EmailHash; h != want {
+		t.Errorf("privacy mode EmailHash %q, want the pseudonym's %q", h, want)
+	}
+}
@@ -0,0 +1,115 @@
+package emailguard
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// lockDir takes an exclusive lock on dir that other processes using it, such
+// as a server and the CLI, also take, so only one of them refreshes or
+// collects garbage there at a time. The lock file sits beside dir rather
+// than in it, since a refresh may delete and re-clone dir.
+func lockDir(dir string) (unlock func(), err error) {
+	if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(dir+".lock", os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("locking %s: %w", f.Name(), err)
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
+
+// CheckDataDir checks that dir, meant for WithBlocklistDir, supports what the
+// guard does there on this platform and filesystem: replacing a list that
+// another handle has open, locking against other processes and deleting a
+// checkout with read-only files, as git leaves them. Run it when deploying
+// somewhere new, such as a Windows desktop or a network share. It works in
+// a scratch directory under dir and removes it afterwards.
+func CheckDataDir(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	scratch, err := os.MkdirTemp(dir, ".checkdata")
+	if err != nil {
+		return err
+	}
+	defer removeAll(scratch)
+	var errs []error
+
+	// atomic replacement under a reader
+	list := filepath.Join(scratch, "list", blocklistFile)
+	if err := writeFileAtomic(list, []byte("one.example\n"), 0o644); err != nil {
+		errs = append(errs, fmt.Errorf("writing a list: %w", err))
+	} else if f, err := os.Open(list); err != nil {
+		errs = append(errs, err)
+	} else {
+		if err := writeFileAtomic(list, []byte("two.example\n"), 0o644); err != nil {
+			errs = append(errs, fmt.Errorf("replacing a list that is open: %w", err))
+		} else if set, err := readBlocklist(list); err != nil || !set.contains("two.example") {
+			errs = append(errs, fmt.Errorf("replaced list reads back wrong (%v)", err))
+		}
+		f.Close()
+	}
+
+	// locks exclude each other
+	if crossProcessLocks {
+		if err := checkLockExclusion(filepath.Join(scratch, "lock")); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// read-only checkout
+	obj := filepath.Join(scratch, "clone", ".git", "objects", "pack", "pack-1.pack")
+	if err := os.MkdirAll(filepath.Dir(obj), 0o755); err != nil {
+		errs = append(errs, err)
+	} else if err := os.WriteFile(obj, []byte("PACK"), 0o444); err != nil {
+		errs = append(errs, err)
+	} else if err := removeAll(filepath.Join(scratch, "clone")); err != nil {
+		errs = append(errs, fmt.Errorf("removing a checkout with read-only files: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// checkLockExclusion checks that a second lockDir on dir waits for the first
+// to be released, and is then granted.
+func checkLockExclusion(dir string) error {
+	unlock, err := lockDir(dir)
+	if err != nil {
+		return err
+	}
+	got := make(chan error, 1)
+	go func() {
+		u, err := lockDir(dir)
+		if err == nil {
+			u()
+		}
+		got <- err
+	}()
+	select {
+	case err := <-got:
+		unlock()
+		if err != nil {
+			return err
+		}
+		return errors.New("a second lock on the directory was granted while the first was held")
+	case <-time.After(100 * time.Millisecond):
+	}
+	unlock()
+	select {
+	case err := <-got:
+		return err
+	case <-time.After(5 * time.Second):
+		return errors.New("a released lock was never granted to the waiter")
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows) || (windows && emailguard_stdlib)
+
+package emailguard
+
+import "os"
+
+// Elsewhere (Solaris, Plan 9, WASI, ...), and on Windows without
+// golang.org/x/sys, there is no lock between processes; within one,
+// refreshes and garbage collection still take turns.
+const crossProcessLocks = false
+
+func lockFile(*os.File) error   { return nil }
+func unlockFile(*os.File) error { return nil }
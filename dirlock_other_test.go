@@ -0,0 +1,24 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows) || (windows && emailguard_stdlib)
+
+package emailguard
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// Without cross-process locks, lockDir must not block or fail: callers
+// rely on it only to serialize work within the process.
+func TestLockDirWithoutLocks(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "lists")
+	u1, err := lockDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer u1()
+	u2, err := lockDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u2()
+}
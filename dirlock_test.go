@@ -0,0 +1,82 @@
+package emailguard
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// lockHelperEnv turns the test binary into a second process that locks the
+// directory it names, releases it and exits; see TestMain.
+const lockHelperEnv = "EMAILGUARD_TEST_LOCK_DIR"
+
+func TestMain(m *testing.M) {
+	if dir := os.Getenv(lockHelperEnv); dir != "" {
+		os.Stdout.WriteString("waiting\n")
+		unlock, err := lockDir(dir)
+		if err != nil {
+			os.Stderr.WriteString(err.Error() + "\n")
+			os.Exit(1)
+		}
+		unlock()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func TestCheckDataDir(t *testing.T) {
+	if err := CheckDataDir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testLockContention checks that another process asking for a lock held
+// here waits until it is released, and then gets it.
+func testLockContention(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "lists")
+	unlock, err := lockDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if unlock != nil {
+			unlock()
+		}
+	}()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	cmd.Env = append(os.Environ(), lockHelperEnv+"="+dir)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bufio.NewReader(out).ReadString('\n'); err != nil {
+		t.Fatalf("helper process: %v", err)
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	select {
+	case err := <-exited:
+		t.Fatalf("another process took the lock while it was held (exit: %v)", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+	unlock()
+	unlock = nil
+	select {
+	case err := <-exited:
+		if err != nil {
+			t.Fatalf("helper process: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		cmd.Process.Kill()
+		t.Fatal("the released lock was never granted to the other process")
+	}
+}
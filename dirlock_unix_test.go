@@ -0,0 +1,18 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package emailguard
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLockDirExcludesOtherProcesses(t *testing.T) {
+	testLockContention(t)
+}
+
+func TestLockDirExcludesWithinProcess(t *testing.T) {
+	if err := checkLockExclusion(filepath.Join(t.TempDir(), "lists")); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,21 @@
+//go:build !emailguard_stdlib
+
+package emailguard
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+const crossProcessLocks = true
+
+// lockFile locks f's first byte, which is enough: every process locks the
+// same range.
+func lockFile(f *os.File) error {
+	return windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, new(windows.Overlapped))
+}
+
+func unlockFile(f *os.File) error {
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, new(windows.Overlapped))
+}
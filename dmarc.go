@@ -0,0 +1,137 @@
+package emailguard
+
+import (
+	"context"
+	"strings"
+)
+
+// DMARCPolicy is what a domain asks receivers to do with mail failing
+// DMARC, or DMARCMissing if it publishes no DMARC record.
+type DMARCPolicy string
+
+// DMARC policies.
+const (
+	DMARCMissing    DMARCPolicy = "missing"
+	DMARCNone       DMARCPolicy = "none"
+	DMARCQuarantine DMARCPolicy = "quarantine"
+	DMARCReject     DMARCPolicy = "reject"
+)
+
+// DMARC signal names, one per policy.
+const (
+	SignalDMARCReject     = "dmarc_reject"
+	SignalDMARCQuarantine = "dmarc_quarantine"
+	SignalDMARCNone       = "dmarc_none"
+	SignalDMARCMissing    = "dmarc_missing"
+)
+
+// dmarcSignals weighs each policy: enforcement is reassuring, a missing
+// record is what throwaway domains look like.
+var dmarcSignals = map[DMARCPolicy]Signal{
+	DMARCReject:     {Name: SignalDMARCReject, Weight: -10},
+	DMARCQuarantine: {Name: SignalDMARCQuarantine, Weight: -5},
+	DMARCNone:       {Name: SignalDMARCNone, Weight: 5},
+	DMARCMissing:    {Name: SignalDMARCMissing, Weight: 15},
+}
+
+// DMARCInfo is what WithDMARCCheck found for a domain.
+type DMARCInfo struct {
+	Policy DMARCPolicy `json:"policy"`
+	Record string      `json:"record,omitempty"` // the v=DMARC1 TXT record; empty if none
+	Domain string      `json:"domain,omitempty"` // where the record was found: the domain or its organizational domain
+}
+
+// Enforced reports whether the policy is quarantine or reject.
+func (d DMARCInfo) Enforced() bool {
+	return d.Policy == DMARCQuarantine || d.Policy == DMARCReject
+}
+
+// WithDMARCCheck looks up the DMARC record of each allowed domain, falling
+// back to its organizational domain as receivers do (RFC 7489 section
+// 6.6.3). The policy is reported in Verdict.DMARC and as a signal: reject
+// and quarantine lower Score, none and a missing record raise it. The
+// outcome is unaffected. A failed lookup adds nothing.
+func WithDMARCCheck() Option {
+	return func(c *config) error {
+		c.dmarc = true
+		return nil
+	}
+}
+
+// inspectDMARC adds v's DMARC policy and its signal.
+func (g *Guard) inspectDMARC(ctx context.Context, v *Verdict) {
+	info, err := g.lookupDMARC(ctx, v.Domain, v.RegistrableDomain)
+	if err != nil {
+		return
+	}
+	v.DMARC = &info
+	s := dmarcSignals[info.Policy]
+	s.Detail = "no DMARC record"
+	if info.Record != "" {
+		s.Detail = "p=" + string(info.Policy) + " at _dmarc." + info.Domain
+	}
+	v.Signals = append(v.Signals, s)
+}
+
+// lookupDMARC finds the DMARC record for domain, or for org, whose sp=
+// then applies, when domain has none.
+func (g *Guard) lookupDMARC(ctx context.Context, domain, org string) (DMARCInfo, error) {
+	txt, err := g.lookupTXT(ctx, "_dmarc."+domain)
+	if err != nil {
+		return DMARCInfo{}, err
+	}
+	if rec, ok := dmarcRecord(txt); ok {
+		return DMARCInfo{Policy: dmarcPolicy(rec, false), Record: rec, Domain: domain}, nil
+	}
+	if org == "" || org == domain {
+		return DMARCInfo{Policy: DMARCMissing}, nil
+	}
+	if txt, err = g.lookupTXT(ctx, "_dmarc."+org); err != nil {
+		return DMARCInfo{}, err
+	}
+	if rec, ok := dmarcRecord(txt); ok {
+		return DMARCInfo{Policy: dmarcPolicy(rec, true), Record: rec, Domain: org}, nil
+	}
+	return DMARCInfo{Policy: DMARCMissing}, nil
+}
+
+// dmarcRecord picks the DMARC record out of TXT records. Receivers ignore
+// the records when there is more than one (RFC 7489 section 6.6.3).
+func dmarcRecord(txt []string) (string, bool) {
+	var rec string
+	n := 0
+	for _, t := range txt {
+		tag, _, _ := strings.Cut(t, ";")
+		if strings.EqualFold(strings.ReplaceAll(strings.TrimSpace(tag), " ", ""), "v=DMARC1") {
+			rec = strings.TrimSpace(t)
+			n++
+		}
+	}
+	return rec, n == 1
+}
+
+// dmarcPolicy reads the p= tag of rec, or sp= for a subdomain when present.
+// A missing or invalid policy counts as none: it enforces nothing.
+func dmarcPolicy(rec string, subdomain bool) DMARCPolicy {
+	var p, sp string
+	for _, tag := range strings.Split(rec, ";") {
+		k, val, ok := strings.Cut(tag, "=")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(k)) {
+		case "p":
+			p = strings.ToLower(strings.TrimSpace(val))
+		case "sp":
+			sp = strings.ToLower(strings.TrimSpace(val))
+		}
+	}
+	if subdomain && sp != "" {
+		p = sp
+	}
+	switch DMARCPolicy(p) {
+	case DMARCQuarantine, DMARCReject:
+		return DMARCPolicy(p)
+	}
+	return DMARCNone
+}
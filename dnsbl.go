@@ -0,0 +1,220 @@
+package emailguard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FlagDNSBLListed marks a domain one of whose mail servers is listed on a
+// DNSBL under WithDNSBL: it sends spam, or did recently.
+const FlagDNSBLListed = "dnsbl_listed"
+
+// Zones of well-known DNSBLs. Most limit free use and refuse queries
+// relayed by public resolvers, so point the guard at your own resolver
+// (see WithDNSServers) when using them.
+const (
+	DNSBLSpamhausZEN = "zen.spamhaus.org"
+	DNSBLSpamCop     = "bl.spamcop.net"
+	DNSBLBarracuda   = "b.barracudacentral.org"
+)
+
+// DNSBL is a DNS blocklist: of IP addresses for WithDNSBL, of domains for
+// WithDomainBlocklists.
+type DNSBL struct {
+	Zone    string        `json:"zone"`    // e.g. DNSBLSpamhausZEN
+	Timeout time.Duration `json:"timeout"` // per query; zero: WithMXTimeout
+}
+
+type dnsblJSON struct {
+	Zone    string `json:"zone"`
+	Timeout string `json:"timeout,omitempty"`
+}
+
+func (b DNSBL) MarshalJSON() ([]byte, error) {
+	j := dnsblJSON{Zone: b.Zone}
+	if b.Timeout > 0 {
+		j.Timeout = b.Timeout.String()
+	}
+	return json.Marshal(j)
+}
+
+func (b *DNSBL) UnmarshalJSON(data []byte) error {
+	var j dnsblJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	*b = DNSBL{Zone: j.Zone}
+	if j.Timeout != "" {
+		d, err := time.ParseDuration(j.Timeout)
+		if err != nil {
+			return fmt.Errorf("timeout: %q is not a duration like \"500ms\"", j.Timeout)
+		}
+		b.Timeout = d
+	}
+	return nil
+}
+
+func (b DNSBL) validate() error {
+	if err := checkHostname(normDomain(b.Zone)); err != nil {
+		return fmt.Errorf("zone %q: %w", b.Zone, err)
+	}
+	if b.Timeout < 0 {
+		return fmt.Errorf("zone %s: negative timeout %v", b.Zone, b.Timeout)
+	}
+	return nil
+}
+
+// DNSBLHit is a mail server listing found by WithDNSBL.
+type DNSBLHit struct {
+	Zone string     `json:"zone"`
+	Host string     `json:"host"` // the MX host
+	IP   netip.Addr `json:"ip"`
+	Code netip.Addr `json:"code"` // the list's answer, such as 127.0.0.2, which says why it is listed
+}
+
+// WithDNSBL resolves the MX hosts of allowed domains and looks their
+// addresses up on the given DNSBLs, queried concurrently. A listed server
+// adds FlagDNSBLListed, which raises Score, and the listings are reported
+// in Verdict.DNSBL; the outcome is unaffected. Answers are cached like
+// other DNS results. Failed or refused queries add nothing.
+func WithDNSBL(lists ...DNSBL) Option {
+	return func(c *config) error {
+		for _, b := range lists {
+			if err := b.validate(); err != nil {
+				return fmt.Errorf("WithDNSBL: %w", err)
+			}
+			b.Zone = normDomain(b.Zone)
+			c.dnsbls = append(c.dnsbls, b)
+		}
+		return nil
+	}
+}
+
+// dnsblEntry is a cached DNSBL answer; code is invalid if not listed.
+type dnsblEntry struct {
+	code netip.Addr
+	exp  time.Time
+}
+
+// checkDNSBL adds the DNSBL listings of v's mail servers.
+func (g *Guard) checkDNSBL(ctx context.Context, v *Verdict) {
+	hosts, ok := g.knownMX(ctx, v.MXDomain)
+	if !ok {
+		return
+	}
+	type target struct {
+		host string
+		ip   netip.Addr
+	}
+	var targets []target
+	seen := make(map[netip.Addr]bool)
+	for _, h := range hosts {
+		addrs, err := g.lookupAddrs(ctx, []string{h})
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			if !seen[a] {
+				seen[a] = true
+				targets = append(targets, target{normDomain(h), a})
+			}
+		}
+	}
+	hits := make([][]DNSBLHit, len(g.cfg.dnsbls))
+	var wg sync.WaitGroup
+	for i, bl := range g.cfg.dnsbls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, t := range targets {
+				if code, err := g.queryDNSBL(ctx, bl, reverseIP(t.ip)); err == nil && code.IsValid() {
+					hits[i] = append(hits[i], DNSBLHit{Zone: bl.Zone, Host: t.host, IP: t.ip, Code: code})
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	for _, h := range hits {
+		v.DNSBL = append(v.DNSBL, h...)
+	}
+	if len(v.DNSBL) > 0 {
+		v.Flags = append(v.Flags, FlagDNSBLListed)
+	}
+}
+
+// queryDNSBL returns bl's answer for key, a reversed IP address or a
+// domain, or an invalid address if key is not listed. Answers outside
+// 127.0.0.0/8 are not listings. 127.255.255.0/24 is how Spamhaus refuses a
+// query and 127.0.0.1, never a listing (RFC 5782), how SURBL does.
+func (g *Guard) queryDNSBL(ctx context.Context, bl DNSBL, key string) (netip.Addr, error) {
+	name := key + "." + bl.Zone
+	if v, ok := g.dnsblCache.Load(name); ok {
+		if e := v.(dnsblEntry); time.Now().Before(e.exp) {
+			return e.code, nil
+		}
+	}
+	if g.cfg.airGapped {
+		return netip.Addr{}, ErrAirGapped
+	}
+	if err := g.spend(ctx); err != nil {
+		return netip.Addr{}, err
+	}
+	release, err := g.dnsPoolFor(ctx).acquire(ctx, name)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	defer release()
+
+	var cancel context.CancelFunc
+	if bl.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, bl.Timeout)
+	} else {
+		ctx, cancel = g.dnsContext(ctx)
+	}
+	defer cancel()
+	addrs, err := g.resolver().LookupNetIP(ctx, "ip4", name)
+	if err = classifyDNSError(err); err != nil {
+		return netip.Addr{}, err
+	}
+	var code netip.Addr
+	for _, a := range addrs {
+		a = a.Unmap()
+		switch {
+		case netip.MustParsePrefix("127.255.255.0/24").Contains(a), a == netip.AddrFrom4([4]byte{127, 0, 0, 1}):
+			return netip.Addr{}, fmt.Errorf("%s refused the query: %s", bl.Zone, a)
+		case netip.MustParsePrefix("127.0.0.0/8").Contains(a) && !code.IsValid():
+			code = a
+		}
+	}
+	ttl := g.cfg.cacheTTL
+	if !code.IsValid() {
+		ttl = g.cfg.negativeTTL
+	}
+	g.dnsblCache.Store(name, dnsblEntry{code: code, exp: time.Now().Add(ttl)})
+	return code, nil
+}
+
+// reverseIP writes ip the way DNSBLs are queried: 192.0.2.1 as 1.2.0.192,
+// and IPv6 addresses nibble by nibble.
+func reverseIP(ip netip.Addr) string {
+	ip = ip.Unmap()
+	var labels []string
+	if ip.Is4() {
+		for _, b := range ip.As4() {
+			labels = append(labels, fmt.Sprint(b))
+		}
+	} else {
+		for _, b := range ip.As16() {
+			labels = append(labels, fmt.Sprintf("%x", b>>4), fmt.Sprintf("%x", b&0xf))
+		}
+	}
+	for i, j := 0, len(labels)-1; i < j; i, j = i+1, j-1 {
+		labels[i], labels[j] = labels[j], labels[i]
+	}
+	return strings.Join(labels, ".")
+}
@@ -0,0 +1,280 @@
+//go:build !emailguard_stdlib
+
+package emailguard
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	dohTimeout     = 5 * time.Second // per request, when the context has no deadline
+	maxDoHResponse = 64 << 10
+)
+
+// DoHResolver is a Resolver that speaks DNS over HTTPS (RFC 8484). It
+// suits networks where plain UDP DNS is unreliable, such as some
+// Kubernetes clusters. Endpoints are tried in order until one answers.
+type DoHResolver struct {
+	endpoints []string
+	client    *http.Client
+	ownClient bool // made by NewDoHResolver, so WithIPPolicy may replace it
+}
+
+// NewDoHResolver returns a resolver querying endpoints, Cloudflare then
+// Google if none are given. A nil client uses one with a 5s timeout.
+func NewDoHResolver(client *http.Client, endpoints ...string) (*DoHResolver, error) {
+	if len(endpoints) == 0 {
+		endpoints = []string{DoHCloudflare, DoHGoogle}
+	}
+	for _, e := range endpoints {
+		if u, err := url.Parse(e); err != nil || u.Scheme != "https" || u.Host == "" {
+			return nil, fmt.Errorf("DoH endpoint %q is not an https URL", e)
+		}
+	}
+	r := &DoHResolver{endpoints: slices.Clone(endpoints), client: client}
+	if client == nil {
+		r.client, r.ownClient = &http.Client{Timeout: dohTimeout}, true
+	}
+	return r, nil
+}
+
+// dohForPolicy returns r connecting only over the IP version p allows, if
+// it is a DoHResolver with its own client; a caller's client is left alone.
+func dohForPolicy(r Resolver, p IPPolicy) Resolver {
+	d, ok := r.(*DoHResolver)
+	if !ok || !d.ownClient || p == DualStack {
+		return r
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	dialer := &net.Dialer{Timeout: dohTimeout}
+	t.DialContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
+		return dialer.DialContext(ctx, p.tcp(), addr)
+	}
+	return &DoHResolver{endpoints: d.endpoints, client: &http.Client{Timeout: dohTimeout, Transport: t}, ownClient: true}
+}
+
+// WithDoH resolves over HTTPS through endpoints (see NewDoHResolver).
+func WithDoH(endpoints ...string) Option {
+	return func(c *config) error {
+		r, err := NewDoHResolver(nil, endpoints...)
+		if err != nil {
+			return fmt.Errorf("WithDoH: %w", err)
+		}
+		c.resolver = r
+		return nil
+	}
+}
+
+// Endpoints returns the endpoints r queries, in order.
+func (r *DoHResolver) Endpoints() []string {
+	return slices.Clone(r.endpoints)
+}
+
+// dohEndpoints returns r's endpoints if it is a DoHResolver.
+func dohEndpoints(r Resolver) []string {
+	if d, ok := r.(*DoHResolver); ok {
+		return d.Endpoints()
+	}
+	return nil
+}
+
+func (r *DoHResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	rrs, err := r.query(ctx, name, dnsmessage.TypeMX)
+	if err != nil {
+		return nil, err
+	}
+	var out []*net.MX
+	for _, rr := range rrs {
+		if mx, ok := rr.Body.(*dnsmessage.MXResource); ok {
+			out = append(out, &net.MX{Host: mx.MX.String(), Pref: mx.Pref})
+		}
+	}
+	slices.SortStableFunc(out, func(a, b *net.MX) int { return int(a.Pref) - int(b.Pref) })
+	return out, nil
+}
+
+func (r *DoHResolver) LookupNS(ctx context.Context, name string) ([]*net.NS, error) {
+	rrs, err := r.query(ctx, name, dnsmessage.TypeNS)
+	if err != nil {
+		return nil, err
+	}
+	var out []*net.NS
+	for _, rr := range rrs {
+		if ns, ok := rr.Body.(*dnsmessage.NSResource); ok {
+			out = append(out, &net.NS{Host: ns.NS.String()})
+		}
+	}
+	return out, nil
+}
+
+func (r *DoHResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	rrs, err := r.query(ctx, name, dnsmessage.TypeTXT)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, rr := range rrs {
+		if txt, ok := rr.Body.(*dnsmessage.TXTResource); ok {
+			out = append(out, strings.Join(txt.TXT, "")) // like net.Resolver
+		}
+	}
+	return out, nil
+}
+
+func (r *DoHResolver) LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error) {
+	var types []dnsmessage.Type
+	switch network {
+	case "ip":
+		types = []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA}
+	case "ip4":
+		types = []dnsmessage.Type{dnsmessage.TypeA}
+	case "ip6":
+		types = []dnsmessage.Type{dnsmessage.TypeAAAA}
+	default:
+		return nil, fmt.Errorf("DoH: unsupported network %q", network)
+	}
+	var out []netip.Addr
+	var notFound error
+	for _, t := range types {
+		rrs, err := r.query(ctx, host, t)
+		if err != nil {
+			if isNotFound(err) {
+				notFound = err
+				continue
+			}
+			return nil, err
+		}
+		for _, rr := range rrs {
+			switch b := rr.Body.(type) {
+			case *dnsmessage.AResource:
+				out = append(out, netip.AddrFrom4(b.A))
+			case *dnsmessage.AAAAResource:
+				out = append(out, netip.AddrFrom16(b.AAAA))
+			}
+		}
+	}
+	if len(out) == 0 && notFound != nil {
+		return nil, notFound
+	}
+	return out, nil
+}
+
+func (r *DoHResolver) LookupAddr(ctx context.Context, addr string) ([]string, error) {
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return nil, &net.DNSError{Err: "unrecognized address", Name: addr}
+	}
+	name := reverseIP(ip) + ".in-addr.arpa"
+	if !ip.Unmap().Is4() {
+		name = reverseIP(ip) + ".ip6.arpa"
+	}
+	rrs, err := r.query(ctx, name, dnsmessage.TypePTR)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, rr := range rrs {
+		if ptr, ok := rr.Body.(*dnsmessage.PTRResource); ok {
+			out = append(out, ptr.PTR.String())
+		}
+	}
+	return out, nil
+}
+
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
+
+// query asks each endpoint in turn for name's records of type t and
+// returns the answers of that type. NXDOMAIN and empty answers are
+// *net.DNSError with IsNotFound, as from net.Resolver.
+func (r *DoHResolver) query(ctx context.Context, name string, t dnsmessage.Type) ([]dnsmessage.Resource, error) {
+	fqdn := name
+	if !strings.HasSuffix(fqdn, ".") {
+		fqdn += "."
+	}
+	qname, err := dnsmessage.NewName(fqdn)
+	if err != nil {
+		return nil, &net.DNSError{Err: err.Error(), Name: name}
+	}
+	q := dnsmessage.Message{
+		Header:    dnsmessage.Header{RecursionDesired: true}, // ID 0, as RFC 8484 asks
+		Questions: []dnsmessage.Question{{Name: qname, Type: t, Class: dnsmessage.ClassINET}},
+	}
+	packed, err := q.Pack()
+	if err != nil {
+		return nil, &net.DNSError{Err: err.Error(), Name: name}
+	}
+
+	var errs []error
+	for _, ep := range r.endpoints {
+		msg, err := r.exchange(ctx, ep, packed)
+		if err != nil {
+			errs = append(errs, &net.DNSError{Err: err.Error(), Name: name, Server: ep, IsTemporary: true})
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		switch msg.RCode {
+		case dnsmessage.RCodeSuccess:
+		case dnsmessage.RCodeNameError:
+			return nil, &net.DNSError{Err: "no such host", Name: name, Server: ep, IsNotFound: true}
+		default:
+			errs = append(errs, &net.DNSError{Err: "server misbehaving: " + msg.RCode.String(), Name: name, Server: ep, IsTemporary: true})
+			continue
+		}
+		var out []dnsmessage.Resource
+		for _, rr := range msg.Answers {
+			if rr.Header.Type == t {
+				out = append(out, rr)
+			}
+		}
+		if len(out) == 0 {
+			return nil, &net.DNSError{Err: "no such host", Name: name, Server: ep, IsNotFound: true}
+		}
+		return out, nil
+	}
+	return nil, errors.Join(errs...)
+}
+
+// exchange POSTs one packed query to ep.
+func (r *DoHResolver) exchange(ctx context.Context, ep string, query []byte) (*dnsmessage.Message, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep, bytes.NewReader(query))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("POST %s: %s", ep, resp.Status)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxDoHResponse))
+	if err != nil {
+		return nil, err
+	}
+	var msg dnsmessage.Message
+	if err := msg.Unpack(b); err != nil {
+		return nil, fmt.Errorf("%s: bad DNS message: %w", ep, err)
+	}
+	return &msg, nil
+}
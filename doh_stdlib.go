@@ -0,0 +1,17 @@
+//go:build emailguard_stdlib
+
+package emailguard
+
+import "errors"
+
+// WithDoH is unavailable in the emailguard_stdlib build, which lacks a DNS
+// message parser.
+func WithDoH(...string) Option {
+	return func(*config) error {
+		return errors.New("WithDoH: DNS over HTTPS needs a build without the emailguard_stdlib tag")
+	}
+}
+
+func dohEndpoints(Resolver) []string { return nil }
+
+func dohForPolicy(r Resolver, _ IPPolicy) Resolver { return r }
@@ -0,0 +1,263 @@
+package emailguard
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// domainSet is an immutable, compact set of domains. Instead of one heap
+// string per entry (as in map[string]struct{}), each domain is split into a
+// head and its public suffix; heads are packed into a single byte arena and
+// suffixes ("com", "co.uk", ...) are interned once. An entry costs 8 bytes
+// plus its head bytes, and lookups binary-search the sorted entries without
+// allocating.
+//
+// Two kinds of rule entries sit beside the domains: "*.example.com" matches
+// every subdomain of example.com, and "/regexp/" matches domains the
+// expression matches in full. Both are answered by rule, never contains.
+type domainSet struct {
+	arena    []byte
+	suffixes []string
+	entries  []setEntry // sorted by full domain
+	version  string     // content hash, e.g. for DecisionRecord.ListVersion
+
+	wild  map[string]bool // "*.example.com" entries, by example.com
+	re    *regexp.Regexp  // every regexp entry in one alternation
+	res   []ruleRegexp    // the same, one by one, to name the match
+	rules []string        // rule entries as written, sorted
+}
+
+type ruleRegexp struct {
+	rule string // as written, e.g. /[a-z]+[0-9]{4}\.xyz/
+	re   *regexp.Regexp
+}
+
+type setEntry struct {
+	off    uint32 // head offset into arena
+	n      uint16 // head length
+	suffix uint16 // index into suffixes
+}
+
+// newDomainSet builds a set from domains, normalizing and de-duplicating
+// them. Rule entries that do not compile are dropped; checkListEntry
+// reports them to callers that can still object.
+func newDomainSet(domains []string) *domainSet {
+	norm := make([]string, 0, len(domains))
+	var rules []string
+	for _, d := range domains {
+		d = normEntry(d)
+		switch {
+		case d == "" || len(d) > 0xffff:
+		case isListRule(d):
+			if checkListEntry(d) == nil {
+				rules = append(rules, d)
+			}
+		default:
+			norm = append(norm, d)
+		}
+	}
+	slices.Sort(norm)
+	norm = slices.Compact(norm)
+	slices.Sort(rules)
+	rules = slices.Compact(rules)
+
+	h := sha256.New()
+	for _, d := range slices.Concat(norm, rules) {
+		h.Write([]byte(d))
+		h.Write([]byte{'\n'})
+	}
+	s := &domainSet{entries: make([]setEntry, 0, len(norm)), version: hex.EncodeToString(h.Sum(nil)[:6])}
+	s.addRules(rules)
+	suffixIdx := make(map[string]uint16)
+	for _, d := range norm {
+		head, suf := splitSuffix(d)
+		idx, ok := suffixIdx[suf]
+		if !ok {
+			if len(s.suffixes) == 0xffff {
+				// absurd number of distinct suffixes; keep the whole
+				// domain as head under the empty suffix
+				head, suf = d, ""
+				idx = suffixIdx[""]
+			} else {
+				idx = uint16(len(s.suffixes))
+				suffixIdx[suf] = idx
+				s.suffixes = append(s.suffixes, suf)
+			}
+		}
+		s.entries = append(s.entries, setEntry{off: uint32(len(s.arena)), n: uint16(len(head)), suffix: idx})
+		s.arena = append(s.arena, head...)
+	}
+	s.arena = slices.Clip(s.arena)
+	return s
+}
+
+// splitSuffix splits d into the part before its public suffix and the suffix.
+func splitSuffix(d string) (head, suffix string) {
+	suffix, _ = publicSuffix(d)
+	if suffix == d {
+		return "", suffix
+	}
+	if head, ok := strings.CutSuffix(d, "."+suffix); ok {
+		return head, suffix
+	}
+	return d, ""
+}
+
+// addRules compiles the rule entries into s.
+func (s *domainSet) addRules(rules []string) {
+	s.rules = rules
+	var alts []string
+	for _, r := range rules {
+		if suffix, ok := strings.CutPrefix(r, "*."); ok {
+			if s.wild == nil {
+				s.wild = make(map[string]bool)
+			}
+			s.wild[suffix] = true
+			continue
+		}
+		expr := "^(?:" + r[1:len(r)-1] + ")$"
+		s.res = append(s.res, ruleRegexp{r, regexp.MustCompile(expr)})
+		alts = append(alts, expr)
+	}
+	if len(alts) > 0 {
+		s.re = regexp.MustCompile(strings.Join(alts, "|"))
+	}
+}
+
+// isListRule reports whether a normalized entry is a wildcard or regexp
+// rule rather than a domain.
+func isListRule(e string) bool {
+	return strings.HasPrefix(e, "*.") || len(e) > 2 && e[0] == '/' && e[len(e)-1] == '/'
+}
+
+// normEntry normalizes a list entry. Regexps keep their case, since
+// lowering it would turn \D into \d.
+func normEntry(e string) string {
+	if t := strings.TrimSpace(e); strings.HasPrefix(t, "/") {
+		return t
+	}
+	return normDomain(e)
+}
+
+// checkListEntry reports why a normalized entry is neither a valid domain
+// nor a valid rule.
+func checkListEntry(e string) error {
+	switch {
+	case strings.HasPrefix(e, "*."):
+		if err := checkHostname(e[2:]); err != nil {
+			return fmt.Errorf("wildcard %q: %w", e, err)
+		}
+		return nil
+	case isListRule(e):
+		if _, err := regexp.Compile(e[1 : len(e)-1]); err != nil {
+			return fmt.Errorf("regexp %s: %w", e, err)
+		}
+		return nil
+	}
+	return checkHostname(e)
+}
+
+// Len returns the number of entries in the set, rules included.
+func (s *domainSet) Len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.entries) + len(s.rules)
+}
+
+// contains reports whether domain (normalized) is in the set.
+func (s *domainSet) contains(domain string) bool {
+	if s == nil || len(s.entries) == 0 {
+		return false
+	}
+	key := normDomain(domain)
+	_, found := slices.BinarySearchFunc(s.entries, key, s.cmp)
+	return found
+}
+
+// cmp compares entry e (head + "." + suffix) with key, like strings.Compare.
+func (s *domainSet) cmp(e setEntry, key string) int {
+	head := s.arena[e.off : e.off+uint32(e.n)]
+	suf := s.suffixes[e.suffix]
+	for i := 0; i < len(head); i++ {
+		if i >= len(key) {
+			return 1
+		}
+		if head[i] != key[i] {
+			if head[i] < key[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	rest := key[len(head):]
+	if len(head) > 0 && suf != "" {
+		if rest == "" {
+			return 1
+		}
+		if rest[0] != '.' {
+			if '.' < rest[0] {
+				return -1
+			}
+			return 1
+		}
+		rest = rest[1:]
+	}
+	return strings.Compare(suf, rest)
+}
+
+// domain reconstructs entry i.
+func (s *domainSet) domain(i int) string {
+	e := s.entries[i]
+	head := string(s.arena[e.off : e.off+uint32(e.n)])
+	suf := s.suffixes[e.suffix]
+	switch {
+	case head == "":
+		return suf
+	case suf == "":
+		return head
+	}
+	return head + "." + suf
+}
+
+// rule returns the rule entry domain (normalized) matches, if any.
+func (s *domainSet) rule(domain string) (string, bool) {
+	if s == nil || len(s.rules) == 0 {
+		return "", false
+	}
+	key := normDomain(domain)
+	for d, ok := key, true; len(s.wild) > 0; {
+		if _, d, ok = strings.Cut(d, "."); !ok {
+			break
+		}
+		if s.wild[d] {
+			return "*." + d, true
+		}
+	}
+	if s.re == nil || !s.re.MatchString(key) {
+		return "", false
+	}
+	for _, r := range s.res {
+		if r.re.MatchString(key) {
+			return r.rule, true
+		}
+	}
+	return "", false
+}
+
+// all calls fn for every domain in sorted order, then every rule entry.
+func (s *domainSet) all(fn func(string)) {
+	if s == nil {
+		return
+	}
+	for i := range s.entries {
+		fn(s.domain(i))
+	}
+	for _, r := range s.rules {
+		fn(r)
+	}
+}
@@ -20,32 +20,32 @@
 //
 //	import "yourproject/emailguard"
 //
-//	ok := emailguard.IsLegitEmail("user@company.com")
-//	if !ok {
+//	ok, err := emailguard.Verify("user@company.com")
+//	if err == nil && !ok {
 //	    fmt.Println("reject: unverified or disposable domain")
 //	}
+//
+// The package-level functions run on a default guard; configure it with
+// New and SetDefaultGuard.
 package emailguard
 
 import (
-	"bufio"
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
 	"net"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
-	"sync"
 	"time"
-
-	"github.com/go-git/go-git/v5"
-	"github.com/go-git/go-git/v5/plumbing/transport/http"
-	"golang.org/x/net/publicsuffix"
+	"unique"
 )
 
 const (
 	repoURL       = "https://github.com/disposable-email-domains/disposable-email-domains.git"
-	repoDir       = "/tmp/disposable-email-domains"
+	listURL       = "https://raw.githubusercontent.com/disposable-email-domains/disposable-email-domains/main/" + blocklistFile
 	blocklistFile = "disposable_email_blocklist.conf"
 
 	mxTimeout    = 1 * time.Second  // keep snappy
@@ -53,23 +53,27 @@ const (
 	pullCooldown = 30 * time.Minute // blocklist repo refresh
 )
 
-// --- policy knobs ---
-
-var allowlist = map[string]struct{}{
-	"gmail.com":      {},
-	"googlemail.com": {},
-	"outlook.com":    {},
-	"hotmail.com":    {},
-	"live.com":       {},
-	"yahoo.com":      {},
-	"icloud.com":     {},
-	"proton.me":      {},
-	"protonmail.com": {},
-	"fastmail.com":   {},
+// repoDir is where the upstream list is checked out by default: /tmp on
+// Linux, the per-user temp directory on macOS and Windows.
+var repoDir = filepath.Join(os.TempDir(), "disposable-email-domains")
+
+// --- policy defaults (copied into every Guard's config) ---
+
+var defaultAllowlist = []string{
+	"gmail.com",
+	"googlemail.com",
+	"outlook.com",
+	"hotmail.com",
+	"live.com",
+	"yahoo.com",
+	"icloud.com",
+	"proton.me",
+	"protonmail.com",
+	"fastmail.com",
 }
 
 // MX hostname keywords that strongly indicate masking/forwarding/temp
-var mxBadKeywords = []string{
+var defaultMXBadKeywords = []string{
 	"mask",
 	"alias",
 	"relay",
@@ -80,245 +84,216 @@ var mxBadKeywords = []string{
 	"burner",
 }
 
-// --- disposable set + once ---
-
-var (
-	tempMails       map[string]struct{}
-	blocklistLoaded bool
-	loadOnce        sync.Once
-)
-
-// --- caches (simple TTL maps) ---
+// --- caches (simple TTL maps, one set per Guard) ---
 
-type verdictEntry struct {
-	val bool
-	exp time.Time
-}
 type mxEntry struct {
-	hosts []string
-	exp   time.Time
+	hosts    []string // immutable once cached; shared with readers
+	prefs    []uint16 // preferences of hosts; nil for the implicit MX
+	exp      time.Time
+	implicit bool // no MX records; hosts is the domain itself (WithImplicitMX)
 }
 
-var (
-	verdictCache = make(map[string]verdictEntry) // key: domain
-	mxCache      = make(map[string]mxEntry)      // key: domain
-	cacheMu      sync.RWMutex
-)
-
 func init() {
 	// lazy + safe: if this fails, we still run with empty set
-	LoadTempMails()
+	builtinGuard.blocklist.load()
 }
 
 // IsLegitEmail returns true only if the domain looks like a legit mailbox domain
 // (no MX => reject, disposable => reject, masking MX => reject).
+//
+// Decisions follow a fixed precedence: override > tenant policy > TLD rules >
+// allowlist > blocklist > provider fingerprints > heuristics. See
+// Guard.ExplainPrecedence.
+//
+// IsLegitEmail runs on the default guard (see SetDefaultGuard) and keeps
+// working, but a DNS timeout reads as a rejection.
+//
+// Deprecated: Use Verify, which reports transient failures separately, or
+// Validate for a detailed verdict.
 func IsLegitEmail(email string) bool {
-	email = strings.TrimSpace(email)
-	at := strings.LastIndexByte(email, '@')
-	if at <= 0 || at == len(email)-1 {
-		return false
-	}
-	domain := normDomain(email[at+1:])
-	if domain == "" {
-		return false
-	}
+	return defaultGuard.Load().IsLegitEmail(email)
+}
 
-	// verdict cache hit
-	if ok, hit := getVerdictCached(domain); hit {
-		return ok
-	}
+// IsLegitEmailContext is IsLegitEmail bounded by ctx: its deadline replaces
+// the internal DNS timeout and cancellation aborts pending lookups.
+//
+// Deprecated: Use VerifyContext.
+func IsLegitEmailContext(ctx context.Context, email string) bool {
+	return defaultGuard.Load().IsLegitEmailContext(ctx, email)
+}
 
-	// 1) allow common consumer providers you explicitly permit
-	if inSet(allowlist, domain) {
-		setVerdictCached(domain, true)
-		return true
-	}
+// Verify is IsLegitEmail that separates "rejected" from "couldn't verify":
+// transient failures (resolver down, timeout) return ok=false with an error
+// wrapping ErrTemporary, leaving fail-open vs fail-closed to the caller.
+// A definitive rejection returns (false, nil).
+func Verify(email string) (ok bool, err error) {
+	return defaultGuard.Load().Verify(email)
+}
 
-	// ensure blocklist is loaded (no-op after first call)
-	LoadTempMails()
+// VerifyContext is Verify bounded by ctx, with per-call opts.
+func VerifyContext(ctx context.Context, email string, opts ...CheckOption) (ok bool, err error) {
+	return defaultGuard.Load().VerifyContext(ctx, email, opts...)
+}
 
-	// 2) block if email domain or its eTLD+1 is disposable
-	if inSet(tempMails, domain) {
-		setVerdictCached(domain, false)
-		return false
+// --- MX lookup with tiny TTL cache ---
+
+// lookupMX resolves MX hosts for domain, holding a slot in the guard's DNS
+// pool only when the cache misses.
+func (g *Guard) lookupMX(ctx context.Context, domain string) ([]string, error) {
+	if hosts, ok := injectedMX(ctx, domain); ok {
+		return hosts, nil
 	}
-	if rd, err := registrableDomain(domain); err == nil && inSet(tempMails, rd) {
-		setVerdictCached(domain, false)
-		return false
+	if e, ok := g.cachedMXEntry(domain); ok {
+		g.countDNS(e.hosts == nil)
+		return e.hosts, nil
 	}
-
-	// 3) require MX records (cached, 1s timeout)
-	mxHosts := checkForMXCached(domain)
-	if len(mxHosts) == 0 {
-		setVerdictCached(domain, false)
-		return false
+	g.countDNS(false)
+	if g.cfg.airGapped {
+		return nil, ErrAirGapped
 	}
-
-	// 4) MX intelligence
-	for _, h := range mxHosts {
-		lh := normDomain(h)
-		// 4a) keyword scan
-		for _, kw := range mxBadKeywords {
-			if strings.Contains(lh, kw) {
-				setVerdictCached(domain, false)
-				return false
-			}
-		}
-		// 4b) disposable check on MX registrable domain
-		if rd, err := registrableDomain(lh); err == nil && inSet(tempMails, rd) {
-			setVerdictCached(domain, false)
-			return false
-		}
+	if err := g.spend(ctx); err != nil {
+		return nil, err
 	}
-
-	setVerdictCached(domain, true)
-	return true
+	// Callers share the lookup, so it must not die with the first one's
+	// ctx: it runs detached, bounded by the pool's wait and the guard's DNS
+	// budget, in the pool of its priority class, which is part of the key.
+	pool := g.dnsPoolFor(ctx)
+	lctx := context.WithoutCancel(ctx)
+	key := checkOptsFrom(ctx).priority.String() + "|" + domain
+	return g.mxFlight.do(ctx, key, func() ([]string, error) {
+		release, err := pool.acquire(lctx, domain)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		return g.checkForMXCached(lctx, domain)
+	})
 }
 
-// --- MX lookup with tiny TTL cache ---
-
-func checkForMXCached(domain string) []string {
-	cacheMu.RLock()
-	if e, ok := mxCache[domain]; ok && time.Now().Before(e.exp) {
-		hostsCopy := append([]string(nil), e.hosts...)
-		cacheMu.RUnlock()
-		return hostsCopy
+// dnsContext bounds a single lookup. A caller deadline wins; without one the
+// guard's MX timeout applies, to each attempt under WithDNSRetry.
+func (g *Guard) dnsContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
 	}
-	cacheMu.RUnlock()
-
-	hosts := checkForMX(domain)
-
-	cacheMu.Lock()
-	mxCache[domain] = mxEntry{hosts: append([]string(nil), hosts...), exp: time.Now().Add(cacheTTL)}
-	cacheMu.Unlock()
-
-	return hosts
+	return context.WithTimeout(ctx, g.cfg.dnsBudget())
 }
 
-// Checks for MX of an email domain. Returns list of MX hostnames.
-func checkForMX(domain string) []string {
-	ctx, cancel := context.WithTimeout(context.Background(), mxTimeout)
-	defer cancel()
-
-	// use DefaultResolver with context; this respects our timeout
-	recs, err := net.DefaultResolver.LookupMX(ctx, domain)
-	if err != nil || len(recs) == 0 {
-		return nil
+// knownMX returns MX hosts for domain supplied with UsingDNS or cached.
+func (g *Guard) knownMX(ctx context.Context, domain string) ([]string, bool) {
+	if hosts, ok := injectedMX(ctx, domain); ok {
+		return hosts, true
 	}
-	out := make([]string, 0, len(recs))
-	for _, mx := range recs {
-		if mx == nil || mx.Host == "" {
-			continue
-		}
-		out = append(out, strings.TrimSpace(mx.Host))
-	}
-	return out
+	return g.cachedMX(domain)
 }
 
-// --- disposable list ---
-
-// LoadTempMails clones or pulls the disposable list and returns a set of domains.
-// Safe to call multiple times; work is done once per process.
-func LoadTempMails() map[string]struct{} {
-	loadOnce.Do(func() {
-		if err := ensureRepo(repoURL, repoDir, "", ""); err != nil {
-			fmt.Fprintf(os.Stderr, "WARN: cannot prepare blocklist repo: %v\n", err)
-			tempMails = make(map[string]struct{}, 0)
-			blocklistLoaded = true
-			return
-		}
+// cachedMX returns unexpired cached MX hosts for domain.
+func (g *Guard) cachedMX(domain string) ([]string, bool) {
+	e, ok := g.cachedMXEntry(domain)
+	return e.hosts, ok
+}
 
-		fp := filepath.Join(repoDir, blocklistFile)
-		f, err := os.Open(fp)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "WARN: cannot open blocklist %s: %v\n", fp, err)
-			tempMails = make(map[string]struct{}, 0)
-			blocklistLoaded = true
-			return
-		}
-		defer f.Close()
-
-		set := make(map[string]struct{}, 40000)
-		sc := bufio.NewScanner(f)
-		for sc.Scan() {
-			line := strings.TrimSpace(sc.Text())
-			if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
-				continue
-			}
-			set[normDomain(line)] = struct{}{}
-		}
-		if err := sc.Err(); err != nil {
-			fmt.Fprintf(os.Stderr, "WARN: scanning blocklist: %v\n", err)
+func (g *Guard) cachedMXEntry(domain string) (mxEntry, bool) {
+	if v, ok := g.mxCache.Load(domain); ok {
+		if e := v.(mxEntry); time.Now().Before(e.exp) {
+			return e, true
 		}
-		tempMails = set
-		blocklistLoaded = true
-	})
-	return tempMails
+	}
+	return mxEntry{}, false
 }
 
-// --- git helpers ---
-
-// ensureRepo clones or pulls the repo into repoDir. Optional basic auth can be provided.
-func ensureRepo(url, dir, username, password string) error {
-	// if dir doesn't exist -> clone
-	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
-		if err := os.MkdirAll(dir, 0o755); err != nil {
-			return err
-		}
-		// clone fresh
-		os.RemoveAll(dir)
-		_, err := git.PlainClone(dir, false, &git.CloneOptions{
-			URL:      url,
-			Progress: os.Stdout,
-			Auth:     basicAuthOrNil(username, password),
-			Depth:    1,
-		})
-		return err
+// checkForMXCached serves MX hosts from cache; transient failures are
+// returned as errors and never cached.
+//
+// The returned slice is shared with the cache and must be treated as
+// read-only. Its capacity is clipped, so an append by a caller reallocates
+// instead of writing into cached memory.
+func (g *Guard) checkForMXCached(ctx context.Context, domain string) ([]string, error) {
+	if hosts, ok := g.cachedMX(domain); ok {
+		return hosts, nil
 	}
 
-	// else open and pull (but not more often than pullCooldown)
-	repo, err := git.PlainOpen(dir)
+	ctx, cancel := g.dnsContext(ctx)
+	defer cancel()
+	hosts, prefs, err := checkForMX(ctx, g.resolver(), domain)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	wt, err := repo.Worktree()
-	if err != nil {
-		return err
+	implicit := false
+	if hosts == nil && g.cfg.implicitMX { // no MX records, not a null MX
+		if hosts, err = implicitMX(ctx, g.resolver(), domain); err != nil {
+			return nil, err
+		}
+		implicit = len(hosts) > 0
 	}
+	hosts = slices.Clip(hosts) // immutable from here on
 
-	stamp := filepath.Join(dir, ".lastpull")
-	if fresh(stamp, pullCooldown) {
-		return nil
+	ttl := g.cfg.cacheTTL
+	// nil is NXDOMAIN or no MX answer; a null MX is an empty, non-nil list
+	// and, as a published answer, keeps the full TTL
+	if hosts == nil {
+		ttl = g.cfg.negativeTTL
 	}
+	g.mxCache.Store(domain, mxEntry{hosts: hosts, prefs: prefs, exp: time.Now().Add(ttl), implicit: implicit})
 
-	pullErr := wt.Pull(&git.PullOptions{
-		RemoteName: "origin",
-		Depth:      1,
-		Auth:       basicAuthOrNil(username, password),
-		Force:      true,
-	})
-	if pullErr != nil && !errors.Is(pullErr, git.NoErrAlreadyUpToDate) {
-		_ = os.RemoveAll(dir)
-		_, cloneErr := git.PlainClone(dir, false, &git.CloneOptions{
-			URL:      url,
-			Progress: os.Stdout,
-			Auth:     basicAuthOrNil(username, password),
-			Depth:    1,
-		})
-		if cloneErr != nil {
-			return fmt.Errorf("pull failed: %v; reclone failed: %w", pullErr, cloneErr)
+	return hosts, nil
+}
+
+// Checks for MX of an email domain. Returns list of MX hostnames, in
+// preference order, and their preferences.
+// A definitive "no such domain / no records" answer yields (nil, nil), and
+// a null MX an empty, non-nil list;
+// timeouts and resolver failures yield an error wrapping ErrTemporary.
+func checkForMX(ctx context.Context, r Resolver, domain string) ([]string, []uint16, error) {
+	// the context carries the caller's deadline
+	recs, err := r.LookupMX(ctx, domain)
+	if err = classifyDNSError(err); err != nil {
+		return nil, nil, err
+	}
+	if len(recs) == 0 {
+		return nil, nil, nil
+	}
+	recs = slices.DeleteFunc(slices.Clone(recs), func(mx *net.MX) bool { return mx == nil })
+	// net.Resolver sorts, but other resolvers need not
+	slices.SortStableFunc(recs, func(a, b *net.MX) int { return cmp.Compare(a.Pref, b.Pref) })
+	// non-nil even if every record is a null MX ("."), which says the
+	// domain takes no mail at all (RFC 7505)
+	out, prefs := make([]string, 0, len(recs)), make([]uint16, 0, len(recs))
+	for _, mx := range recs {
+		if mx.Host == "" || mx.Host == "." {
+			continue
 		}
+		out, prefs = append(out, strings.TrimSpace(mx.Host)), append(prefs, mx.Pref)
 	}
-
-	_ = os.WriteFile(stamp, []byte(time.Now().Format(time.RFC3339Nano)), 0o644)
-	return nil
+	return out, prefs, nil
 }
 
-func basicAuthOrNil(user, pass string) *http.BasicAuth {
-	if user == "" && pass == "" {
+// classifyDNSError maps a resolver error to nil for a definitive "no such
+// domain / no records" answer, or to an error wrapping ErrTemporary.
+func classifyDNSError(err error) error {
+	if err == nil {
 		return nil
 	}
-	return &http.BasicAuth{Username: user, Password: pass}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrTemporary, err)
+}
+
+// --- disposable list ---
+
+// LoadTempMails clones or pulls the disposable list and returns a set of domains.
+// Safe to call multiple times; work is done once per process.
+//
+// The list is held internally in a compact form; the returned map is a fresh
+// copy built on every call, so avoid it on hot paths. Wildcard and regexp
+// rules appear as written.
+func LoadTempMails() map[string]struct{} {
+	set := defaultGuard.Load().blocklist.load()
+	out := make(map[string]struct{}, set.Len())
+	set.all(func(d string) { out[d] = struct{}{} })
+	return out
 }
 
 func fresh(stampPath string, maxAge time.Duration) bool {
@@ -331,33 +306,19 @@ func fresh(stampPath string, maxAge time.Duration) bool {
 
 // registrableDomain returns eTLD+1 (e.g., mx1.mail.tempmail.com.tr -> tempmail.com.tr)
 func registrableDomain(host string) (string, error) {
-	return publicsuffix.EffectiveTLDPlusOne(normDomain(host))
+	return effectiveTLDPlusOne(normDomain(host))
 }
 
 // --- tiny utils ---
 
-func inSet(m map[string]struct{}, k string) bool {
-	_, ok := m[normDomain(k)]
-	return ok
-}
-
 func normDomain(s string) string {
 	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(s)), ".")
 }
 
-func getVerdictCached(domain string) (bool, bool) {
-	now := time.Now()
-	cacheMu.RLock()
-	e, ok := verdictCache[domain]
-	cacheMu.RUnlock()
-	if !ok || now.After(e.exp) {
-		return false, false
-	}
-	return e.val, true
-}
-
-func setVerdictCached(domain string, v bool) {
-	cacheMu.Lock()
-	verdictCache[domain] = verdictEntry{val: v, exp: time.Now().Add(cacheTTL)}
-	cacheMu.Unlock()
+// internDomain returns the canonical copy of a normalized domain. The same
+// hot domain is used as a key in the verdict cache, the MX cache and the
+// re-check queue; interning makes those share one allocation. Entries are
+// weak and vanish once no cache references them.
+func internDomain(d string) string {
+	return unique.Make(d).Value()
 }
@@ -0,0 +1,123 @@
+package emailguard
+
+import (
+	"context"
+	"slices"
+)
+
+// Explanation is the full matrix of checks behind a decision.
+type Explanation struct {
+	Email   string        `json:"email"`
+	Verdict Verdict       `json:"verdict"` // what the checks add up to
+	Checks  []CheckResult `json:"checks"`
+}
+
+// CheckResult is one check's opinion in an Explanation.
+type CheckResult struct {
+	Check    string `json:"check"`
+	Action   Action `json:"action"`
+	Reason   Reason `json:"reason,omitempty"`
+	Match    string `json:"match,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Decisive bool   `json:"decisive,omitempty"` // this check decided the verdict
+	// Extra marks individual checks that are not stages of the guard's
+	// pipeline (e.g. mx_keywords, which the default pipeline runs as part
+	// of heuristics). They are shown for insight and never decide.
+	Extra bool `json:"extra,omitempty"`
+}
+
+// Explain explains email with the default guard.
+func Explain(email string) Explanation {
+	return defaultGuard.Load().Explain(email)
+}
+
+// Explain runs every check on email, even after one has decided, bypassing
+// the verdict cache, and returns each result along with the verdict they
+// add up to. It answers "why was this rejected?" without side effects: no
+// history, statistics or sequence tracking, and hooks and shadow mode are
+// not applied.
+func (g *Guard) Explain(email string) Explanation {
+	return g.ExplainContext(context.Background(), email)
+}
+
+// ExplainContext is Explain bounded by ctx.
+func (g *Guard) ExplainContext(ctx context.Context, email string) Explanation {
+	local, domain, ok := splitEmail(email)
+	ex := Explanation{Email: g.redactEmail(email, local, domain)}
+	ex.Verdict.Email, ex.Verdict.Confidence = ex.Email, 1
+	if !ok {
+		ex.Verdict.Reason, ex.Verdict.Detail = ReasonSyntax, "malformed address"
+		return ex
+	}
+	ex.Verdict.Domain = domain
+	err := checkAddress(local, domain)
+	if err == nil {
+		err = g.checkProviderLocal(local, domain)
+	}
+	if err != nil {
+		ex.Verdict.Layer, ex.Verdict.Reason, ex.Verdict.Detail = LayerSyntax, ReasonSyntax, err.Error()
+		ex.Checks = []CheckResult{{Check: LayerSyntax, Action: ActionDeny, Reason: ReasonSyntax, Detail: err.Error(), Decisive: true}}
+		return ex
+	}
+	if how, ok := g.subaddressed(local, domain); ok && g.cfg.subaddress == SubaddressReject {
+		detail := "subaddressed: " + how
+		ex.Verdict.Layer, ex.Verdict.Reason, ex.Verdict.Detail = LayerSubaddress, ReasonSubaddressed, detail
+		ex.Checks = []CheckResult{{Check: LayerSubaddress, Action: ActionDeny, Reason: ReasonSubaddressed, Detail: detail, Decisive: true}}
+		return ex
+	}
+
+	layers := make([]LayerResult, 0, len(g.pipeline))
+	for _, s := range g.pipeline {
+		f, err := g.evalStage(ctx, s, domain)
+		r := layerResult(s.Name(), f)
+		r.Err = err
+		layers = append(layers, r)
+	}
+	decisive := g.verdictOf(ctx, &ex.Verdict, layers)
+	for i, r := range layers {
+		c := checkResult(r)
+		c.Decisive = i == decisive
+		ex.Checks = append(ex.Checks, c)
+	}
+
+	for _, s := range []Stage{SyntaxCheck, MXCheck, MXKeywordCheck, DisposableMXCheck, ParkedCheck} {
+		if stageIndex(g.pipeline, s.Name()) >= 0 || g.cfg.disabled[s.Name()] || g.switchedOff(s.Name()) {
+			continue
+		}
+		f, err := s.Eval(ctx, g, domain)
+		r := layerResult(s.Name(), f)
+		r.Err = err
+		c := checkResult(r)
+		c.Extra = true
+		ex.Checks = append(ex.Checks, c)
+	}
+	return ex
+}
+
+func checkResult(r LayerResult) CheckResult {
+	c := CheckResult{Check: r.Layer, Action: r.Action, Reason: r.Reason, Match: r.Match, Detail: r.Detail}
+	if r.Err != nil {
+		c.Error = r.Err.Error()
+	}
+	return c
+}
+
+// verdictOf fills v with what the pipeline decides given every layer's
+// result: the first layer with an opinion or an error wins, else the
+// fallback. It annotates v without recording the address and returns the
+// decisive layer's index, or -1 for the fallback.
+func (g *Guard) verdictOf(ctx context.Context, v *Verdict, layers []LayerResult) int {
+	i := slices.IndexFunc(layers, func(l LayerResult) bool { return l.Err != nil || l.Action != ActionNone })
+	r := g.fallbackResult()
+	if i >= 0 {
+		r = layers[i]
+		if r.Err != nil {
+			r.Reason = ReasonTemporary
+		}
+	}
+	fillVerdict(v, r)
+	g.annotate(ctx, v, "")
+	g.settle(v)
+	return i
+}
@@ -0,0 +1,405 @@
+package emailguard
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// TableWriter receives tabular results row by row, the header first. Close
+// finishes the output; it does not close the underlying writer.
+type TableWriter interface {
+	WriteRow(row []string) error
+	Close() error
+}
+
+// NewTableWriter returns a TableWriter for format: "csv", "xlsx" or
+// "parquet".
+func NewTableWriter(w io.Writer, format string) (TableWriter, error) {
+	switch strings.ToLower(format) {
+	case "csv":
+		return NewCSVTable(w), nil
+	case "xlsx":
+		return NewXLSXTable(w), nil
+	case "parquet":
+		return NewParquetTable(w), nil
+	default:
+		return nil, fmt.Errorf("emailguard: unknown table format %q", format)
+	}
+}
+
+// TableFormat guesses a format for NewTableWriter from a file name,
+// defaulting to "csv".
+func TableFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xlsx":
+		return "xlsx"
+	case ".parquet", ".parq":
+		return "parquet"
+	default:
+		return "csv"
+	}
+}
+
+// --- CSV ---
+
+type csvTable struct{ w *csv.Writer }
+
+// NewCSVTable writes rows as CSV.
+func NewCSVTable(w io.Writer) TableWriter {
+	return csvTable{csv.NewWriter(w)}
+}
+
+func (t csvTable) WriteRow(row []string) error { return t.w.Write(row) }
+
+func (t csvTable) Close() error {
+	t.w.Flush()
+	return t.w.Error()
+}
+
+// --- XLSX ---
+
+// xlsxTable streams a single-sheet workbook. Cells are inline strings, so
+// nothing but the current row is held in memory.
+type xlsxTable struct {
+	zw    *zip.Writer
+	sheet io.Writer
+	rows  int
+	err   error
+}
+
+// NewXLSXTable writes rows as an Excel workbook with one sheet.
+func NewXLSXTable(w io.Writer) TableWriter {
+	return &xlsxTable{zw: zip.NewWriter(w)}
+}
+
+var xlsxParts = []struct{ name, body string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="emailguard" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`},
+}
+
+// start writes the fixed parts and opens the sheet, which must be the last
+// entry since it is still being written.
+func (t *xlsxTable) start() error {
+	for _, p := range xlsxParts {
+		f, err := t.zw.Create(p.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, p.body); err != nil {
+			return err
+		}
+	}
+	f, err := t.zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	t.sheet = f
+	_, err = io.WriteString(f, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	return err
+}
+
+func (t *xlsxTable) WriteRow(row []string) error {
+	if t.err != nil {
+		return t.err
+	}
+	if t.sheet == nil {
+		if t.err = t.start(); t.err != nil {
+			return t.err
+		}
+	}
+	t.rows++
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<row r="%d">`, t.rows)
+	for i, cell := range row {
+		fmt.Fprintf(&b, `<c r="%s%d" t="inlineStr"><is><t xml:space="preserve">`, xlsxColumn(i), t.rows)
+		xml.EscapeText(&b, []byte(cell))
+		b.WriteString(`</t></is></c>`)
+	}
+	b.WriteString(`</row>`)
+	_, t.err = t.sheet.Write(b.Bytes())
+	return t.err
+}
+
+func (t *xlsxTable) Close() error {
+	if t.err != nil {
+		return t.err
+	}
+	if t.sheet == nil {
+		if err := t.start(); err != nil {
+			return err
+		}
+	}
+	if _, err := io.WriteString(t.sheet, `</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	return t.zw.Close()
+}
+
+// xlsxColumn names the i'th column: A..Z, AA, AB, ...
+func xlsxColumn(i int) string {
+	var name []byte
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = append([]byte{byte('A' + (i-1)%26)}, name...)
+	}
+	return string(name)
+}
+
+// --- Parquet ---
+
+// parquetRowGroup is how many rows are buffered per row group.
+const parquetRowGroup = 8192
+
+// parquetTable writes every column as a required UTF-8 string, PLAIN
+// encoded and uncompressed, one row group per parquetRowGroup rows. Column
+// names come from the header; later rows are padded or cut to fit it.
+type parquetTable struct {
+	w       io.Writer
+	off     int64
+	names   []string
+	cols    []bytes.Buffer // PLAIN-encoded values of the open row group
+	n       int            // rows in the open row group
+	total   int64
+	groups  []parquetGroup
+	started bool
+	err     error
+}
+
+type parquetGroup struct {
+	rows   int64
+	chunks []parquetChunk
+}
+
+type parquetChunk struct {
+	offset, size int64
+}
+
+// NewParquetTable writes rows as a Parquet file.
+func NewParquetTable(w io.Writer) TableWriter {
+	return &parquetTable{w: w}
+}
+
+func (t *parquetTable) write(b []byte) {
+	if t.err != nil {
+		return
+	}
+	var n int
+	n, t.err = t.w.Write(b)
+	t.off += int64(n)
+}
+
+func (t *parquetTable) WriteRow(row []string) error {
+	if t.err != nil {
+		return t.err
+	}
+	if !t.started {
+		t.started = true
+		if len(row) == 0 {
+			return errors.New("emailguard: parquet header has no columns")
+		}
+		t.names = append([]string(nil), row...)
+		t.cols = make([]bytes.Buffer, len(row))
+		t.write([]byte("PAR1"))
+		return t.err
+	}
+	var lenBuf [4]byte
+	for i := range t.cols {
+		var v string
+		if i < len(row) {
+			v = row[i]
+		}
+		binary.LittleEndian.PutUint32(lenBuf[:], uint32(len(v)))
+		t.cols[i].Write(lenBuf[:])
+		t.cols[i].WriteString(v)
+	}
+	if t.n++; t.n == parquetRowGroup {
+		t.flushGroup()
+	}
+	return t.err
+}
+
+// flushGroup writes the open row group: one data page per column.
+func (t *parquetTable) flushGroup() {
+	if t.n == 0 {
+		return
+	}
+	g := parquetGroup{rows: int64(t.n)}
+	for i := range t.cols {
+		data := t.cols[i].Bytes()
+		var hdr thriftWriter
+		hdr.i32(1, 0) // type: DATA_PAGE
+		hdr.i32(2, int32(len(data)))
+		hdr.i32(3, int32(len(data)))
+		hdr.structBegin(5) // data_page_header
+		hdr.i32(1, int32(t.n))
+		hdr.i32(2, 0) // encoding: PLAIN
+		hdr.i32(3, 3) // definition levels: RLE
+		hdr.i32(4, 3) // repetition levels: RLE
+		hdr.structEnd()
+		hdr.stop()
+
+		start := t.off
+		t.write(hdr.b)
+		t.write(data)
+		g.chunks = append(g.chunks, parquetChunk{offset: start, size: t.off - start})
+		t.cols[i].Reset()
+	}
+	t.groups = append(t.groups, g)
+	t.total += int64(t.n)
+	t.n = 0
+}
+
+func (t *parquetTable) Close() error {
+	if t.err != nil {
+		return t.err
+	}
+	if !t.started {
+		return errors.New("emailguard: parquet output has no header")
+	}
+	t.flushGroup()
+
+	var m thriftWriter
+	m.i32(1, 1) // version
+	m.listBegin(2, thriftStruct, len(t.names)+1)
+	m.elemBegin() // root
+	m.binary(4, "schema")
+	m.i32(5, int32(len(t.names)))
+	m.elemEnd()
+	for _, name := range t.names {
+		m.elemBegin()
+		m.i32(1, 6) // type: BYTE_ARRAY
+		m.i32(3, 0) // repetition: REQUIRED
+		m.binary(4, name)
+		m.i32(6, 0) // converted type: UTF8
+		m.elemEnd()
+	}
+	m.i64(3, t.total)
+	m.listBegin(4, thriftStruct, len(t.groups))
+	for _, g := range t.groups {
+		m.elemBegin()
+		m.listBegin(1, thriftStruct, len(g.chunks))
+		var size int64
+		for i, c := range g.chunks {
+			size += c.size
+			m.elemBegin()
+			m.i64(2, c.offset) // file_offset
+			m.structBegin(3)   // meta_data
+			m.i32(1, 6)        // BYTE_ARRAY
+			m.listBegin(2, thriftI32, 2)
+			m.elemI32(0) // PLAIN
+			m.elemI32(3) // RLE
+			m.listBegin(3, thriftBinary, 1)
+			m.elemBinary(t.names[i])
+			m.i32(4, 0) // codec: UNCOMPRESSED
+			m.i64(5, g.rows)
+			m.i64(6, c.size)
+			m.i64(7, c.size)
+			m.i64(9, c.offset) // data_page_offset
+			m.structEnd()
+			m.elemEnd()
+		}
+		m.i64(2, size)
+		m.i64(3, g.rows)
+		m.elemEnd()
+	}
+	m.binary(6, "emailguard")
+	m.stop()
+
+	t.write(m.b)
+	var lenBuf [4]byte
+	binary.LittleEndian.PutUint32(lenBuf[:], uint32(len(m.b)))
+	t.write(lenBuf[:])
+	t.write([]byte("PAR1"))
+	return t.err
+}
+
+// thriftWriter encodes the Thrift compact protocol, just enough for Parquet
+// metadata.
+type thriftWriter struct {
+	b    []byte
+	last []int16 // last field id per open struct
+}
+
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+func (w *thriftWriter) field(id int16, typ byte) {
+	if len(w.last) == 0 {
+		w.last = append(w.last, 0)
+	}
+	top := &w.last[len(w.last)-1]
+	if d := id - *top; d > 0 && d <= 15 {
+		w.b = append(w.b, byte(d)<<4|typ)
+	} else {
+		w.b = append(w.b, typ)
+		w.b = binary.AppendVarint(w.b, int64(id))
+	}
+	*top = id
+}
+
+func (w *thriftWriter) i32(id int16, v int32) {
+	w.field(id, thriftI32)
+	w.b = binary.AppendVarint(w.b, int64(v))
+}
+
+func (w *thriftWriter) i64(id int16, v int64) {
+	w.field(id, thriftI64)
+	w.b = binary.AppendVarint(w.b, v)
+}
+
+func (w *thriftWriter) binary(id int16, s string) {
+	w.field(id, thriftBinary)
+	w.elemBinary(s)
+}
+
+func (w *thriftWriter) structBegin(id int16) {
+	w.field(id, thriftStruct)
+	w.last = append(w.last, 0)
+}
+
+func (w *thriftWriter) structEnd() {
+	w.b = append(w.b, 0)
+	w.last = w.last[:len(w.last)-1]
+}
+
+func (w *thriftWriter) stop() { w.b = append(w.b, 0) }
+
+func (w *thriftWriter) listBegin(id int16, elem byte, n int) {
+	w.field(id, thriftList)
+	if n < 15 {
+		w.b = append(w.b, byte(n)<<4|elem)
+	} else {
+		w.b = append(w.b, 0xf0|elem)
+		w.b = binary.AppendUvarint(w.b, uint64(n))
+	}
+}
+
+// elemBegin and elemEnd bracket a struct list element.
+func (w *thriftWriter) elemBegin() { w.last = append(w.last, 0) }
+func (w *thriftWriter) elemEnd()   { w.structEnd() }
+
+func (w *thriftWriter) elemI32(v int32) { w.b = binary.AppendVarint(w.b, int64(v)) }
+
+func (w *thriftWriter) elemBinary(s string) {
+	w.b = binary.AppendUvarint(w.b, uint64(len(s)))
+	w.b = append(w.b, s...)
+}
@@ -0,0 +1,279 @@
+package emailguard
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"slices"
+	"testing"
+)
+
+func TestXLSXColumn(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		if got := xlsxColumn(i); got != want {
+			t.Errorf("xlsxColumn(%d) = %q, want %q", i, got, want)
+		}
+	}
+}
+
+// exportRows is a header and rows wide enough to need two-letter columns,
+// with cells XML must escape.
+func exportRows(n int) [][]string {
+	header := make([]string, 28)
+	for i := range header {
+		header[i] = fmt.Sprintf("col%d", i)
+	}
+	rows := [][]string{header}
+	for r := range n {
+		row := make([]string, len(header))
+		for i := range row {
+			row[i] = fmt.Sprintf("r%d c%d", r, i)
+		}
+		row[0] = fmt.Sprintf("<a&b> \"%d\"  ", r)
+		row[1] = ""
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func writeTable(t *testing.T, format string, rows [][]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw, err := NewTableWriter(&buf, format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		if err := tw.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestXLSXRoundTrip(t *testing.T) {
+	rows := exportRows(3)
+	data := writeTable(t, "xlsx", rows)
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sheet io.ReadCloser
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			sheet = rc
+			continue
+		}
+		// every part must be well-formed XML
+		if err := xml.NewDecoder(rc).Decode(new(struct{})); err != nil {
+			t.Errorf("%s: %v", f.Name, err)
+		}
+		rc.Close()
+	}
+	if sheet == nil {
+		t.Fatal("workbook has no sheet1")
+	}
+	defer sheet.Close()
+
+	var ws struct {
+		Rows []struct {
+			R     int `xml:"r,attr"`
+			Cells []struct {
+				R    string `xml:"r,attr"`
+				T    string `xml:"t,attr"`
+				Text string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.NewDecoder(sheet).Decode(&ws); err != nil {
+		t.Fatal(err)
+	}
+	if len(ws.Rows) != len(rows) {
+		t.Fatalf("%d rows, want %d", len(ws.Rows), len(rows))
+	}
+	for r, row := range ws.Rows {
+		if row.R != r+1 || len(row.Cells) != len(rows[r]) {
+			t.Fatalf("row %d: r=%d with %d cells, want r=%d with %d", r, row.R, len(row.Cells), r+1, len(rows[r]))
+		}
+		for i, c := range row.Cells {
+			if ref := fmt.Sprintf("%s%d", xlsxColumn(i), r+1); c.R != ref || c.T != "inlineStr" || c.Text != rows[r][i] {
+				t.Errorf("cell %s: got %s %s %q, want inlineStr %q", ref, c.R, c.T, c.Text, rows[r][i])
+			}
+		}
+	}
+}
+
+func TestParquetRoundTrip(t *testing.T) {
+	rows := exportRows(parquetRowGroup + 5)
+	header, body := rows[0], rows[1:]
+	data := writeTable(t, "parquet", rows)
+
+	if len(data) < 12 || string(data[:4]) != "PAR1" || string(data[len(data)-4:]) != "PAR1" {
+		t.Fatal("missing PAR1 magic")
+	}
+	flen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	if flen <= 0 || flen > len(data)-12 {
+		t.Fatalf("footer length %d in a %d-byte file", flen, len(data))
+	}
+	footer := data[len(data)-8-flen : len(data)-8]
+	d := &thriftReader{b: footer}
+	meta := d.structure()
+	if d.err != nil || d.p != len(footer) {
+		t.Fatalf("footer: read %d of %d bytes: %v", d.p, len(footer), d.err)
+	}
+
+	schema := meta[2].([]any)
+	if len(schema) != len(header)+1 || schema[0].(map[int16]any)[5] != int64(len(header)) {
+		t.Fatalf("schema %v, want a root with %d columns", schema, len(header))
+	}
+	for i, name := range header {
+		if el := schema[i+1].(map[int16]any); el[4] != name || el[1] != int64(6) {
+			t.Errorf("schema column %d: %v, want BYTE_ARRAY %q", i, el, name)
+		}
+	}
+	if n := meta[3]; n != int64(len(body)) {
+		t.Errorf("num_rows %v, want %d", n, len(body))
+	}
+
+	groups := meta[4].([]any)
+	if len(groups) != 2 {
+		t.Fatalf("%d row groups, want 2 for %d rows", len(groups), len(body))
+	}
+	cols := make([][]string, len(header))
+	for _, g := range groups {
+		g := g.(map[int16]any)
+		chunks := g[1].([]any)
+		if len(chunks) != len(header) {
+			t.Fatalf("row group with %d column chunks, want %d", len(chunks), len(header))
+		}
+		for i, c := range chunks {
+			md := c.(map[int16]any)[3].(map[int16]any)
+			if md[5] != g[3] {
+				t.Errorf("column %d: %v values in a group of %v rows", i, md[5], g[3])
+			}
+			off, size := md[9].(int64), md[7].(int64)
+			chunk := data[off : off+size]
+			p := &thriftReader{b: chunk}
+			page := p.structure()
+			if p.err != nil || page[3] != int64(len(chunk)-p.p) {
+				t.Fatalf("column %d: page header %v (%v) for a %d-byte chunk", i, page, p.err, len(chunk))
+			}
+			values := chunk[p.p:]
+			for range page[5].(map[int16]any)[1].(int64) {
+				n := binary.LittleEndian.Uint32(values)
+				cols[i] = append(cols[i], string(values[4:4+n]))
+				values = values[4+n:]
+			}
+			if len(values) != 0 {
+				t.Errorf("column %d: %d bytes after the page's values", i, len(values))
+			}
+		}
+	}
+	for i := range header {
+		want := make([]string, len(body))
+		for r, row := range body {
+			want[r] = row[i]
+		}
+		if !slices.Equal(cols[i], want) {
+			t.Errorf("column %d does not read back", i)
+		}
+	}
+}
+
+// thriftReader decodes the Thrift compact protocol as written by
+// thriftWriter: structs become maps by field id, lists slices, integers
+// int64 and binaries strings.
+type thriftReader struct {
+	b   []byte
+	p   int
+	err error
+}
+
+func (r *thriftReader) byte() byte {
+	if r.p >= len(r.b) {
+		r.err = io.ErrUnexpectedEOF
+		return 0
+	}
+	r.p++
+	return r.b[r.p-1]
+}
+
+func (r *thriftReader) varint() int64 {
+	v, n := binary.Varint(r.b[r.p:])
+	if n <= 0 {
+		r.err = io.ErrUnexpectedEOF
+		return 0
+	}
+	r.p += n
+	return v
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.b[r.p:])
+	if n <= 0 {
+		r.err = io.ErrUnexpectedEOF
+		return 0
+	}
+	r.p += n
+	return v
+}
+
+func (r *thriftReader) structure() map[int16]any {
+	m := make(map[int16]any)
+	var id int16
+	for r.err == nil {
+		h := r.byte()
+		if h == 0 {
+			break
+		}
+		if d := int16(h >> 4); d > 0 {
+			id += d
+		} else {
+			id = int16(r.varint())
+		}
+		m[id] = r.value(h & 0x0f)
+	}
+	return m
+}
+
+func (r *thriftReader) value(typ byte) any {
+	switch typ {
+	case thriftI32, thriftI64:
+		return r.varint()
+	case thriftBinary:
+		n := int(r.uvarint())
+		if r.err != nil || n > len(r.b)-r.p {
+			r.err = io.ErrUnexpectedEOF
+			return ""
+		}
+		r.p += n
+		return string(r.b[r.p-n : r.p])
+	case thriftList:
+		h := r.byte()
+		n := int(h >> 4)
+		if n == 15 {
+			n = int(r.uvarint())
+		}
+		list := make([]any, 0, n)
+		for range n {
+			list = append(list, r.value(h&0x0f))
+		}
+		return list
+	case thriftStruct:
+		return r.structure()
+	default:
+		r.err = fmt.Errorf("unexpected thrift type %d", typ)
+		return nil
+	}
+}
@@ -0,0 +1,167 @@
+package emailguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/netip"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Faults makes a guard fail on purpose, so a staging environment can check
+// that signup flows cope with every failure emailguard produces before
+// they meet one in production. Rates are probabilities from 0 to 1. Never
+// enable it in production.
+type Faults struct {
+	// DNSTimeout is the share of DNS lookups that hang until their
+	// deadline and then fail as timed out, which surfaces as
+	// OutcomeRetryLater and ErrTemporary.
+	DNSTimeout float64 `json:"dns_timeout"`
+	// SMTPTempFail is the share of SMTP conversations (see
+	// WithDeepVerification) answered with 451.
+	SMTPTempFail float64 `json:"smtp_temp_fail"`
+	// StaleList makes every blocklist refresh after the first load fail,
+	// so the list ages, DebugStats counts refresh failures and refresh
+	// alerts fire.
+	StaleList bool `json:"stale_list"`
+	// Seed fixes the sequence of faults for reproducible runs; zero picks
+	// a random one.
+	Seed uint64 `json:"seed"`
+}
+
+// WithFaultInjection injects f into the guard's DNS lookups, SMTP
+// conversations and blocklist refreshes.
+func WithFaultInjection(f Faults) Option {
+	return func(c *config) error {
+		if f.DNSTimeout < 0 || f.DNSTimeout > 1 || f.SMTPTempFail < 0 || f.SMTPTempFail > 1 {
+			return fmt.Errorf("WithFaultInjection: rates must be between 0 and 1, got dns %g, smtp %g", f.DNSTimeout, f.SMTPTempFail)
+		}
+		c.faults = &f
+		return nil
+	}
+}
+
+// ParseFaults reads faults written as comma-separated settings, for
+// example "dns=0.2,smtp=0.1,stale,seed=42".
+func ParseFaults(s string) (Faults, error) {
+	var f Faults
+	for _, kv := range strings.Split(s, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(kv), "=")
+		var err error
+		switch k {
+		case "":
+		case "dns":
+			f.DNSTimeout, err = strconv.ParseFloat(v, 64)
+		case "smtp":
+			f.SMTPTempFail, err = strconv.ParseFloat(v, 64)
+		case "stale":
+			f.StaleList = true
+		case "seed":
+			f.Seed, err = strconv.ParseUint(v, 10, 64)
+		default:
+			return Faults{}, fmt.Errorf("faults: unknown setting %q; known: dns, smtp, stale, seed", k)
+		}
+		if err != nil {
+			return Faults{}, fmt.Errorf("faults: %s: %w", k, err)
+		}
+	}
+	return f, nil
+}
+
+// errInjected marks the blocklist refreshes failed by Faults.StaleList.
+var errInjected = errors.New("injected fault")
+
+// faultInjector rolls the dice for a guard built WithFaultInjection.
+type faultInjector struct {
+	f    Faults
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+func newFaultInjector(f Faults) *faultInjector {
+	seed := f.Seed
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	return &faultInjector{f: f, rand: rand.New(rand.NewPCG(seed, seed))}
+}
+
+func (fi *faultInjector) roll(p float64) bool {
+	if p <= 0 {
+		return false
+	}
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	return fi.rand.Float64() < p
+}
+
+// smtpFault returns the reply of an injected SMTP failure, or nil.
+func (fi *faultInjector) smtpFault() error {
+	if fi == nil || !fi.roll(fi.f.SMTPTempFail) {
+		return nil
+	}
+	return &textproto.Error{Code: 451, Msg: "4.3.0 try again later (injected fault)"}
+}
+
+// faultResolver times out a share of the lookups it passes to Resolver.
+type faultResolver struct {
+	Resolver
+	fi   *faultInjector
+	wait time.Duration // how long a lookup without a deadline hangs
+}
+
+// timeout waits out the lookup's deadline for an injected timeout, or
+// returns nil.
+func (r faultResolver) timeout(ctx context.Context, name string) error {
+	if !r.fi.roll(r.fi.f.DNSTimeout) {
+		return nil
+	}
+	t := time.NewTimer(r.wait)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+	case <-t.C:
+	}
+	return &net.DNSError{Err: "i/o timeout (injected fault)", Name: name, IsTimeout: true, IsTemporary: true}
+}
+
+func (r faultResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	if err := r.timeout(ctx, name); err != nil {
+		return nil, err
+	}
+	return r.Resolver.LookupMX(ctx, name)
+}
+
+func (r faultResolver) LookupNS(ctx context.Context, name string) ([]*net.NS, error) {
+	if err := r.timeout(ctx, name); err != nil {
+		return nil, err
+	}
+	return r.Resolver.LookupNS(ctx, name)
+}
+
+func (r faultResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if err := r.timeout(ctx, name); err != nil {
+		return nil, err
+	}
+	return r.Resolver.LookupTXT(ctx, name)
+}
+
+func (r faultResolver) LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error) {
+	if err := r.timeout(ctx, host); err != nil {
+		return nil, err
+	}
+	return r.Resolver.LookupNetIP(ctx, network, host)
+}
+
+func (r faultResolver) LookupAddr(ctx context.Context, addr string) ([]string, error) {
+	if err := r.timeout(ctx, addr); err != nil {
+		return nil, err
+	}
+	return lookupAddr(ctx, r.Resolver, addr)
+}
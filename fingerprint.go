@@ -0,0 +1,303 @@
+package emailguard
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProviderFingerprint identifies an API-driven temp-mail service (mail.tm,
+// 1secmail, ...) by infrastructure that stays put while its domains rotate.
+// A domain matches when any of its MX hosts, nameservers or MX addresses
+// hits one of the indicators.
+type ProviderFingerprint struct {
+	Provider string
+	MXHosts  []string // host or parent domain, e.g. "mail.tm"
+	NSHosts  []string // nameserver host or parent domain
+	IPs      []string // MX addresses or CIDR prefixes
+}
+
+// defaultFingerprints only use indicators that are the provider's own
+// domain; operators add NS/IP indicators as they observe them.
+var defaultFingerprints = []ProviderFingerprint{
+	{Provider: "mail.tm", MXHosts: []string{"mail.tm"}},
+	{Provider: "mail.gw", MXHosts: []string{"mail.gw"}},
+	{Provider: "1secmail", MXHosts: []string{"1secmail.com"}},
+	{Provider: "guerrillamail", MXHosts: []string{"guerrillamail.com"}},
+	{Provider: "mailinator", MXHosts: []string{"mailinator.com"}},
+	{Provider: "temp-mail.org", MXHosts: []string{"temp-mail.org"}},
+	{Provider: "maildrop", MXHosts: []string{"maildrop.cc"}},
+}
+
+// WithProviderFingerprints adds fingerprints to the built-in set.
+func WithProviderFingerprints(fps ...ProviderFingerprint) Option {
+	return func(c *config) error {
+		for _, fp := range fps {
+			if fp.Provider == "" {
+				return errors.New("WithProviderFingerprints: fingerprint without provider name")
+			}
+			for _, ip := range fp.IPs {
+				if _, err := parsePrefix(ip); err != nil {
+					return fmt.Errorf("WithProviderFingerprints: %s: %w", fp.Provider, err)
+				}
+			}
+		}
+		c.fingerprints = append(c.fingerprints, fps...)
+		return nil
+	}
+}
+
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		return netip.ParsePrefix(s)
+	}
+	a, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(a, a.BitLen()), nil
+}
+
+func hostUnder(host, parent string) bool {
+	host, parent = normDomain(host), normDomain(parent)
+	return host == parent || strings.HasSuffix(host, "."+parent)
+}
+
+// LearnedDomain is a disposable domain discovered locally through a provider
+// fingerprint rather than from the upstream list.
+type LearnedDomain struct {
+	Domain    string    `json:"domain"`
+	Provider  string    `json:"provider"`
+	Indicator string    `json:"indicator"` // e.g. "mx in.mail.tm"
+	FirstSeen time.Time `json:"first_seen"`
+}
+
+type learnedSet struct {
+	mu sync.Mutex
+	m  map[string]LearnedDomain
+}
+
+// add records d unless its domain is already known and reports whether it
+// was new.
+func (l *learnedSet) add(d LearnedDomain) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.m == nil {
+		l.m = make(map[string]LearnedDomain)
+	}
+	if _, ok := l.m[d.Domain]; ok {
+		return false
+	}
+	l.m[d.Domain] = d
+	return true
+}
+
+// LearnedDomains returns domains this guard identified as disposable via
+// provider fingerprints, sorted by domain.
+func (g *Guard) LearnedDomains() []LearnedDomain {
+	g.learned.mu.Lock()
+	out := make([]LearnedDomain, 0, len(g.learned.m))
+	for _, d := range g.learned.m {
+		out = append(out, d)
+	}
+	g.learned.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Domain < out[j].Domain })
+	return out
+}
+
+// WriteLearned writes LearnedDomains as versioned JSON, the input format of
+// "emailguard contribute". Read it back with ReadLearned.
+func (g *Guard) WriteLearned(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(learnedFile{Version: learnedFormat, Learned: g.LearnedDomains()})
+}
+
+// evalFingerprint matches the domain's mail infrastructure against known
+// temp-mail providers. NS and address lookups only happen when some
+// fingerprint uses them.
+func (g *Guard) evalFingerprint(ctx context.Context, domain string) (Finding, error) {
+	fps := g.cfg.fingerprints
+	if len(fps) == 0 {
+		return noOpinion("no fingerprints"), nil
+	}
+	mxHosts, err := g.lookupMX(ctx, domain)
+	if err != nil {
+		return noOpinion("MX lookup failed"), err
+	}
+	mxHosts = g.scopedMX(ctx, domain, mxHosts)
+
+	var wantNS, wantIP bool
+	for _, fp := range fps {
+		wantNS = wantNS || len(fp.NSHosts) > 0
+		wantIP = wantIP || len(fp.IPs) > 0
+	}
+	var nsHosts []string
+	if wantNS {
+		if nsHosts, err = g.lookupNS(ctx, domain); err != nil {
+			return noOpinion("NS lookup failed"), err
+		}
+	}
+	var addrs []netip.Addr
+	if wantIP && len(mxHosts) > 0 {
+		if addrs, err = g.lookupAddrs(ctx, mxHosts); err != nil {
+			return noOpinion("MX address lookup failed"), err
+		}
+	}
+
+	for _, fp := range fps {
+		if ind, ok := fp.match(mxHosts, nsHosts, addrs); ok {
+			d := LearnedDomain{Domain: domain, Provider: fp.Provider, Indicator: ind, FirstSeen: time.Now()}
+			if g.learned.add(d) && g.cfg.store != nil {
+				warnStore("save learned", g.cfg.store.SaveLearned(d))
+			}
+			return Finding{ActionDeny, ReasonTempMailInfra, fp.Provider, fmt.Sprintf("%s infrastructure (%s)", fp.Provider, ind)}, nil
+		}
+	}
+	return noOpinion("no provider fingerprint matched"), nil
+}
+
+func (fp ProviderFingerprint) match(mx, ns []string, addrs []netip.Addr) (string, bool) {
+	for _, h := range mx {
+		for _, want := range fp.MXHosts {
+			if hostUnder(h, want) {
+				return "mx " + normDomain(h), true
+			}
+		}
+	}
+	for _, h := range ns {
+		for _, want := range fp.NSHosts {
+			if hostUnder(h, want) {
+				return "ns " + normDomain(h), true
+			}
+		}
+	}
+	for _, a := range addrs {
+		for _, want := range fp.IPs {
+			if p, err := parsePrefix(want); err == nil && p.Contains(a) {
+				return "ip " + a.String(), true
+			}
+		}
+	}
+	return "", false
+}
+
+// --- NS / TXT / address lookups (pooled, cached) ---
+
+type dnsCacheEntry struct {
+	hosts []string
+	addrs []netip.Addr
+	exp   time.Time
+}
+
+func (g *Guard) lookupNS(ctx context.Context, domain string) ([]string, error) {
+	if v, ok := g.nsCache.Load(domain); ok {
+		if e := v.(dnsCacheEntry); time.Now().Before(e.exp) {
+			g.countDNS(len(e.hosts) == 0)
+			return e.hosts, nil
+		}
+	}
+	g.countDNS(false)
+	if g.cfg.airGapped {
+		return nil, ErrAirGapped
+	}
+	if err := g.spend(ctx); err != nil {
+		return nil, err
+	}
+	release, err := g.dnsPoolFor(ctx).acquire(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	ctx, cancel := g.dnsContext(ctx)
+	defer cancel()
+	recs, err := g.resolver().LookupNS(ctx, domain)
+	if err = classifyDNSError(err); err != nil {
+		return nil, err
+	}
+	hosts := make([]string, 0, len(recs))
+	for _, ns := range recs {
+		hosts = append(hosts, normDomain(ns.Host))
+	}
+	g.nsCache.Store(domain, dnsCacheEntry{hosts: hosts, exp: time.Now().Add(g.dnsTTL(len(hosts)))})
+	return hosts, nil
+}
+
+// lookupTXT returns domain's TXT records, preferring ones supplied with
+// UsingDNS.
+func (g *Guard) lookupTXT(ctx context.Context, domain string) ([]string, error) {
+	if txt, ok := injectedTXT(ctx, domain); ok {
+		return txt, nil
+	}
+	if v, ok := g.txtCache.Load(domain); ok {
+		if e := v.(dnsCacheEntry); time.Now().Before(e.exp) {
+			g.countDNS(len(e.hosts) == 0)
+			return e.hosts, nil
+		}
+	}
+	g.countDNS(false)
+	if g.cfg.airGapped {
+		return nil, ErrAirGapped
+	}
+	if err := g.spend(ctx); err != nil {
+		return nil, err
+	}
+	release, err := g.dnsPoolFor(ctx).acquire(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	ctx, cancel := g.dnsContext(ctx)
+	defer cancel()
+	txt, err := g.resolver().LookupTXT(ctx, domain)
+	if err = classifyDNSError(err); err != nil {
+		return nil, err
+	}
+	g.txtCache.Store(domain, dnsCacheEntry{hosts: txt, exp: time.Now().Add(g.dnsTTL(len(txt)))})
+	return txt, nil
+}
+
+func (g *Guard) lookupAddrs(ctx context.Context, hosts []string) ([]netip.Addr, error) {
+	var out []netip.Addr
+	for _, h := range hosts {
+		h = normDomain(h)
+		if v, ok := g.addrCache.Load(h); ok {
+			if e := v.(dnsCacheEntry); time.Now().Before(e.exp) {
+				out = append(out, e.addrs...)
+				continue
+			}
+		}
+		if g.cfg.airGapped {
+			return nil, ErrAirGapped
+		}
+		if err := g.spend(ctx); err != nil {
+			return nil, err
+		}
+		release, err := g.dnsPoolFor(ctx).acquire(ctx, h)
+		if err != nil {
+			return nil, err
+		}
+		lctx, cancel := g.dnsContext(ctx)
+		addrs, err := g.resolver().LookupNetIP(lctx, "ip", h)
+		cancel()
+		release()
+		if err = classifyDNSError(err); err != nil {
+			return nil, err
+		}
+		for i, a := range addrs {
+			addrs[i] = a.Unmap()
+		}
+		g.addrCache.Store(h, dnsCacheEntry{addrs: addrs, exp: time.Now().Add(g.dnsTTL(len(addrs)))})
+		out = append(out, addrs...)
+	}
+	return out, nil
+}
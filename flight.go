@@ -0,0 +1,53 @@
+package emailguard
+
+import (
+	"context"
+	"sync"
+)
+
+// flightGroup collapses concurrent lookups of the same key into one, so
+// parallel pipeline stages needing the same MX records query DNS once.
+type flightGroup struct {
+	mu sync.Mutex
+	m  map[string]*flightCall
+}
+
+type flightCall struct {
+	done  chan struct{}
+	hosts []string
+	err   error
+}
+
+// do runs fn once per key at a time, in its own goroutine; every caller,
+// the one that started it included, waits for its result or its own ctx,
+// whichever comes first. fn must not depend on any one caller's ctx: the
+// others share its result, and it runs on after all of them gave up.
+func (f *flightGroup) do(ctx context.Context, key string, fn func() ([]string, error)) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, classifyDNSError(err)
+	}
+	f.mu.Lock()
+	c, ok := f.m[key]
+	if !ok {
+		if f.m == nil {
+			f.m = make(map[string]*flightCall)
+		}
+		c = &flightCall{done: make(chan struct{})}
+		f.m[key] = c
+		go func() {
+			c.hosts, c.err = fn()
+			f.mu.Lock()
+			delete(f.m, key)
+			f.mu.Unlock()
+			close(c.done)
+		}()
+	}
+	f.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.hosts, c.err
+	case <-ctx.Done():
+		return nil, classifyDNSError(ctx.Err())
+	}
+}
@@ -0,0 +1,98 @@
+package emailguard
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockingResolver holds MX lookups until released, or their ctx ends.
+type blockingResolver struct {
+	mxResolver
+	entered chan struct{}
+	release chan struct{}
+	lookups atomic.Int64
+}
+
+func (r *blockingResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	r.lookups.Add(1)
+	r.entered <- struct{}{}
+	select {
+	case <-r.release:
+		return r.mxResolver.LookupMX(ctx, name)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func newBlockingResolver() *blockingResolver {
+	return &blockingResolver{
+		mxResolver: mxResolver{"acme-corp.com": {"mx1.mailhost.net"}},
+		entered:    make(chan struct{}, 2),
+		release:    make(chan struct{}),
+	}
+}
+
+func TestLookupMXOutlivesFirstCaller(t *testing.T) {
+	r := newBlockingResolver()
+	g := newTestGuard(t, WithResolver(r))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error)
+	go func() {
+		_, err := g.lookupMX(ctx, "acme-corp.com")
+		errc <- err
+	}()
+	<-r.entered
+	cancel()
+	if err := <-errc; err == nil {
+		t.Fatal("cancelled caller got no error")
+	}
+
+	type result struct {
+		hosts []string
+		err   error
+	}
+	done := make(chan result)
+	go func() {
+		hosts, err := g.lookupMX(context.Background(), "acme-corp.com")
+		done <- result{hosts, err}
+	}()
+	close(r.release)
+	res := <-done
+	if res.err != nil || len(res.hosts) != 1 {
+		t.Errorf("second caller got %v, %v; want the shared lookup's answer", res.hosts, res.err)
+	}
+	if n := r.lookups.Load(); n != 1 {
+		t.Errorf("%d lookups, want 1 shared", n)
+	}
+}
+
+func TestLookupMXFlightPerPriority(t *testing.T) {
+	r := newBlockingResolver()
+	g := newTestGuard(t, WithResolver(r))
+
+	batch := withCheckOptions(context.Background(), []CheckOption{AtPriority(PriorityBatch)})
+	done := make(chan error, 2)
+	for _, ctx := range []context.Context{context.Background(), batch} {
+		go func() {
+			_, err := g.lookupMX(ctx, "acme-corp.com")
+			done <- err
+		}()
+	}
+	for range 2 {
+		select {
+		case <-r.entered:
+		case <-time.After(5 * time.Second):
+			t.Fatal("a batch check joined the realtime lookup")
+		}
+	}
+	close(r.release)
+	for range 2 {
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+	}
+}
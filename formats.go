@@ -0,0 +1,126 @@
+package emailguard
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// On-disk format versions. Bump a version whenever its format changes and
+// teach the matching migrate function to upgrade the previous one, so
+// accumulated state survives package upgrades.
+const (
+	overridesFormat = 1 // override store file
+	learnedFormat   = 1 // WriteLearned output
+	sqlSchemaFormat = 6 // SQLStore schema, kept in PRAGMA user_version
+	snapshotFormat  = 1 // Snapshot
+)
+
+// ErrNewerFormat is returned when an artifact was written by a newer version
+// of emailguard. It is never rewritten, so downgrades cannot corrupt it.
+var ErrNewerFormat = errors.New("emailguard: written by a newer version")
+
+// formatHeader is the leading part of every versioned JSON artifact.
+type formatHeader struct {
+	Version int `json:"version"`
+}
+
+// checkFormat validates version against current. Version 0 means the file
+// predates version headers.
+func checkFormat(what string, version, current int) error {
+	if version > current {
+		return fmt.Errorf("%s: format v%d, this build reads up to v%d: %w", what, version, current, ErrNewerFormat)
+	}
+	return nil
+}
+
+// backupBeforeMigrate keeps the original bytes next to path before an
+// upgraded version is written over it.
+func backupBeforeMigrate(path string, b []byte, from int) error {
+	bak := fmt.Sprintf("%s.v%d.bak", path, from)
+	if _, err := os.Stat(bak); err == nil {
+		return nil // keep the oldest backup
+	}
+	return writeFileAtomic(bak, b, 0o600)
+}
+
+// --- overrides ---
+
+// migrateOverrides decodes an override file of any known version into the
+// current layout and reports the version it was stored in.
+func migrateOverrides(b []byte) (overrideFile, int, error) {
+	var h formatHeader
+	if err := json.Unmarshal(b, &h); err != nil {
+		return overrideFile{}, 0, err
+	}
+	if err := checkFormat("overrides", h.Version, overridesFormat); err != nil {
+		return overrideFile{}, h.Version, err
+	}
+	var f overrideFile
+	// v0 (unversioned) has the same fields as v1
+	if err := json.Unmarshal(b, &f); err != nil {
+		return overrideFile{}, h.Version, err
+	}
+	f.Version = overridesFormat
+	return f, h.Version, nil
+}
+
+// --- learned domains ---
+
+type learnedFile struct {
+	Version int             `json:"version"`
+	Learned []LearnedDomain `json:"learned"`
+}
+
+// ReadLearned reads the output of WriteLearned, including the bare JSON
+// array written before the format was versioned.
+func ReadLearned(r io.Reader) ([]LearnedDomain, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	b = bytes.TrimSpace(b)
+	if len(b) > 0 && b[0] == '[' {
+		// v0: bare array
+		var out []LearnedDomain
+		err := json.Unmarshal(b, &out)
+		return out, err
+	}
+	var f learnedFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, err
+	}
+	if err := checkFormat("learned domains", f.Version, learnedFormat); err != nil {
+		return nil, err
+	}
+	return f.Learned, nil
+}
+
+// --- SQL schema ---
+
+// sqlMigrations[i] upgrades the schema from version i to i+1.
+var sqlMigrations = []string{
+	sqlSchema, // v0 -> v1: initial schema
+	// v1 -> v2: cache entries are keyed by policy version + domain; drop
+	// the unversioned ones
+	`DELETE FROM verdict_cache;
+	ALTER TABLE verdict_cache RENAME COLUMN domain TO cache_key;`,
+	// v2 -> v3: first sightings for WithQuarantine
+	`CREATE TABLE IF NOT EXISTS first_seen (
+	domain TEXT PRIMARY KEY,
+	at     INTEGER NOT NULL
+);`,
+	// v3 -> v4: what History needs beyond the outcome
+	`ALTER TABLE verdicts ADD COLUMN reason TEXT NOT NULL DEFAULT '';
+	ALTER TABLE verdicts ADD COLUMN match TEXT NOT NULL DEFAULT '';
+	ALTER TABLE verdicts ADD COLUMN list_version TEXT NOT NULL DEFAULT '';
+	ALTER TABLE verdicts ADD COLUMN policy TEXT NOT NULL DEFAULT '';`,
+	// v4 -> v5: the caller's correlation ID
+	`ALTER TABLE verdicts ADD COLUMN correlation_id TEXT NOT NULL DEFAULT '';`,
+	// v5 -> v6: campaigns, and time ranges for FunnelReport
+	`ALTER TABLE verdicts ADD COLUMN campaign TEXT NOT NULL DEFAULT '';
+	CREATE INDEX IF NOT EXISTS verdicts_at ON verdicts(at);`,
+}
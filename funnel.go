@@ -0,0 +1,157 @@
+package emailguard
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DecisionLog is a HistoryStore that can list decisions by time, which
+// FunnelReport needs. SQLStore implements it.
+type DecisionLog interface {
+	// Decisions returns the records made from from up to, not including,
+	// to, oldest first.
+	Decisions(from, to time.Time) ([]DecisionRecord, error)
+}
+
+// Funnel counts checks at each step from checked to deliverable. Each step
+// is a subset of the one before.
+type Funnel struct {
+	Campaign      string `json:"campaign"`       // empty for the total and for checks without a campaign
+	Checked       int    `json:"checked"`        // every check
+	ValidSyntax   int    `json:"valid_syntax"`   // not rejected as malformed
+	NonDisposable int    `json:"non_disposable"` // nor as disposable
+	Deliverable   int    `json:"deliverable"`    // allowed
+}
+
+func (f *Funnel) add(d DecisionRecord) {
+	f.Checked++
+	if d.Reason == ReasonSyntax {
+		return
+	}
+	f.ValidSyntax++
+	if disposableReason(d.Reason) {
+		return
+	}
+	f.NonDisposable++
+	if d.Outcome == OutcomeAllow {
+		f.Deliverable++
+	}
+}
+
+// FunnelReport summarizes the lead quality of the checks in a time window.
+type FunnelReport struct {
+	From  time.Time `json:"from"`
+	To    time.Time `json:"to"`
+	Total Funnel    `json:"total"`
+	// Campaigns breaks Total down by the Campaign option, sorted by name,
+	// when any check had one.
+	Campaigns []Funnel `json:"campaigns,omitempty"`
+}
+
+// FunnelReport summarizes the checks recorded from from up to to: how many
+// addresses were checked, well-formed, not disposable and deliverable, in
+// all and per campaign. It needs a store implementing DecisionLog.
+func (g *Guard) FunnelReport(from, to time.Time) (FunnelReport, error) {
+	dl, ok := g.cfg.store.(DecisionLog)
+	if !ok {
+		return FunnelReport{}, errors.New("funnel report: the store keeps no decision log; use SQLStore")
+	}
+	recs, err := dl.Decisions(from, to)
+	if err != nil {
+		return FunnelReport{}, fmt.Errorf("funnel report: %w", err)
+	}
+	return SummarizeFunnel(recs, from, to), nil
+}
+
+// SummarizeFunnel builds a FunnelReport from the records made from from up
+// to to, e.g. from an export of decision history. Other records are
+// skipped.
+func SummarizeFunnel(recs []DecisionRecord, from, to time.Time) FunnelReport {
+	r := FunnelReport{From: from, To: to}
+	byCampaign := make(map[string]*Funnel)
+	tagged := false
+	for _, d := range recs {
+		if d.At.Before(from) || !d.At.Before(to) {
+			continue
+		}
+		r.Total.add(d)
+		f := byCampaign[d.Campaign]
+		if f == nil {
+			f = &Funnel{Campaign: d.Campaign}
+			byCampaign[d.Campaign] = f
+		}
+		f.add(d)
+		tagged = tagged || d.Campaign != ""
+	}
+	if tagged {
+		for _, name := range slices.Sorted(maps.Keys(byCampaign)) {
+			r.Campaigns = append(r.Campaigns, *byCampaign[name])
+		}
+	}
+	return r
+}
+
+// funnelSteps names the steps of a Funnel in order.
+var funnelSteps = []string{"checked", "valid syntax", "non-disposable", "deliverable"}
+
+func (f Funnel) steps() []int {
+	return []int{f.Checked, f.ValidSyntax, f.NonDisposable, f.Deliverable}
+}
+
+func (f Funnel) name() string {
+	if f.Campaign == "" {
+		return "(no campaign)"
+	}
+	return f.Campaign
+}
+
+// share writes n as a percentage of total.
+func share(n, total int) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", 100*float64(n)/float64(total))
+}
+
+// Text renders r as plain text for a terminal or an email, one block per
+// funnel, with each step's share of the checked addresses.
+func (r FunnelReport) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Funnel from %s to %s\n", r.From.Format(time.RFC3339), r.To.Format(time.RFC3339))
+	write := func(title string, f Funnel) {
+		fmt.Fprintf(&b, "\n%s\n", title)
+		for i, n := range f.steps() {
+			fmt.Fprintf(&b, "  %-15s %8d %7s\n", funnelSteps[i], n, share(n, f.Checked))
+		}
+	}
+	write("all checks", r.Total)
+	for _, f := range r.Campaigns {
+		write(f.name(), f)
+	}
+	return b.String()
+}
+
+// Markdown renders r as a Markdown table, one row per campaign and one for
+// the total.
+func (r FunnelReport) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Funnel from %s to %s\n\n", r.From.Format(time.RFC3339), r.To.Format(time.RFC3339))
+	b.WriteString("| Campaign | Checked | Valid syntax | Non-disposable | Deliverable |\n")
+	b.WriteString("|---|--:|--:|--:|--:|\n")
+	row := func(name string, f Funnel) {
+		fmt.Fprintf(&b, "| %s | %d", strings.ReplaceAll(name, "|", `\|`), f.Checked)
+		for _, n := range f.steps()[1:] {
+			fmt.Fprintf(&b, " | %d (%s)", n, share(n, f.Checked))
+		}
+		b.WriteString(" |\n")
+	}
+	for _, f := range r.Campaigns {
+		row(f.name(), f)
+	}
+	row("**all checks**", r.Total)
+	return b.String()
+}
@@ -0,0 +1,99 @@
+//go:build !emailguard_stdlib
+
+package emailguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// defaultSource is the upstream list used without WithBlocklistSources.
+const defaultSource = repoURL
+
+// errNoGit is nil where git sources are supported.
+var errNoGit error
+
+// probeGit lists the refs of the repository at src.
+func probeGit(ctx context.Context, src string) error {
+	rem := git.NewRemote(memory.NewStorage(), &gitconfig.RemoteConfig{Name: "origin", URLs: []string{src}})
+	_, err := rem.ListContext(ctx, &git.ListOptions{})
+	return err
+}
+
+// isGitCheckout reports whether dir holds a usable git checkout.
+func isGitCheckout(dir string) bool {
+	_, err := git.PlainOpen(dir)
+	return err == nil
+}
+
+// ensureRepo clones or pulls the repo into dir. Optional basic auth can be provided.
+func ensureRepo(url, dir, username, password string) error {
+	// if dir doesn't exist -> clone
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		// clone fresh
+		removeAll(dir)
+		_, err := git.PlainClone(dir, false, &git.CloneOptions{
+			URL:      url,
+			Progress: os.Stdout,
+			Auth:     basicAuthOrNil(username, password),
+			Depth:    1,
+		})
+		return err
+	}
+
+	// else open and pull (but not more often than pullCooldown)
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		return err
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+
+	stamp := filepath.Join(dir, ".lastpull")
+	if fresh(stamp, pullCooldown) {
+		return nil
+	}
+
+	pullErr := wt.Pull(&git.PullOptions{
+		RemoteName: "origin",
+		Depth:      1,
+		Auth:       basicAuthOrNil(username, password),
+		Force:      true,
+	})
+	if pullErr != nil && !errors.Is(pullErr, git.NoErrAlreadyUpToDate) {
+		_ = removeAll(dir)
+		_, cloneErr := git.PlainClone(dir, false, &git.CloneOptions{
+			URL:      url,
+			Progress: os.Stdout,
+			Auth:     basicAuthOrNil(username, password),
+			Depth:    1,
+		})
+		if cloneErr != nil {
+			return fmt.Errorf("pull failed: %v; reclone failed: %w", pullErr, cloneErr)
+		}
+	}
+
+	_ = os.WriteFile(stamp, []byte(time.Now().Format(time.RFC3339Nano)), 0o644)
+	return nil
+}
+
+func basicAuthOrNil(user, pass string) *http.BasicAuth {
+	if user == "" && pass == "" {
+		return nil
+	}
+	return &http.BasicAuth{Username: user, Password: pass}
+}
@@ -0,0 +1,20 @@
+//go:build emailguard_stdlib
+
+package emailguard
+
+import (
+	"context"
+	"errors"
+)
+
+// defaultSource is the upstream list used without WithBlocklistSources:
+// the same list as repoURL, fetched as plain text.
+const defaultSource = listURL
+
+var errNoGit = errors.New("git sources need a build without the emailguard_stdlib tag; use the list's HTTP(S) URL")
+
+func probeGit(context.Context, string) error { return errNoGit }
+
+func isGitCheckout(string) bool { return false }
+
+func ensureRepo(_, _, _, _ string) error { return errNoGit }
@@ -0,0 +1,481 @@
+package emailguard
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Guard evaluates email domains against a layered policy. The zero value is
+// not usable; construct one with New. A Guard is safe for concurrent use.
+//
+// Every Guard owns its configuration, blocklist and caches, so guards with
+// different policies can run side by side in one process without sharing
+// state. Only the on-disk blocklist checkout is shared, when two guards use
+// the same directory.
+type Guard struct {
+	cfg       config
+	overrides atomic.Pointer[OverrideStore]
+	allow     atomic.Pointer[domainSet] // frozen; swapped wholesale, never mutated
+	free      *domainSet                // ClassFree providers
+	platforms *domainSet                // FlagPlatformSubdomain suffixes
+	mxBlock   *domainSet                // WithMXBlocklist
+	parking   *domainSet                // WithParkedDomains
+	blocklist *blocklist
+
+	faults *faultInjector // nil unless WithFaultInjection
+
+	killMu   sync.Mutex                      // serializes kill switch updates
+	killSet  map[string]bool                 // switched off by config and SetKillSwitch; under killMu
+	killFile map[string]bool                 // switched off by WatchKillSwitches; under killMu
+	killed   atomic.Pointer[map[string]bool] // union of the two: checks switched off at runtime
+
+	// verdict cache is per Guard: two guards with different policies must
+	// never share decisions. domain -> verdictEntry.
+	verdicts sync.Map
+	failures sync.Map // domain -> failureEntry, with WithVerdictTTLs
+
+	// DNS and SMTP caches; sync.Map keeps the read path lock-free
+	mxCache    sync.Map // domain -> mxEntry
+	mxFlight   flightGroup
+	nsCache    sync.Map // domain -> dnsCacheEntry
+	txtCache   sync.Map // domain -> dnsCacheEntry
+	addrCache  sync.Map // host -> dnsCacheEntry
+	dnsblCache sync.Map // query name -> dnsblEntry
+	ptrCache   sync.Map // IP address -> dnsCacheEntry
+	smtpCache  sync.Map // "kind|host" -> smtpCacheEntry
+
+	dnsLookups   atomic.Int64 // MX, NS and TXT lookups, cached or not
+	negativeHits atomic.Int64 // of which answered by a negative cache entry
+
+	// domains awaiting re-evaluation after an inconclusive verdict
+	recheckMu sync.Mutex
+	pending   map[string]*pendingCheck
+
+	// per-stage concurrency limits, one set per priority class
+	dnsPool       *pool
+	smtpPool      *pool
+	batchDNSPool  *pool
+	batchSMTPPool *pool
+
+	stats debugStats
+
+	seqStore SequenceStore // nil when sequence detection is off
+
+	relayLimit *tokenBucket // nil when open-relay probing is off
+	budgets    sync.Map     // tenant -> *tokenBucket
+
+	learned learnedSet
+	seen    firstSeenSet // first sightings, for WithQuarantine
+
+	refreshAlerting atomic.Bool // a failing refresh alert is outstanding
+
+	providers map[string]ProviderRule // by domain, for Canonicalize
+
+	policyVersion string    // prefixes verdict keys in the store
+	edits         listEdits // AddToAllowlist and friends
+
+	pipeline []pipelineStage
+}
+
+// Validator is an alias for Guard.
+type Validator = Guard
+
+type config struct {
+	allowlist     []string
+	mxBadKeywords []string
+	blocklistDir  string // where upstream lists are materialized
+	mxTimeout     time.Duration
+	dnsAttempts   int           // per lookup, with retries; see WithDNSRetry
+	dnsBackoff    time.Duration // before the first retry, doubling
+	dnsJitter     float64       // fraction of the backoff varied at random
+	cacheTTL      time.Duration
+	reevaluate    time.Duration // allow verdicts are re-evaluated this often
+
+	allowTTL, rejectTTL time.Duration // zero: cacheTTL
+	negativeTTL         time.Duration // DNS answers that names or records do not exist
+	failureTTL          time.Duration // zero: failures are not cached
+	ruleTTLs            []RuleTTL     // first match overrides allowTTL and rejectTTL
+
+	overrides        *OverrideStore
+	tenant           TenantPolicy
+	onRecheck        RecheckFunc
+	sources          []string // blocklist upstreams, preferred first
+	tldRules         []TLDRule
+	minMX            int         // zero: any MX will do
+	parentMX         bool        // fall back to the registrable parent's MX
+	implicitMX       bool        // no MX: the domain's A/AAAA receives mail
+	mxBlocklist      []string    // MX hosts and domains to reject
+	parked           bool        // look for parking nameservers and MX
+	parkedAction     Action      // ActionNone: flag only
+	parkingHosts     []string    // parking services
+	smtp             *SMTPConfig // nil: no deep verification
+	spf              bool        // flag missing or pass-all SPF
+	dmarc            bool        // report the DMARC policy
+	dkimSelectors    []string    // nil: no DKIM probing
+	dnsbls           []DNSBL     // queried for MX addresses
+	domainLists      []DNSBL     // queried for the domain; WithDomainBlocklists
+	domainListAction Action      // ActionNone: flag only
+
+	listFS     fs.FS // replaces sources when set
+	listFSName string
+
+	relayEvery time.Duration // zero: no open-relay probing
+
+	fingerprints []ProviderFingerprint
+
+	seqWindow    time.Duration // zero disables sequence detection
+	seqThreshold int
+	seqStore     SequenceStore
+
+	dnsConcurrency       int
+	smtpConcurrency      int
+	batchDNSConcurrency  int
+	batchSMTPConcurrency int
+
+	store       Store // nil: state is memory-only
+	consistency Consistency
+
+	tenantBudget  *TenantBudget // nil: tenants are not budgeted
+	tenantBudgets map[string]TenantBudget
+
+	stageTimeouts map[string]time.Duration
+	stages        []Stage         // nil: the default pipeline
+	skipStages    map[string]bool // dropped from the pipeline
+	disabled      map[string]bool // checks turned off; dropped where present
+	killSwitches  []string        // checks switched off at start; see SetKillSwitch
+	airGapped     bool            // no DNS at all
+	resolver      Resolver        // nil: net.DefaultResolver
+	ipPolicy      IPPolicy        // which IP versions to connect over
+	searchDomains bool            // let the system resolver apply resolv.conf search domains
+	reverseDNS    bool            // look up PTR records of the primary MX
+	mxScope       MXScope         // which MX hosts the MX checks look at
+	fallback      Action          // when no stage decides; ActionNone denies
+
+	signer crypto.Signer // signs snapshots
+
+	keyring Keyring // non-nil: keep only hashed local parts
+
+	preHooks, postHooks []Hook
+
+	shadow ShadowFunc // non-nil: report, then allow
+
+	quarantine   time.Duration // zero: no quarantine
+	staleIfError time.Duration // zero: expired verdicts are never served
+	abstainBelow float64       // zero: never OutcomeUnknown
+
+	providerRules []ProviderRule   // for Canonicalize, built-ins first
+	roleAccounts  []string         // nil: no role-account flag
+	subaddress    SubaddressPolicy // user+tag@ handling
+	popular       []string         // typo suggestion targets
+	subdomains    SubdomainPolicy  // how list entries cover subdomains
+	freeProviders []string         // classified ClassFree
+
+	platformSuffixes []string // FlagPlatformSubdomain
+	platformAction   Action   // ActionNone: flag only
+
+	dataGC *DataGC // nil: no garbage collection after refreshes
+
+	faults *Faults // nil: no fault injection
+
+	refreshAfter  time.Duration // alert once refreshes fail this long
+	refreshAlerts []RefreshAlertFunc
+}
+
+// Option configures a Guard.
+type Option func(*config) error
+
+// WithOverrides makes the guard consult s before any other rule.
+func WithOverrides(s *OverrideStore) Option {
+	return func(c *config) error {
+		if s == nil {
+			return errors.New("WithOverrides: nil store")
+		}
+		c.overrides = s
+		return nil
+	}
+}
+
+// WithTenantPolicy installs the embedding application's own per-domain
+// policy. It ranks below overrides and above the built-in lists.
+func WithTenantPolicy(p TenantPolicy) Option {
+	return func(c *config) error {
+		if p == nil {
+			return errors.New("WithTenantPolicy: nil policy")
+		}
+		c.tenant = p
+		return nil
+	}
+}
+
+// TenantPolicy is a per-domain policy owned by the integrating application.
+// Decide returns ActionNone to defer to lower-precedence layers.
+type TenantPolicy interface {
+	Decide(domain string) Action
+}
+
+// TenantPolicyFunc adapts a plain function to TenantPolicy.
+type TenantPolicyFunc func(domain string) Action
+
+func (f TenantPolicyFunc) Decide(domain string) Action { return f(domain) }
+
+// New returns a Guard configured by opts.
+func New(opts ...Option) (*Guard, error) {
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		if err := opt(&cfg); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.relayEvery > 0 && cfg.smtp == nil {
+		return nil, errors.New("WithOpenRelayProbe requires WithDeepVerification")
+	}
+	if cfg.relayEvery > 0 {
+		if err := checkProbeFrom(cfg.smtp.ProbeFrom); err != nil {
+			return nil, fmt.Errorf("WithOpenRelayProbe: %w", err)
+		}
+	}
+	if cfg.airGapped && cfg.smtp != nil {
+		return nil, errors.New("WithAirGapped cannot be combined with WithDeepVerification")
+	}
+	if cfg.reverseDNS && !cfg.canLookupAddr() {
+		return nil, fmt.Errorf("WithReverseDNS: resolver %T cannot look up PTR records; see AddrResolver", cfg.resolver)
+	}
+	if err := checkConsistency(cfg); err != nil {
+		return nil, err
+	}
+	if _, err := buildPipeline(cfg); err != nil {
+		return nil, err
+	}
+	if cfg.keyring != nil {
+		if _, _, err := cfg.keyring.Current(); err != nil {
+			return nil, fmt.Errorf("privacy keyring: %w", err)
+		}
+	}
+	if cfg.store != nil && cfg.overrides == nil {
+		s, err := NewOverrideStoreFrom(cfg.store)
+		if err != nil {
+			return nil, err
+		}
+		cfg.overrides = s
+	}
+	g := newGuard(cfg)
+	if err := g.SetKillSwitches(cfg.killSwitches...); err != nil {
+		return nil, err
+	}
+	if cfg.store != nil {
+		learned, err := cfg.store.LoadLearned()
+		if err != nil {
+			return nil, fmt.Errorf("load learned domains: %w", err)
+		}
+		for _, d := range learned {
+			g.learned.add(d)
+		}
+	}
+	return g, nil
+}
+
+func newGuard(cfg config) *Guard {
+	cfg.resolver = dohForPolicy(cfg.resolver, cfg.ipPolicy)
+	g := &Guard{
+		cfg:           cfg,
+		pending:       make(map[string]*pendingCheck),
+		policyVersion: policyVersion(cfg),
+		dnsPool:       newPool("dns", cfg.dnsConcurrency),
+		smtpPool:      newPool("smtp", cfg.smtpConcurrency),
+		batchDNSPool:  newPool("batch dns", cfg.batchDNSConcurrency),
+		batchSMTPPool: newPool("batch smtp", cfg.batchSMTPConcurrency),
+	}
+	g.pipeline, _ = buildPipeline(cfg) // validated by New
+	g.allow.Store(newDomainSet(cfg.allowlist))
+	g.free = newDomainSet(cfg.freeProviders)
+	g.platforms = newDomainSet(cfg.platformSuffixes)
+	g.mxBlock = newDomainSet(cfg.mxBlocklist)
+	g.parking = newDomainSet(cfg.parkingHosts)
+	if cfg.relayEvery > 0 {
+		g.relayLimit = newTokenBucket(cfg.relayEvery, 1)
+	}
+	if cfg.seqWindow > 0 {
+		g.seqStore = cfg.seqStore
+		if g.seqStore == nil {
+			g.seqStore = newMemSequenceStore()
+		}
+	}
+	sources := cfg.sources
+	if len(sources) == 0 {
+		sources = []string{defaultSource}
+	}
+	g.blocklist = newBlocklist(cfg.blocklistDir, sources)
+	g.blocklist.store = cfg.store
+	g.providers = providerIndex(cfg.providerRules)
+	g.blocklist.fsys, g.blocklist.fsName = cfg.listFS, cfg.listFSName
+	if cfg.overrides != nil {
+		g.overrides.Store(cfg.overrides)
+	}
+	if cfg.faults != nil {
+		g.faults = newFaultInjector(*cfg.faults)
+		g.blocklist.staleFault = cfg.faults.StaleList
+	}
+	return g
+}
+
+func defaultConfig() config {
+	return config{
+		allowlist:       slices.Clone(defaultAllowlist),
+		providerRules:   slices.Clone(defaultProviderRules),
+		popular:         slices.Clone(popularDomains),
+		freeProviders:   defaultFreeProviders(),
+		subdomains:      defaultSubdomainPolicy,
+		mxBadKeywords:   slices.Clone(defaultMXBadKeywords),
+		blocklistDir:    repoDir,
+		mxTimeout:       mxTimeout,
+		dnsAttempts:     1,
+		dnsBackoff:      defaultDNSBackoff,
+		dnsJitter:       defaultDNSJitter,
+		cacheTTL:        cacheTTL,
+		negativeTTL:     defaultNegativeTTL,
+		reevaluate:      defaultReevaluation,
+		dnsConcurrency:  defaultDNSConcurrency,
+		smtpConcurrency: defaultSMTPConcurrency,
+
+		batchDNSConcurrency:  defaultBatchDNSConcurrency,
+		batchSMTPConcurrency: defaultBatchSMTPConcurrency,
+		fingerprints:         append([]ProviderFingerprint(nil), defaultFingerprints...),
+		platformSuffixes:     slices.Clone(defaultPlatformSuffixes),
+		parkingHosts:         slices.Clone(defaultParkingHosts),
+	}
+}
+
+// builtinGuard is the default guard until SetDefaultGuard replaces it.
+var builtinGuard = newGuard(defaultConfig())
+
+// defaultGuard backs the package-level functions.
+var defaultGuard atomic.Pointer[Guard]
+
+func init() {
+	defaultGuard.Store(builtinGuard)
+}
+
+// SetDefaultGuard makes the package-level functions (Verify, Validate,
+// Check, IsLegitEmail, ...) use g, so code written against them picks up any
+// configuration New supports. Checks already running finish on the previous
+// guard. A nil g restores the built-in default: 1s DNS timeout, 5 minute
+// caches, the upstream list checked out under os.TempDir, the built-in
+// allowlist and MX keywords, and no store.
+func SetDefaultGuard(g *Guard) {
+	if g == nil {
+		g = builtinGuard
+	}
+	defaultGuard.Store(g)
+}
+
+// Default returns the guard behind the package-level functions.
+func Default() *Guard {
+	return defaultGuard.Load()
+}
+
+// IsLegitEmail reports whether email's domain passes the guard's policy.
+//
+// Deprecated: Use Verify, which reports transient failures separately.
+func (g *Guard) IsLegitEmail(email string) bool {
+	return g.IsLegitEmailContext(context.Background(), email)
+}
+
+// IsLegitEmailContext is IsLegitEmail bounded by ctx.
+//
+// Deprecated: Use VerifyContext.
+func (g *Guard) IsLegitEmailContext(ctx context.Context, email string) bool {
+	v, _ := g.Validate(ctx, email)
+	return v.Outcome == OutcomeAllow
+}
+
+// Verify reports whether email passes the guard's policy. A non-nil error
+// wraps ErrTemporary and means the address could not be verified right now;
+// ok is then false.
+func (g *Guard) Verify(email string) (ok bool, err error) {
+	return g.VerifyContext(context.Background(), email)
+}
+
+// VerifyContext is Verify bounded by ctx.
+func (g *Guard) VerifyContext(ctx context.Context, email string, opts ...CheckOption) (ok bool, err error) {
+	v, err := g.Validate(ctx, email, opts...)
+	return v.Outcome == OutcomeAllow, err
+}
+
+// splitEmail extracts the local part and the normalized domain of email.
+func splitEmail(email string) (local, domain string, ok bool) {
+	email = strings.TrimSpace(email)
+	at := strings.LastIndexByte(email, '@')
+	if at <= 0 || at == len(email)-1 {
+		return "", "", false
+	}
+	domain = normDomain(email[at+1:])
+	if domain == "" {
+		return "", "", false
+	}
+	return email[:at], internDomain(domain), true
+}
+
+// --- verdict cache ---
+
+type verdictEntry struct {
+	res LayerResult
+	exp time.Time
+}
+
+func (g *Guard) getVerdictCached(domain string) (LayerResult, bool) {
+	v, ok := g.verdicts.Load(domain)
+	if !ok {
+		return g.loadStoredVerdict(domain)
+	}
+	e := v.(verdictEntry)
+	if time.Now().After(g.verdictExpiry(e.res, e.exp)) {
+		return LayerResult{}, false
+	}
+	return e.res, true
+}
+
+// setVerdictCached caches r and returns the result to use, which differs
+// from r only when a shared store already holds another replica's verdict.
+func (g *Guard) setVerdictCached(domain string, r LayerResult) LayerResult {
+	now := time.Now()
+	r.Evaluated = now
+	exp := g.verdictExpiry(r, now.Add(g.verdictTTL(r)))
+	switch {
+	case g.cfg.consistency == ConsistencyShared:
+		// adopt whatever the store settled on, so replicas agree
+		won, wonExp, err := g.cfg.store.(ConsistentStore).PutCachedVerdictIfAbsent(g.storeKey(domain), r, exp)
+		if err == nil {
+			r, exp = won, g.verdictExpiry(won, wonExp)
+		}
+		warnStore("save verdict cache", err)
+	case g.cfg.store != nil:
+		warnStore("save verdict cache", g.cfg.store.SaveCachedVerdict(g.storeKey(domain), r, exp))
+	}
+	g.verdicts.Store(domain, verdictEntry{res: r, exp: exp})
+	return r
+}
+
+// loadStoredVerdict warms the memory cache from the store after a restart.
+func (g *Guard) loadStoredVerdict(domain string) (LayerResult, bool) {
+	if g.cfg.store == nil {
+		return LayerResult{}, false
+	}
+	r, exp, ok, err := g.cfg.store.LoadCachedVerdict(g.storeKey(domain))
+	if err != nil || !ok {
+		warnStore("load verdict cache", err)
+		return LayerResult{}, false
+	}
+	if exp = g.verdictExpiry(r, exp); time.Now().After(exp) {
+		return LayerResult{}, false
+	}
+	g.verdicts.Store(domain, verdictEntry{res: r, exp: exp})
+	return r, true
+}
@@ -0,0 +1,74 @@
+package emailguard
+
+import "time"
+
+// maxHistory bounds History; hot domains collect a record per check.
+const maxHistory = 1000
+
+// DecisionRecord is one past verdict for a domain, as kept by the store.
+type DecisionRecord struct {
+	Domain      string    `json:"domain"`
+	At          time.Time `json:"at"`
+	Outcome     Outcome   `json:"outcome"`
+	Reason      Reason    `json:"reason,omitempty"`
+	Layer       string    `json:"layer,omitempty"`
+	Match       string    `json:"match,omitempty"`
+	Detail      string    `json:"detail,omitempty"`
+	ListVersion string    `json:"list_version,omitempty"` // hash of the blocklist in force
+	Policy      string    `json:"policy,omitempty"`       // fingerprint of the guard's configuration
+	// CorrelationID is the caller's ID for the check; see CorrelationID
+	CorrelationID string `json:"correlation_id,omitempty"`
+	Campaign      string `json:"campaign,omitempty"` // see Campaign
+}
+
+// HistoryStore is a Store that keeps full decision records. SQLStore
+// implements it; other stores only get RecordVerdict.
+type HistoryStore interface {
+	RecordDecision(d DecisionRecord) error
+	// History returns up to limit records for domain, newest first.
+	History(domain string, limit int) ([]DecisionRecord, error)
+}
+
+// History returns the most recent recorded verdicts for domain, newest
+// first, so support can see whether its treatment changed, e.g. after a
+// list refresh (ListVersion) or a configuration change (Policy). It needs a
+// store implementing HistoryStore and returns nil otherwise.
+func (g *Guard) History(domain string) []DecisionRecord {
+	hs, ok := g.cfg.store.(HistoryStore)
+	if !ok {
+		return nil
+	}
+	recs, err := hs.History(normDomain(domain), maxHistory)
+	warnStore("history", err)
+	return recs
+}
+
+// recordDecision adds v to the store's verdict history.
+func (g *Guard) recordDecision(v Verdict) {
+	if g.cfg.store == nil {
+		return
+	}
+	now := time.Now()
+	op := "record verdict"
+	if v.CorrelationID != "" {
+		op += " " + v.CorrelationID
+	}
+	hs, ok := g.cfg.store.(HistoryStore)
+	if !ok {
+		warnStore(op, g.cfg.store.RecordVerdict(v, now))
+		return
+	}
+	warnStore(op, hs.RecordDecision(DecisionRecord{
+		Domain:        v.Domain,
+		At:            now,
+		Outcome:       v.Outcome,
+		Reason:        v.Reason,
+		Layer:         v.Layer,
+		Match:         v.Match,
+		Detail:        v.Detail,
+		ListVersion:   g.blocklist.version(),
+		Policy:        g.policy(),
+		CorrelationID: v.CorrelationID,
+		Campaign:      v.Campaign,
+	}))
+}
@@ -0,0 +1,65 @@
+package emailguard
+
+import "errors"
+
+// HookAction is a hook's decision.
+type HookAction int
+
+const (
+	HookContinue HookAction = iota // no opinion; carry on
+	HookAllow
+	HookDeny
+)
+
+// Layers reported in Verdict.Layer when a hook decided.
+const (
+	LayerPreHook  = "pre_hook"
+	LayerPostHook = "post_hook"
+)
+
+// Hook injects application logic into a check, e.g. blocking competitors or
+// force-allowing partner domains. It sees the verdict so far and may add
+// Flags or Signals to it; returning HookAllow or HookDeny decides the check.
+type Hook func(domain string, v *Verdict) HookAction
+
+// WithPreHook runs h before the pipeline, with only Email and Domain set. A
+// decision skips the pipeline and is not cached. Pre-hooks run in the order
+// given; the first decision wins.
+func WithPreHook(h Hook) Option {
+	return func(c *config) error {
+		if h == nil {
+			return errors.New("WithPreHook: nil hook")
+		}
+		c.preHooks = append(c.preHooks, h)
+		return nil
+	}
+}
+
+// WithPostHook runs h on the finished verdict, including inconclusive ones.
+// A decision replaces the outcome and clears any temporary error. Post-hooks
+// run in the order given; the first decision wins.
+func WithPostHook(h Hook) Option {
+	return func(c *config) error {
+		if h == nil {
+			return errors.New("WithPostHook: nil hook")
+		}
+		c.postHooks = append(c.postHooks, h)
+		return nil
+	}
+}
+
+// runHooks calls hooks until one decides and applies that decision to v.
+// It reports whether one did.
+func runHooks(hooks []Hook, layer string, v *Verdict) bool {
+	for _, h := range hooks {
+		switch h(v.Domain, v) {
+		case HookAllow:
+			fillVerdict(v, LayerResult{Layer: layer, Action: ActionAllow, Reason: ReasonHook, Detail: "allowed by " + layer})
+			return true
+		case HookDeny:
+			fillVerdict(v, LayerResult{Layer: layer, Action: ActionDeny, Reason: ReasonHook, Detail: "denied by " + layer})
+			return true
+		}
+	}
+	return false
+}
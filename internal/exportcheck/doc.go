@@ -0,0 +1,5 @@
+// Package exportcheck reads the XLSX and Parquet output of
+// emailguard.NewTableWriter back with third-party readers. It is a module of
+// its own so those readers never become dependencies of emailguard; run its
+// tests with `go test` from this directory.
+package exportcheck
@@ -0,0 +1,103 @@
+package exportcheck
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/vandit1604/emailguard"
+	"github.com/xuri/excelize/v2"
+)
+
+var header = []string{"email", "outcome", "reason", "detail"}
+
+func rows(n int) [][]string {
+	out := [][]string{header}
+	for i := range n {
+		out = append(out, []string{fmt.Sprintf("user%d@example.com", i), "deny", "blocklist", fmt.Sprintf("<a&b> %d", i)})
+	}
+	return out
+}
+
+func write(t *testing.T, format string, rows [][]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw, err := emailguard.NewTableWriter(&buf, format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		if err := tw.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestXLSX(t *testing.T) {
+	want := rows(30)
+	f, err := excelize.OpenReader(bytes.NewReader(write(t, "xlsx", want)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	got, err := f.GetRows("emailguard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("excelize read %q, want %q", got, want)
+	}
+}
+
+func TestParquet(t *testing.T) {
+	// more than one row group of 8192 rows
+	want := rows(10000)
+	data := write(t, "parquet", want)
+	f, err := parquet.OpenFile(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, c := range f.Schema().Columns() {
+		names = append(names, c[0])
+	}
+	if !slices.Equal(names, header) {
+		t.Errorf("columns %q, want %q", names, header)
+	}
+	if f.NumRows() != int64(len(want)-1) || len(f.RowGroups()) != 2 {
+		t.Fatalf("%d rows in %d row groups, want %d in 2", f.NumRows(), len(f.RowGroups()), len(want)-1)
+	}
+
+	var got [][]string
+	buf := make([]parquet.Row, 1024)
+	for _, rg := range f.RowGroups() {
+		rr := rg.Rows()
+		for {
+			n, err := rr.ReadRows(buf)
+			for _, row := range buf[:n] {
+				cells := make([]string, len(row))
+				for _, v := range row {
+					cells[v.Column()] = v.String()
+				}
+				got = append(got, cells)
+			}
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		rr.Close()
+	}
+	if !slices.EqualFunc(got, want[1:], slices.Equal) {
+		t.Errorf("parquet-go read back %d rows that differ from the %d written", len(got), len(want)-1)
+	}
+}
@@ -0,0 +1,91 @@
+package emailguard
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+)
+
+// IPPolicy says which IP versions the guard connects over, for SMTP
+// probes and DNS over HTTPS. DNS lookups still return addresses of both
+// versions, so fingerprints and DNSBLs see every address a host has.
+type IPPolicy int
+
+const (
+	DualStack IPPolicy = iota // either, as the system prefers (the default)
+	IPv4Only
+	IPv6Only
+)
+
+func (p IPPolicy) String() string {
+	switch p {
+	case IPv4Only:
+		return "ipv4_only"
+	case IPv6Only:
+		return "ipv6_only"
+	default:
+		return "dual_stack"
+	}
+}
+
+func (p IPPolicy) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *IPPolicy) UnmarshalText(b []byte) error {
+	switch string(b) {
+	case "dual_stack", "":
+		*p = DualStack
+	case "ipv4_only":
+		*p = IPv4Only
+	case "ipv6_only":
+		*p = IPv6Only
+	default:
+		return fmt.Errorf("unknown IP policy %q; use dual_stack, ipv4_only or ipv6_only", b)
+	}
+	return nil
+}
+
+// WithIPPolicy restricts the guard's connections to one IP version. In an
+// IPv6-only cluster, IPv6Only keeps SMTP probes from waiting on IPv4
+// addresses they cannot reach; a mail server without an address of the
+// allowed version is reported unreachable, which is never penalized.
+// Verdict.SMTP.Addr and the smtp entry of traced checks show the address
+// used.
+func WithIPPolicy(p IPPolicy) Option {
+	return func(c *config) error {
+		if p < DualStack || p > IPv6Only {
+			return fmt.Errorf("WithIPPolicy: unknown policy %d", p)
+		}
+		c.ipPolicy = p
+		return nil
+	}
+}
+
+// tcp is the network to dial under p.
+func (p IPPolicy) tcp() string {
+	switch p {
+	case IPv4Only:
+		return "tcp4"
+	case IPv6Only:
+		return "tcp6"
+	}
+	return "tcp"
+}
+
+// addrFamily names the IP version of a "host:port" address: "ipv4",
+// "ipv6", or "" if it is not one.
+func addrFamily(hostport string) string {
+	host, _, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host = hostport
+	}
+	switch a, err := netip.ParseAddr(host); {
+	case err != nil:
+		return ""
+	case a.Unmap().Is4():
+		return "ipv4"
+	default:
+		return "ipv6"
+	}
+}
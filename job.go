@@ -0,0 +1,117 @@
+package emailguard
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// Job is a batch running in the background. Start one with StartBatch or
+// StartBulk.
+type Job struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	count  atomic.Int64
+	total  int
+
+	mu        sync.Mutex
+	results   []Result
+	err       error
+	callbacks []func(*Job)
+}
+
+// Progress is how far a Job has got.
+type Progress struct {
+	Done  int `json:"done"`  // addresses checked
+	Total int `json:"total"` // -1 when unknown, as for StartBulk
+}
+
+// StartBatch runs ValidateBatch in the background.
+func (g *Guard) StartBatch(ctx context.Context, emails []string, opts BatchOptions) *Job {
+	j, ctx := newJob(ctx, len(emails))
+	opts.progress = &j.count
+	go func() {
+		res := g.ValidateBatch(ctx, emails, opts)
+		j.finish(res, ctx.Err())
+	}()
+	return j
+}
+
+// StartBulk runs BulkValidateTable in the background. It does not close t.
+func (g *Guard) StartBulk(ctx context.Context, r io.Reader, t TableWriter, opts BatchOptions) *Job {
+	j, ctx := newJob(ctx, -1)
+	opts.progress = &j.count
+	go func() {
+		j.finish(nil, g.BulkValidateTable(ctx, r, t, opts))
+	}()
+	return j
+}
+
+func newJob(ctx context.Context, total int) (*Job, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Job{cancel: cancel, done: make(chan struct{}), total: total}, ctx
+}
+
+func (j *Job) finish(res []Result, err error) {
+	j.mu.Lock()
+	j.results, j.err = res, err
+	cbs := j.callbacks
+	j.callbacks = nil
+	close(j.done)
+	j.mu.Unlock()
+	j.cancel() // release the context
+	for _, fn := range cbs {
+		fn(j)
+	}
+}
+
+// Progress reports how many addresses have been checked so far.
+func (j *Job) Progress() Progress {
+	return Progress{Done: int(j.count.Load()), Total: j.total}
+}
+
+// Cancel stops the job. Addresses already in flight finish; the rest are
+// reported as inconclusive. Cancel does not wait; use Wait or Done.
+func (j *Job) Cancel() { j.cancel() }
+
+// Done is closed when the job has finished.
+func (j *Job) Done() <-chan struct{} { return j.done }
+
+// Wait blocks until the job has finished and returns its error: the
+// context's error if it was cancelled, or a read or write error for bulk
+// jobs.
+func (j *Job) Wait() error {
+	<-j.done
+	return j.Err()
+}
+
+// Err is the job's error, nil while it runs.
+func (j *Job) Err() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.err
+}
+
+// Results returns a finished batch job's results in input order; nil while
+// it runs and for bulk jobs, whose results went to their table.
+func (j *Job) Results() []Result {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.results
+}
+
+// OnComplete registers fn to run once the job has finished, on the job's
+// goroutine. If it already has, fn runs immediately.
+func (j *Job) OnComplete(fn func(*Job)) {
+	j.mu.Lock()
+	select {
+	case <-j.done:
+		j.mu.Unlock()
+		fn(j)
+		return
+	default:
+	}
+	j.callbacks = append(j.callbacks, fn)
+	j.mu.Unlock()
+}
@@ -0,0 +1,19 @@
+package emailguard
+
+import (
+	"context"
+	"testing"
+)
+
+func TestJobCancelledReportsFullProgress(t *testing.T) {
+	g := newTestGuard(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	j := g.StartBatch(ctx, []string{"a@example.org", "b@example.org", "c@example.net"}, BatchOptions{})
+	if err := j.Wait(); err == nil {
+		t.Fatal("Wait: got nil, want the context's error")
+	}
+	if p := j.Progress(); p.Done != p.Total {
+		t.Errorf("Progress = %d/%d after cancellation, want all addresses accounted for", p.Done, p.Total)
+	}
+}
@@ -0,0 +1,237 @@
+package emailguard
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ErrUnknownKey is returned by a Keyring for a key ID it does not hold.
+var ErrUnknownKey = errors.New("emailguard: unknown key")
+
+// Keyring supplies the HMAC keys behind privacy-mode pseudonyms. New
+// pseudonyms use the current key and name its ID, so after a rotation
+// pseudonyms made with an older key still verify as long as the keyring
+// keeps that key.
+type Keyring interface {
+	// Current returns the key new pseudonyms are made with.
+	Current() (id string, key []byte, err error)
+	// Key returns the key with the given ID, or an error wrapping
+	// ErrUnknownKey.
+	Key(id string) ([]byte, error)
+}
+
+// WithPrivacyKeyring is WithPrivacy with keys drawn from k.
+func WithPrivacyKeyring(k Keyring) Option {
+	return func(c *config) error {
+		if k == nil {
+			return errors.New("WithPrivacyKeyring: nil keyring")
+		}
+		c.keyring = k
+		return nil
+	}
+}
+
+// checkKeyID rejects IDs that would make a pseudonym ambiguous.
+func checkKeyID(id string) error {
+	if strings.ContainsAny(id, ".@") {
+		return fmt.Errorf("key ID %q contains '.' or '@'", id)
+	}
+	return nil
+}
+
+func checkKey(id string, key []byte) error {
+	if err := checkKeyID(id); err != nil {
+		return err
+	}
+	if len(key) < minPrivacyKey {
+		return fmt.Errorf("key %q is shorter than %d bytes", id, minPrivacyKey)
+	}
+	return nil
+}
+
+// --- static ---
+
+// StaticKeyring holds keys in memory.
+type StaticKeyring struct {
+	mu      sync.RWMutex
+	current string
+	keys    map[string][]byte
+}
+
+// NewStaticKeyring returns a keyring holding keys, with current as the key
+// for new pseudonyms. Keys must be at least 16 bytes.
+func NewStaticKeyring(current string, keys map[string][]byte) (*StaticKeyring, error) {
+	k := &StaticKeyring{current: current, keys: make(map[string][]byte, len(keys))}
+	for id, key := range keys {
+		if err := checkKey(id, key); err != nil {
+			return nil, fmt.Errorf("keyring: %w", err)
+		}
+		k.keys[id] = append([]byte(nil), key...)
+	}
+	if _, ok := k.keys[current]; !ok {
+		return nil, fmt.Errorf("keyring: current key %q: %w", current, ErrUnknownKey)
+	}
+	return k, nil
+}
+
+// Rotate adds key under id and makes it current. Older keys stay available
+// for verification.
+func (k *StaticKeyring) Rotate(id string, key []byte) error {
+	if err := checkKey(id, key); err != nil {
+		return fmt.Errorf("keyring: %w", err)
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.keys[id]; ok {
+		return fmt.Errorf("keyring: key %q already exists", id)
+	}
+	k.keys[id] = append([]byte(nil), key...)
+	k.current = id
+	return nil
+}
+
+func (k *StaticKeyring) Current() (string, []byte, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.current, k.keys[k.current], nil
+}
+
+func (k *StaticKeyring) Key(id string) ([]byte, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if key, ok := k.keys[id]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("keyring: %q: %w", id, ErrUnknownKey)
+}
+
+// --- environment ---
+
+// KeyringFromEnv builds a StaticKeyring from the environment: prefix_CURRENT
+// names the current key ID and every prefix_KEY_<ID> holds a key, base64 or
+// hex encoded. IDs are lowercased. For example, with prefix
+// "EMAILGUARD_HMAC":
+//
+//	EMAILGUARD_HMAC_CURRENT=k2
+//	EMAILGUARD_HMAC_KEY_K1=<old key>
+//	EMAILGUARD_HMAC_KEY_K2=<new key>
+func KeyringFromEnv(prefix string) (*StaticKeyring, error) {
+	current := strings.ToLower(os.Getenv(prefix + "_CURRENT"))
+	if current == "" {
+		return nil, fmt.Errorf("keyring: %s_CURRENT is not set", prefix)
+	}
+	keys := make(map[string][]byte)
+	for _, kv := range os.Environ() {
+		name, val, _ := strings.Cut(kv, "=")
+		id, ok := strings.CutPrefix(name, prefix+"_KEY_")
+		if !ok || id == "" {
+			continue
+		}
+		key, err := decodeKey(val)
+		if err != nil {
+			return nil, fmt.Errorf("keyring: %s: %w", name, err)
+		}
+		keys[strings.ToLower(id)] = key
+	}
+	return NewStaticKeyring(current, keys)
+}
+
+func decodeKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if b, err := hex.DecodeString(s); err == nil {
+		return b, nil
+	}
+	if b, err := base64.StdEncoding.DecodeString(s); err == nil {
+		return b, nil
+	}
+	return base64.RawURLEncoding.DecodeString(s)
+}
+
+// --- KMS ---
+
+// KMSDecrypter unwraps data keys; implement it with your KMS client (AWS
+// KMS Decrypt, GCP KMS Decrypt, Vault transit, ...).
+type KMSDecrypter interface {
+	Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error)
+}
+
+// KMSKeyring holds data keys wrapped by a KMS and unwraps each one on first
+// use, so plaintext keys never sit in config files or the environment.
+type KMSKeyring struct {
+	kms KMSDecrypter
+
+	mu      sync.Mutex
+	current string
+	wrapped map[string][]byte
+	plain   map[string][]byte
+}
+
+// NewKMSKeyring returns a keyring over wrapped data keys, keyed by ID, with
+// current as the key for new pseudonyms.
+func NewKMSKeyring(kms KMSDecrypter, current string, wrapped map[string][]byte) (*KMSKeyring, error) {
+	if kms == nil {
+		return nil, errors.New("keyring: nil KMS decrypter")
+	}
+	k := &KMSKeyring{kms: kms, current: current, wrapped: make(map[string][]byte), plain: make(map[string][]byte)}
+	for id, w := range wrapped {
+		if err := checkKeyID(id); err != nil {
+			return nil, fmt.Errorf("keyring: %w", err)
+		}
+		k.wrapped[id] = append([]byte(nil), w...)
+	}
+	if _, ok := k.wrapped[current]; !ok {
+		return nil, fmt.Errorf("keyring: current key %q: %w", current, ErrUnknownKey)
+	}
+	return k, nil
+}
+
+// Rotate adds a wrapped key under id and makes it current.
+func (k *KMSKeyring) Rotate(id string, wrapped []byte) error {
+	if err := checkKeyID(id); err != nil {
+		return fmt.Errorf("keyring: %w", err)
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.wrapped[id]; ok {
+		return fmt.Errorf("keyring: key %q already exists", id)
+	}
+	k.wrapped[id] = append([]byte(nil), wrapped...)
+	k.current = id
+	return nil
+}
+
+func (k *KMSKeyring) Current() (string, []byte, error) {
+	k.mu.Lock()
+	id := k.current
+	k.mu.Unlock()
+	key, err := k.Key(id)
+	return id, key, err
+}
+
+func (k *KMSKeyring) Key(id string) ([]byte, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if key, ok := k.plain[id]; ok {
+		return key, nil
+	}
+	w, ok := k.wrapped[id]
+	if !ok {
+		return nil, fmt.Errorf("keyring: %q: %w", id, ErrUnknownKey)
+	}
+	// failures are not cached; the next call retries the KMS
+	key, err := k.kms.Decrypt(context.Background(), w)
+	if err != nil {
+		return nil, fmt.Errorf("keyring: unwrap %q: %w", id, err)
+	}
+	if err := checkKey(id, key); err != nil {
+		return nil, fmt.Errorf("keyring: %w", err)
+	}
+	k.plain[id] = key
+	return key, nil
+}
@@ -0,0 +1,171 @@
+package emailguard
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Names of the checks that run after the pipeline has allowed an address.
+// Kill switches accept them besides the pipeline's stage names.
+const (
+	CheckSPF   = "spf"   // WithSPFCheck
+	CheckDMARC = "dmarc" // WithDMARCCheck
+	CheckDKIM  = "dkim"  // WithDKIMProbe
+	CheckDNSBL = "dnsbl" // WithDNSBL
+	CheckPTR   = "ptr"   // WithReverseDNS
+	CheckSMTP  = "smtp"  // WithDeepVerification
+)
+
+var postChecks = []string{CheckSPF, CheckDMARC, CheckDKIM, CheckDNSBL, CheckPTR, CheckSMTP}
+
+// SetKillSwitch switches the named check off, or back on, while the guard
+// runs: say when a registry starts rate limiting lookups. name is a stage
+// of the pipeline or one of CheckSPF, CheckDMARC, CheckDKIM, CheckDNSBL,
+// CheckPTR and CheckSMTP.
+// A check switched off is skipped, listed in DebugStats and reported in
+// traced checks with StageTrace.Disabled. Verdicts cached before the switch
+// stand until they expire. A check named in the file of WatchKillSwitches
+// stays off until the file drops it.
+func (g *Guard) SetKillSwitch(name string, off bool) error {
+	if !g.killable(name) {
+		return fmt.Errorf("kill switch: unknown check %q", name)
+	}
+	g.killMu.Lock()
+	defer g.killMu.Unlock()
+	m := maps.Clone(g.killSet)
+	if m == nil {
+		m = make(map[string]bool)
+	}
+	if off {
+		m[name] = true
+	} else {
+		delete(m, name)
+	}
+	g.killSet = m
+	g.publishKills()
+	return nil
+}
+
+// SetKillSwitches switches off exactly the named checks, turning every
+// other one back on, except those named in the file of WatchKillSwitches.
+// Unknown names are an error and change nothing.
+func (g *Guard) SetKillSwitches(names ...string) error {
+	m, err := g.killMap(names)
+	if err != nil {
+		return err
+	}
+	g.killMu.Lock()
+	defer g.killMu.Unlock()
+	g.killSet = m
+	g.publishKills()
+	return nil
+}
+
+func (g *Guard) killMap(names []string) (map[string]bool, error) {
+	m := make(map[string]bool, len(names))
+	for _, n := range names {
+		if !g.killable(n) {
+			return nil, fmt.Errorf("kill switch: unknown check %q", n)
+		}
+		m[n] = true
+	}
+	return m, nil
+}
+
+// publishKills makes the union of the switch sets current. g.killMu is
+// held.
+func (g *Guard) publishKills() {
+	m := maps.Clone(g.killSet)
+	if m == nil {
+		m = make(map[string]bool, len(g.killFile))
+	}
+	maps.Copy(m, g.killFile)
+	g.killed.Store(&m)
+}
+
+// KillSwitches returns the checks switched off at runtime, sorted.
+func (g *Guard) KillSwitches() []string {
+	return slices.Sorted(maps.Keys(g.kills()))
+}
+
+func (g *Guard) kills() map[string]bool {
+	if m := g.killed.Load(); m != nil {
+		return *m
+	}
+	return nil
+}
+
+// switchedOff reports whether the named check is switched off at runtime.
+func (g *Guard) switchedOff(name string) bool {
+	return g.kills()[name]
+}
+
+func (g *Guard) killable(name string) bool {
+	return slices.Contains(knownChecks, name) || slices.Contains(postChecks, name) || stageIndex(g.pipeline, name) >= 0
+}
+
+// WatchKillSwitches polls the file at path every interval until ctx is
+// done, switching off the checks it names, one per line; blank lines and
+// lines starting with # are ignored. Mounting the same file on every
+// instance, a Kubernetes ConfigMap for example, switches checks fleet-wide
+// without a redeploy. The file's switches add to those of the config and
+// SetKillSwitch rather than replacing them, and a missing file names none.
+// An unreadable or invalid file is logged and leaves its switches as they
+// are.
+func (g *Guard) WatchKillSwitches(ctx context.Context, path string, interval time.Duration) {
+	var last []byte
+	apply := func() {
+		b, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			b, err = []byte{}, nil
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "WARN: kill switches: %v\n", err)
+			return
+		}
+		if last != nil && bytes.Equal(b, last) {
+			return
+		}
+		m, err := g.killMap(parseKillSwitches(b))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "WARN: %s: %v\n", path, err)
+			return
+		}
+		g.killMu.Lock()
+		g.killFile = m
+		g.publishKills()
+		g.killMu.Unlock()
+		last = append([]byte{}, b...)
+	}
+	apply()
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			apply()
+		}
+	}
+}
+
+func parseKillSwitches(b []byte) []string {
+	var names []string
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			names = append(names, line)
+		}
+	}
+	return names
+}
@@ -0,0 +1,68 @@
+package emailguard
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// Switching the MX checks off during a DNS outage must stop MX lookups,
+// not turn them into retry-later verdicts.
+func TestKillSwitchMXSkipsLookup(t *testing.T) {
+	r := &timeoutResolver{}
+	g := newTestGuard(t, WithResolver(r))
+	if err := g.SetKillSwitches(LayerMX, LayerMXKeywords, LayerDisposableMX, LayerFingerprint); err != nil {
+		t.Fatal(err)
+	}
+	v, err := g.Validate(context.Background(), "jane@example.org")
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if v.Outcome != OutcomeAllow || v.Reason == ReasonValidMX {
+		t.Errorf("got %s/%s, want allow without %s", v.Outcome, v.Reason, ReasonValidMX)
+	}
+	if n := r.lookups.Load(); n != 0 {
+		t.Errorf("%d lookups with the MX checks switched off, want none", n)
+	}
+}
+
+// The watched file is a source of its own: it neither wipes the switches
+// set in process nor is undone by them.
+func TestWatchKillSwitchesMerges(t *testing.T) {
+	g := newTestGuard(t)
+	if err := g.SetKillSwitches(CheckSPF); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "kill-switches")
+	watch := func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		g.WatchKillSwitches(ctx, path, time.Hour) // reads the file once
+	}
+	check := func(when string, want ...string) {
+		t.Helper()
+		if got := g.KillSwitches(); !slices.Equal(got, want) {
+			t.Errorf("%s: switched off %q, want %q", when, got, want)
+		}
+	}
+
+	watch()
+	check("no file", CheckSPF)
+	if err := os.WriteFile(path, []byte("# outage\ndkim\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	watch()
+	check("file names dkim", CheckDKIM, CheckSPF)
+	if err := g.SetKillSwitch(CheckDKIM, false); err != nil {
+		t.Fatal(err)
+	}
+	check("dkim switched on in process", CheckDKIM, CheckSPF)
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	watch()
+	check("file removed", CheckSPF)
+}
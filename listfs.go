@@ -0,0 +1,58 @@
+package emailguard
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// WithBlocklistFS reads the disposable blocklist from name in fsys (an
+// embed.FS, fstest.MapFS, zip reader, ...) instead of the upstream
+// sources, so tests and embedded deployments need neither network nor a
+// writable disk. The file has one domain per line; lines starting with #
+// or ; are comments. RunBlocklistRefresh re-reads it.
+func WithBlocklistFS(fsys fs.FS, name string) Option {
+	return func(c *config) error {
+		if fsys == nil {
+			return errors.New("WithBlocklistFS: nil fs")
+		}
+		if _, err := fs.Stat(fsys, name); err != nil {
+			return fmt.Errorf("WithBlocklistFS: %w", err)
+		}
+		c.listFS, c.listFSName = fsys, name
+		return nil
+	}
+}
+
+// WithAllowlistFS replaces the built-in allowlist with the domains listed
+// in name in fsys, in the format of WithBlocklistFS.
+func WithAllowlistFS(fsys fs.FS, name string) Option {
+	return func(c *config) error {
+		if fsys == nil {
+			return errors.New("WithAllowlistFS: nil fs")
+		}
+		f, err := fsys.Open(name)
+		if err != nil {
+			return fmt.Errorf("WithAllowlistFS: %w", err)
+		}
+		defer f.Close()
+		domains, err := readList(f)
+		if err != nil {
+			return fmt.Errorf("WithAllowlistFS: %s: %w", name, err)
+		}
+		return WithAllowlist(domains...)(c)
+	}
+}
+
+func readBlocklistFS(fsys fs.FS, name string) (*domainSet, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	lines, err := readList(f)
+	if err != nil {
+		return nil, fmt.Errorf("scanning blocklist %s: %w", name, err)
+	}
+	return newDomainSet(lines), nil
+}
@@ -0,0 +1,114 @@
+package emailguard
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// listEdits are runtime changes to the allowlist and blocklist, laid over
+// the configured allowlist and the synced blocklist. They live in memory
+// only; a restarted guard starts from its configuration again.
+type listEdits struct {
+	mu                 sync.Mutex // serializes writers
+	allowAdd, allowDel map[string]bool
+	blockAdd, blockDel map[string]bool
+	version            atomic.Pointer[string] // "" until the first edit
+}
+
+// overlay is a frozen set of edits to one list.
+type overlay struct {
+	add, del map[string]bool
+}
+
+// apply returns base with o's edits.
+func (o *overlay) apply(base *domainSet) *domainSet {
+	if o == nil || len(o.add)+len(o.del) == 0 {
+		return base
+	}
+	domains := make([]string, 0, base.Len()+len(o.add))
+	base.all(func(d string) {
+		if !o.del[d] {
+			domains = append(domains, d)
+		}
+	})
+	for d := range o.add {
+		domains = append(domains, d)
+	}
+	return newDomainSet(domains)
+}
+
+// AddToAllowlist allows domains from now on, ahead of the blocklist. The
+// change takes effect immediately: cached verdicts are dropped.
+func (g *Guard) AddToAllowlist(domains ...string) error {
+	return g.editLists(domains, func(e *listEdits, d string) { delete(e.allowDel, d); e.allowAdd[d] = true })
+}
+
+// RemoveFromAllowlist takes domains off the allowlist, built-in entries
+// included.
+func (g *Guard) RemoveFromAllowlist(domains ...string) error {
+	return g.editLists(domains, func(e *listEdits, d string) { delete(e.allowAdd, d); e.allowDel[d] = true })
+}
+
+// AddToBlocklist blocks domains as disposable from now on. The entries
+// survive blocklist refreshes.
+func (g *Guard) AddToBlocklist(domains ...string) error {
+	return g.editLists(domains, func(e *listEdits, d string) { delete(e.blockDel, d); e.blockAdd[d] = true })
+}
+
+// RemoveFromBlocklist stops blocking domains even while the upstream list
+// carries them, e.g. after a false positive.
+func (g *Guard) RemoveFromBlocklist(domains ...string) error {
+	return g.editLists(domains, func(e *listEdits, d string) { delete(e.blockAdd, d); e.blockDel[d] = true })
+}
+
+// editLists validates domains, applies edit to each, installs the new
+// lists and drops every cached verdict, which the edit may have changed.
+func (g *Guard) editLists(domains []string, edit func(*listEdits, string)) error {
+	norm := make([]string, len(domains))
+	for i, d := range domains {
+		norm[i] = normEntry(d)
+		if err := checkListEntry(norm[i]); err != nil {
+			return fmt.Errorf("%q: %w", d, err)
+		}
+	}
+
+	e := &g.edits
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.allowAdd == nil {
+		e.allowAdd, e.allowDel = make(map[string]bool), make(map[string]bool)
+		e.blockAdd, e.blockDel = make(map[string]bool), make(map[string]bool)
+	}
+	for _, d := range norm {
+		edit(e, d)
+	}
+
+	allow := &overlay{add: maps.Clone(e.allowAdd), del: maps.Clone(e.allowDel)}
+	g.allow.Store(allow.apply(newDomainSet(g.cfg.allowlist)))
+	g.blocklist.setOverlay(&overlay{add: maps.Clone(e.blockAdd), del: maps.Clone(e.blockDel)})
+
+	h := sha256.New()
+	for _, m := range []map[string]bool{e.allowAdd, e.allowDel, e.blockAdd, e.blockDel} {
+		fmt.Fprintf(h, "%q\n", slices.Sorted(maps.Keys(m)))
+	}
+	v := "+" + hex.EncodeToString(h.Sum(nil)[:4])
+	e.version.Store(&v)
+
+	g.verdicts.Clear()
+	g.failures.Clear()
+	return nil
+}
+
+// policy is the policy version including runtime list edits, so verdicts
+// cached in the store before an edit are not served after it.
+func (g *Guard) policy() string {
+	if v := g.edits.version.Load(); v != nil {
+		return g.policyVersion + *v
+	}
+	return g.policyVersion
+}
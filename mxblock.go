@@ -0,0 +1,53 @@
+package emailguard
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// WithMXBlocklist rejects domains whose MX hosts are listed, so abusive
+// mail infrastructure is banned once for every domain pointed at it. An
+// entry names an MX host (mx1.abuse.example) or a domain whose hosts are
+// all banned (abuse.example); wildcard and regexp rules work as in the
+// blocklist. Entries add to earlier ones.
+func WithMXBlocklist(entries ...string) Option {
+	return func(c *config) error {
+		for _, e := range entries {
+			if err := checkListEntry(normEntry(e)); err != nil {
+				return fmt.Errorf("WithMXBlocklist: %w", err)
+			}
+		}
+		c.mxBlocklist = append(c.mxBlocklist, entries...)
+		return nil
+	}
+}
+
+// WithMXBlocklistFS adds the entries listed in name in fsys to the MX
+// blocklist, in the format of WithBlocklistFS.
+func WithMXBlocklistFS(fsys fs.FS, name string) Option {
+	return func(c *config) error {
+		if fsys == nil {
+			return errors.New("WithMXBlocklistFS: nil fs")
+		}
+		f, err := fsys.Open(name)
+		if err != nil {
+			return fmt.Errorf("WithMXBlocklistFS: %w", err)
+		}
+		defer f.Close()
+		entries, err := readList(f)
+		if err != nil {
+			return fmt.Errorf("WithMXBlocklistFS: %s: %w", name, err)
+		}
+		return WithMXBlocklist(entries...)(c)
+	}
+}
+
+// blockedMX returns the MX blocklist entry host matches: the host itself, a
+// rule, or a domain it is under.
+func (g *Guard) blockedMX(host string) (string, bool) {
+	if g.mxBlock.Len() == 0 {
+		return "", false
+	}
+	return SubdomainPolicy{}.match(g.mxBlock, host, true)
+}
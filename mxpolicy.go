@@ -0,0 +1,244 @@
+package emailguard
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// MX hostname hints for consumer-grade or dynamic hosting. A lone MX on such
+// infrastructure is unusual for a real business.
+var residentialMXHints = []string{
+	"dyn",
+	"dsl",
+	"dhcp",
+	"cable",
+	"pool",
+	"broadband",
+	"residential",
+	"duckdns",
+	"no-ip",
+	"ddns",
+}
+
+// WithMinMX rejects domains publishing fewer than n distinct MX hosts.
+func WithMinMX(n int) Option {
+	return func(c *config) error {
+		if n < 1 {
+			return fmt.Errorf("WithMinMX: must be at least 1, got %d", n)
+		}
+		c.minMX = n
+		return nil
+	}
+}
+
+// MXScope says which of a domain's MX hosts the MX keyword, blocklist and
+// disposable-MX checks and the provider fingerprints look at.
+type MXScope int
+
+const (
+	MXScopeAll     MXScope = iota // every MX host (the default)
+	MXScopePrimary                // only the hosts with the lowest preference
+)
+
+func (s MXScope) String() string {
+	if s == MXScopePrimary {
+		return "primary"
+	}
+	return "all"
+}
+
+func (s MXScope) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *MXScope) UnmarshalText(b []byte) error {
+	switch string(b) {
+	case "all", "":
+		*s = MXScopeAll
+	case "primary":
+		*s = MXScopePrimary
+	default:
+		return fmt.Errorf("unknown MX scope %q; use all or primary", b)
+	}
+	return nil
+}
+
+// WithMXScope restricts the MX keyword, blocklist and disposable-MX checks
+// and the MX hosts and addresses of provider fingerprints to scope. With MXScopePrimary, a domain whose primary MX is legitimate is
+// not rejected for a disposable-looking backup relay, and a domain is
+// judged by the host that actually receives its mail. Hosts supplied with
+// UsingDNS come without preferences; the first counts as the primary.
+func WithMXScope(scope MXScope) Option {
+	return func(c *config) error {
+		if scope != MXScopeAll && scope != MXScopePrimary {
+			return fmt.Errorf("WithMXScope: unknown scope %d", scope)
+		}
+		c.mxScope = scope
+		return nil
+	}
+}
+
+// MXRecord is an MX host with its preference; lower is tried first.
+type MXRecord struct {
+	Host string `json:"host"`
+	Pref uint16 `json:"pref"`
+}
+
+// scopedMX narrows hosts, domain's MX hosts, to those the MX checks and
+// fingerprints look at under WithMXScope.
+func (g *Guard) scopedMX(ctx context.Context, domain string, hosts []string) []string {
+	if g.cfg.mxScope != MXScopePrimary || len(hosts) < 2 {
+		return hosts
+	}
+	prefs := g.mxPrefs(ctx, domain, hosts)
+	if prefs == nil {
+		return hosts[:1]
+	}
+	n := 1
+	for n < len(hosts) && prefs[n] == prefs[0] {
+		n++
+	}
+	return hosts[:n]
+}
+
+// mxPrefs returns the preferences of hosts, domain's MX hosts, or nil if
+// they are not known: supplied with UsingDNS, or the implicit MX.
+func (g *Guard) mxPrefs(ctx context.Context, domain string, hosts []string) []uint16 {
+	if _, ok := injectedMX(ctx, domain); ok {
+		return nil
+	}
+	e, ok := g.cachedMXEntry(domain)
+	if !ok || len(e.prefs) != len(hosts) {
+		return nil
+	}
+	return e.prefs
+}
+
+// mxRecords returns domain's known MX hosts with their preferences, or
+// nil if the preferences are not known.
+func (g *Guard) mxRecords(ctx context.Context, domain string) []MXRecord {
+	hosts, ok := g.knownMX(ctx, domain)
+	if !ok {
+		return nil
+	}
+	prefs := g.mxPrefs(ctx, domain, hosts)
+	if prefs == nil {
+		return nil
+	}
+	out := make([]MXRecord, len(hosts))
+	for i, h := range hosts {
+		out[i] = MXRecord{Host: h, Pref: prefs[i]}
+	}
+	return out
+}
+
+// WithParentDomainMX evaluates the registrable parent's MX when an address
+// on a subdomain (user@mail.company.com) has none of its own, instead of
+// rejecting it for missing MX. Verdict.MXDomain reports which domain
+// carried the mail infrastructure.
+func WithParentDomainMX() Option {
+	return func(c *config) error {
+		c.parentMX = true
+		return nil
+	}
+}
+
+// WithImplicitMX treats a domain without MX records but with an A or AAAA
+// record as its own mail host, as RFC 5321 section 5.1 does, instead of
+// rejecting it for missing MX. Small businesses often run mail that way.
+// Such verdicts carry the SignalMXImplicit signal, and the MX checks and
+// SMTP probes see the domain itself as the MX host. A null MX (RFC 7505)
+// still means no mail.
+func WithImplicitMX() Option {
+	return func(c *config) error {
+		c.implicitMX = true
+		return nil
+	}
+}
+
+// implicitMX returns domain as its own mail host if it has an address, or
+// nothing if it has none.
+func implicitMX(ctx context.Context, r Resolver, domain string) ([]string, error) {
+	addrs, err := r.LookupNetIP(ctx, "ip", domain)
+	if err = classifyDNSError(err); err != nil || len(addrs) == 0 {
+		return nil, err
+	}
+	return []string{domain}, nil
+}
+
+// isImplicitMX reports whether domain's cached mail host is the implicit
+// one.
+func (g *Guard) isImplicitMX(domain string) bool {
+	v, ok := g.mxCache.Load(domain)
+	return ok && v.(mxEntry).implicit
+}
+
+// parentDomain returns domain's registrable parent, or "" when domain is
+// itself registrable.
+func parentDomain(domain string) string {
+	rd, err := registrableDomain(domain)
+	if err != nil || rd == domain {
+		return ""
+	}
+	return rd
+}
+
+// mxDomain is the domain whose MX serves domain: domain itself, or its
+// parent under WithParentDomainMX. It only consults known MX data and
+// returns "" when no MX is known.
+func (g *Guard) mxDomain(ctx context.Context, domain string) string {
+	if hosts, ok := g.knownMX(ctx, domain); ok && len(hosts) > 0 {
+		return domain
+	}
+	if !g.cfg.parentMX {
+		return ""
+	}
+	if p := parentDomain(domain); p != "" {
+		if hosts, ok := g.knownMX(ctx, p); ok && len(hosts) > 0 {
+			return p
+		}
+	}
+	return ""
+}
+
+// distinctHosts counts MX hosts after normalization.
+func distinctHosts(hosts []string) int {
+	seen := make(map[string]struct{}, len(hosts))
+	for _, h := range hosts {
+		seen[normDomain(h)] = struct{}{}
+	}
+	return len(seen)
+}
+
+func looksResidential(host string) bool {
+	host = normDomain(host)
+	for _, hint := range residentialMXHints {
+		if strings.Contains(host, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// mxSignals scores MX redundancy from already known MX data; it never
+// triggers a lookup of its own.
+func (g *Guard) mxSignals(ctx context.Context, domain string) (count int, signals []Signal) {
+	hosts, ok := g.knownMX(ctx, domain)
+	if !ok || len(hosts) == 0 {
+		return 0, nil
+	}
+	count = distinctHosts(hosts)
+	if g.isImplicitMX(domain) {
+		return count, []Signal{{Name: SignalMXImplicit, Weight: 15, Detail: "no MX records; mail goes to the domain's own address"}}
+	}
+	switch {
+	case count >= 2:
+		signals = append(signals, Signal{Name: SignalMXRedundant, Weight: -10, Detail: fmt.Sprintf("%d MX hosts", count)})
+	case looksResidential(hosts[0]):
+		signals = append(signals, Signal{Name: SignalMXSingleResidential, Weight: 20, Detail: "single MX on residential-grade host " + normDomain(hosts[0])})
+	default:
+		signals = append(signals, Signal{Name: SignalMXSingle, Weight: 5, Detail: "single MX host " + normDomain(hosts[0])})
+	}
+	return count, signals
+}
@@ -0,0 +1,85 @@
+package emailguard
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// WithMXTimeout bounds each DNS lookup when the caller's context has no
+// deadline. The default is 1s.
+func WithMXTimeout(d time.Duration) Option {
+	return func(c *config) error {
+		if d <= 0 {
+			return fmt.Errorf("WithMXTimeout: need a positive timeout, got %v", d)
+		}
+		c.mxTimeout = d
+		return nil
+	}
+}
+
+// WithCacheTTL sets how long verdicts and DNS/SMTP results are cached. The
+// default is 5 minutes. Answers that a domain or record does not exist
+// keep WithNegativeCacheTTL.
+func WithCacheTTL(d time.Duration) Option {
+	return func(c *config) error {
+		if d <= 0 {
+			return fmt.Errorf("WithCacheTTL: need a positive TTL, got %v", d)
+		}
+		c.cacheTTL = d
+		return nil
+	}
+}
+
+// WithAllowlist replaces the built-in allowlist of consumer providers. To
+// extend it instead, pass append(DefaultAllowlist(), ...).
+func WithAllowlist(domains ...string) Option {
+	return func(c *config) error {
+		c.allowlist = slices.Clone(domains)
+		return nil
+	}
+}
+
+// WithBadMXKeywords replaces the built-in list of substrings that mark an MX
+// host as a masking or forwarding service. To extend it instead, pass
+// append(DefaultBadMXKeywords(), ...).
+func WithBadMXKeywords(keywords ...string) Option {
+	return func(c *config) error {
+		kw := make([]string, 0, len(keywords))
+		for _, k := range keywords {
+			if k = strings.ToLower(strings.TrimSpace(k)); k == "" {
+				return errors.New("WithBadMXKeywords: empty keyword")
+			}
+			kw = append(kw, k)
+		}
+		c.mxBadKeywords = kw
+		return nil
+	}
+}
+
+// WithBlocklistDir sets where the upstream blocklist is checked out. The
+// default is disposable-email-domains in os.TempDir (/tmp on Linux, %TEMP%
+// on Windows); point it elsewhere where that is read-only, shared or wiped.
+// Processes sharing a directory take turns refreshing it.
+func WithBlocklistDir(dir string) Option {
+	return func(c *config) error {
+		if dir == "" {
+			return errors.New("WithBlocklistDir: empty path")
+		}
+		c.blocklistDir = filepath.Clean(dir)
+		return nil
+	}
+}
+
+// DefaultAllowlist returns a copy of the built-in allowlist.
+func DefaultAllowlist() []string {
+	return slices.Clone(defaultAllowlist)
+}
+
+// DefaultBadMXKeywords returns a copy of the built-in MX keywords.
+func DefaultBadMXKeywords() []string {
+	return slices.Clone(defaultMXBadKeywords)
+}
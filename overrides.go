@@ -0,0 +1,313 @@
+package emailguard
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Action is an explicit policy decision for a domain.
+type Action int
+
+const (
+	ActionNone Action = iota // no decision; fall through to the next rule
+	ActionAllow
+	ActionDeny
+)
+
+func (a Action) String() string {
+	switch a {
+	case ActionAllow:
+		return "allow"
+	case ActionDeny:
+		return "deny"
+	default:
+		return "none"
+	}
+}
+
+func (a Action) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+func (a *Action) UnmarshalText(b []byte) error {
+	switch string(b) {
+	case "allow":
+		*a = ActionAllow
+	case "deny":
+		*a = ActionDeny
+	case "none", "":
+		*a = ActionNone
+	default:
+		return fmt.Errorf("unknown action %q", b)
+	}
+	return nil
+}
+
+// Override is an operator decision pinned to a single domain, together with
+// who made it, when, and why.
+type Override struct {
+	Domain  string    `json:"domain"`
+	Action  Action    `json:"action"`
+	Actor   string    `json:"actor"`
+	Reason  string    `json:"reason"`
+	Created time.Time `json:"created"`
+	Expires time.Time `json:"expires,omitzero"` // zero => never expires
+}
+
+// Expired reports whether the override is no longer in force at now.
+func (o Override) Expired(now time.Time) bool {
+	return !o.Expires.IsZero() && !now.Before(o.Expires)
+}
+
+// AuditEntry records a single change made to an OverrideStore.
+type AuditEntry struct {
+	Time     time.Time `json:"time"`
+	Op       string    `json:"op"` // "set" or "remove"
+	Actor    string    `json:"actor"`
+	Reason   string    `json:"reason"`
+	Override Override  `json:"override"`
+}
+
+// OverrideStore keeps operator overrides with an append-only audit trail.
+// When created with a path or a Store, every change is persisted so
+// exceptions survive restarts.
+type OverrideStore struct {
+	mu      sync.Mutex // serializes writers
+	path    string
+	db      Store // when set, changes go here instead of path
+	entries map[string]Override
+	audit   []AuditEntry
+
+	// active is a frozen copy of entries republished after every change so
+	// Lookup, which sits on the hot path, never takes a lock.
+	active atomic.Pointer[map[string]Override]
+}
+
+type overrideFile struct {
+	Version   int          `json:"version"`
+	Overrides []Override   `json:"overrides"`
+	Audit     []AuditEntry `json:"audit"`
+}
+
+// NewOverrideStore opens (or creates) an override store backed by path.
+// An empty path yields a memory-only store.
+func NewOverrideStore(path string) (*OverrideStore, error) {
+	s := &OverrideStore{path: path, entries: make(map[string]Override)}
+	if path == "" {
+		s.publishLocked()
+		return s, nil
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		s.publishLocked()
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	f, from, err := migrateOverrides(b)
+	if err != nil {
+		return nil, fmt.Errorf("parse overrides %s: %w", path, err)
+	}
+	for _, o := range f.Overrides {
+		s.entries[normDomain(o.Domain)] = o
+	}
+	s.audit = f.Audit
+	s.publishLocked()
+	if from != overridesFormat {
+		if err := backupBeforeMigrate(path, b, from); err != nil {
+			return nil, fmt.Errorf("back up overrides %s: %w", path, err)
+		}
+		if err := s.saveLocked(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// NewOverrideStoreFrom loads overrides from db and persists every change
+// back to it.
+func NewOverrideStoreFrom(db Store) (*OverrideStore, error) {
+	entries, audit, err := db.LoadOverrides()
+	if err != nil {
+		return nil, fmt.Errorf("load overrides: %w", err)
+	}
+	s := &OverrideStore{db: db, entries: make(map[string]Override, len(entries)), audit: audit}
+	for _, o := range entries {
+		s.entries[normDomain(o.Domain)] = o
+	}
+	s.publishLocked()
+	return s, nil
+}
+
+// Set records o, replacing any existing override for the same domain.
+func (s *OverrideStore) Set(o Override) error {
+	o.Domain = normDomain(o.Domain)
+	if o.Domain == "" {
+		return errors.New("override: empty domain")
+	}
+	if o.Action != ActionAllow && o.Action != ActionDeny {
+		return fmt.Errorf("override %s: action must be allow or deny", o.Domain)
+	}
+	if o.Created.IsZero() {
+		o.Created = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := AuditEntry{Time: o.Created, Op: "set", Actor: o.Actor, Reason: o.Reason, Override: o}
+	if s.db != nil {
+		if err := s.db.SaveOverride(o, e); err != nil {
+			return err
+		}
+	}
+	s.entries[o.Domain] = o
+	s.audit = append(s.audit, e)
+	s.publishLocked()
+	return s.saveLocked()
+}
+
+// Allow pins domain to allow. A ttl of zero never expires.
+func (s *OverrideStore) Allow(domain, actor, reason string, ttl time.Duration) error {
+	return s.Set(newOverride(domain, ActionAllow, actor, reason, ttl))
+}
+
+// Deny pins domain to deny. A ttl of zero never expires.
+func (s *OverrideStore) Deny(domain, actor, reason string, ttl time.Duration) error {
+	return s.Set(newOverride(domain, ActionDeny, actor, reason, ttl))
+}
+
+func newOverride(domain string, a Action, actor, reason string, ttl time.Duration) Override {
+	now := time.Now()
+	o := Override{Domain: domain, Action: a, Actor: actor, Reason: reason, Created: now}
+	if ttl > 0 {
+		o.Expires = now.Add(ttl)
+	}
+	return o
+}
+
+// Remove deletes the override for domain, if any.
+func (s *OverrideStore) Remove(domain, actor, reason string) error {
+	domain = normDomain(domain)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.entries[domain]
+	if !ok {
+		return nil
+	}
+	e := AuditEntry{Time: time.Now(), Op: "remove", Actor: actor, Reason: reason, Override: o}
+	if s.db != nil {
+		if err := s.db.DeleteOverride(domain, e); err != nil {
+			return err
+		}
+	}
+	delete(s.entries, domain)
+	s.audit = append(s.audit, e)
+	s.publishLocked()
+	return s.saveLocked()
+}
+
+// Lookup returns the active override for domain. Expired entries are ignored.
+func (s *OverrideStore) Lookup(domain string) (Override, bool) {
+	o, ok := (*s.active.Load())[normDomain(domain)]
+	if !ok || o.Expired(time.Now()) {
+		return Override{}, false
+	}
+	return o, true
+}
+
+// List returns all active overrides sorted by domain.
+func (s *OverrideStore) List() []Override {
+	now := time.Now()
+	active := *s.active.Load()
+	out := make([]Override, 0, len(active))
+	for _, o := range active {
+		if !o.Expired(now) {
+			out = append(out, o)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Domain < out[j].Domain })
+	return out
+}
+
+// Audit returns a copy of the audit trail, oldest first.
+func (s *OverrideStore) Audit() []AuditEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]AuditEntry(nil), s.audit...)
+}
+
+// Export writes the active overrides and the full audit trail as JSON.
+func (s *OverrideStore) Export(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(overrideFile{Version: overridesFormat, Overrides: s.List(), Audit: s.Audit()})
+}
+
+func (s *OverrideStore) publishLocked() {
+	m := maps.Clone(s.entries)
+	s.active.Store(&m)
+}
+
+func (s *OverrideStore) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+	f := overrideFile{Version: overridesFormat, Overrides: make([]Override, 0, len(s.entries)), Audit: s.audit}
+	for _, o := range s.entries {
+		f.Overrides = append(f.Overrides, o)
+	}
+	sort.Slice(f.Overrides, func(i, j int) bool { return f.Overrides[i].Domain < f.Overrides[j].Domain })
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, b, 0o644)
+}
+
+// writeFileAtomic writes via a temp file + rename so readers never observe
+// a half-written file.
+func writeFileAtomic(path string, b []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return rename(tmp.Name(), path)
+}
+
+// --- package-level store used by IsLegitEmail ---
+
+// UseOverrides installs s as the override store consulted before any other
+// check. Overrides bypass the verdict cache so they take effect immediately.
+// Passing nil disables overrides.
+//
+// Deprecated: Build a guard with New(WithOverrides(s)) and install it with
+// SetDefaultGuard.
+func UseOverrides(s *OverrideStore) {
+	defaultGuard.Load().overrides.Store(s)
+}
@@ -0,0 +1,107 @@
+package emailguard
+
+import (
+	"context"
+	"fmt"
+)
+
+// FlagParked marks a domain parked at a parking or for-sale service, under
+// WithParkedDomains. Parked domains often answer MX with a catch-all that
+// nobody reads.
+const FlagParked = "parked"
+
+// defaultParkingHosts are domain parking and for-sale services, matched
+// against a domain's nameservers and MX hosts.
+var defaultParkingHosts = []string{
+	"sedoparking.com", "bodis.com", "parkingcrew.net", "above.com",
+	"parklogic.com", "dan.com", "afternic.com", "hugedomains.com",
+	"undeveloped.com", "namebrightdns.com", "uniregistrymarket.link",
+	"fabulous.com", "domainmarket.com", "parkingspa.com",
+}
+
+// WithParkedDomains checks whether a domain's nameservers or MX hosts
+// belong to a parking or for-sale service (see WithParkingHosts).
+// ActionDeny rejects parked domains with ReasonParked in the heuristics
+// stage; ActionNone only adds FlagParked, which raises Score. It costs an
+// NS lookup per domain.
+func WithParkedDomains(a Action) Option {
+	return func(c *config) error {
+		if a != ActionNone && a != ActionDeny {
+			return fmt.Errorf("WithParkedDomains: action must be none or deny, got %d", a)
+		}
+		c.parked, c.parkedAction = true, a
+		return nil
+	}
+}
+
+// WithParkingHosts adds parking services to the built-in set, by
+// nameserver or MX host, or a domain all of whose hosts count.
+func WithParkingHosts(hosts ...string) Option {
+	return func(c *config) error {
+		for _, h := range hosts {
+			if err := checkHostname(normDomain(h)); err != nil {
+				return fmt.Errorf("WithParkingHosts: %w", err)
+			}
+			c.parkingHosts = append(c.parkingHosts, normDomain(h))
+		}
+		return nil
+	}
+}
+
+// evalParked rejects parked domains under WithParkedDomains(ActionDeny).
+func (g *Guard) evalParked(ctx context.Context, domain string) (Finding, error) {
+	if !g.cfg.parked || g.cfg.parkedAction != ActionDeny {
+		return noOpinion("parked check off"), nil
+	}
+	ind, err := g.parkedBy(ctx, domain)
+	if err != nil {
+		return noOpinion("NS lookup failed"), err
+	}
+	if ind == "" {
+		return noOpinion("not parked"), nil
+	}
+	return Finding{ActionDeny, ReasonParked, ind, "parked domain (" + ind + ")"}, nil
+}
+
+// flagParked adds FlagParked to an allowed verdict of a parked domain.
+func (g *Guard) flagParked(ctx context.Context, v *Verdict) {
+	if ind, err := g.parkedBy(ctx, v.Domain); err == nil && ind != "" {
+		v.Flags = append(v.Flags, FlagParked)
+	}
+}
+
+// parkedBy returns the nameserver or MX host that shows domain is parked,
+// or "" if none does. A subdomain without nameservers of its own is
+// judged by its registrable domain's.
+func (g *Guard) parkedBy(ctx context.Context, domain string) (string, error) {
+	mx, err := g.lookupMX(ctx, domain)
+	if err != nil {
+		return "", err
+	}
+	for _, h := range mx {
+		if g.parkingHost(normDomain(h)) {
+			return "mx " + normDomain(h), nil
+		}
+	}
+	ns, err := g.lookupNS(ctx, domain)
+	if err != nil {
+		return "", err
+	}
+	if reg, rerr := registrableDomain(domain); len(ns) == 0 && rerr == nil && reg != domain {
+		if ns, err = g.lookupNS(ctx, reg); err != nil {
+			return "", err
+		}
+	}
+	for _, h := range ns {
+		if g.parkingHost(h) {
+			return "ns " + h, nil
+		}
+	}
+	return "", nil
+}
+
+// parkingHost reports whether host is, or is under, a parking service.
+func (g *Guard) parkingHost(host string) bool {
+	_, ok := SubdomainPolicy{}.match(g.parking, host, true)
+	return ok
+}
@@ -0,0 +1,386 @@
+package emailguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Stage is one step of the check pipeline. Stages run in precedence order
+// and the first one with an opinion decides. Eval returns a Finding with
+// ActionNone to defer to later stages, and a non-nil error when it could not
+// reach a conclusion (e.g. DNS timeout), which makes the check inconclusive.
+type Stage interface {
+	Name() string
+	Eval(ctx context.Context, g *Guard, domain string) (Finding, error)
+}
+
+// Finding is a stage's opinion on a domain.
+type Finding struct {
+	Action Action // ActionNone: no opinion
+	Reason Reason
+	Match  string // list entry, MX host or rule that triggered the decision
+	Detail string // short human-readable explanation
+}
+
+// NewStage adapts fn to the Stage interface.
+func NewStage(name string, fn func(ctx context.Context, g *Guard, domain string) (Finding, error)) Stage {
+	return funcStage{name: name, fn: fn}
+}
+
+type funcStage struct {
+	name string
+	fn   func(ctx context.Context, g *Guard, domain string) (Finding, error)
+}
+
+func (s funcStage) Name() string { return s.name }
+
+func (s funcStage) Eval(ctx context.Context, g *Guard, domain string) (Finding, error) {
+	return s.fn(ctx, g, domain)
+}
+
+// methodStage adapts a built-in Guard method.
+func methodStage(name string, m func(*Guard, context.Context, string) (Finding, error)) Stage {
+	return NewStage(name, func(ctx context.Context, g *Guard, domain string) (Finding, error) {
+		return m(g, ctx, domain)
+	})
+}
+
+// pipelineStage is a Stage plus how the runner treats it.
+type pipelineStage struct {
+	Stage
+	// cached stages are static enough to share the verdict cache; uncached
+	// stages (operator/tenant decisions) run on every call so changes take
+	// effect immediately. Cached stages must follow uncached ones.
+	cached bool
+	// adjacent parallel stages run concurrently; their findings are still
+	// applied in order
+	parallel bool
+	timeout  time.Duration // zero: bounded by the caller's context only
+}
+
+// defaultPipeline is the precedence ladder.
+func defaultPipeline() []pipelineStage {
+	return []pipelineStage{
+		{Stage: methodStage(LayerOverride, (*Guard).evalOverride)},
+		{Stage: methodStage(LayerTenant, (*Guard).evalTenant)},
+		{Stage: methodStage(LayerTLD, (*Guard).evalTLD), cached: true},
+		{Stage: methodStage(LayerAllowlist, (*Guard).evalAllowlist), cached: true},
+		{Stage: methodStage(LayerBlocklist, (*Guard).evalBlocklist), cached: true},
+		// these wait on DNS; the MX lookup is shared between the last two
+		{Stage: methodStage(LayerReputation, (*Guard).evalReputation), cached: true, parallel: true},
+		{Stage: methodStage(LayerFingerprint, (*Guard).evalFingerprint), cached: true, parallel: true},
+		{Stage: methodStage(LayerHeuristics, (*Guard).evalHeuristics), cached: true, parallel: true},
+	}
+}
+
+// WithStageTimeout bounds a single pipeline stage, e.g. to give up on slow
+// NS lookups in the fingerprint stage while leaving the heuristics alone.
+// A stage that times out makes the check inconclusive.
+func WithStageTimeout(stage string, d time.Duration) Option {
+	return func(c *config) error {
+		if d <= 0 {
+			return fmt.Errorf("WithStageTimeout: %s: must be positive, got %s", stage, d)
+		}
+		if c.stageTimeouts == nil {
+			c.stageTimeouts = make(map[string]time.Duration)
+		}
+		c.stageTimeouts[stage] = d
+		return nil
+	}
+}
+
+// WithPipeline replaces the default pipeline with stages, run in the order
+// given. Start from DefaultStages to reorder or extend the built-in ladder,
+// or assemble the individual checks (SyntaxCheck, MXCheck, ...). A domain
+// no stage has an opinion on is allowed.
+//
+// Built-in stages keep their caching; other stages run on every check, as
+// does everything ahead of them, so put custom stages first only when they
+// must see every call.
+func WithPipeline(stages ...Stage) Option {
+	return func(c *config) error {
+		if len(stages) == 0 {
+			return errors.New("WithPipeline: no stages")
+		}
+		seen := make(map[string]bool)
+		for _, s := range stages {
+			if s == nil {
+				return errors.New("WithPipeline: nil stage")
+			}
+			if seen[s.Name()] {
+				return fmt.Errorf("WithPipeline: duplicate stage %q", s.Name())
+			}
+			seen[s.Name()] = true
+		}
+		c.stages = slices.Clone(stages)
+		if c.fallback == ActionNone {
+			c.fallback = ActionAllow
+		}
+		return nil
+	}
+}
+
+// WithoutStages drops stages from the pipeline by name, e.g.
+// WithoutStages(emailguard.LayerFingerprint).
+func WithoutStages(names ...string) Option {
+	return func(c *config) error {
+		if c.skipStages == nil {
+			c.skipStages = make(map[string]bool)
+		}
+		for _, n := range names {
+			c.skipStages[n] = true
+		}
+		return nil
+	}
+}
+
+// cachedChecks are the individual checks whose results may be cached.
+var cachedChecks = map[string]bool{LayerSyntax: true, LayerMX: true, LayerMXKeywords: true, LayerDisposableMX: true}
+
+// customPipeline gives the stages of WithPipeline their runner flags.
+func customPipeline(stages []Stage) []pipelineStage {
+	defaults := defaultPipeline()
+	p := make([]pipelineStage, len(stages))
+	last := -1 // last uncached stage
+	for i, s := range stages {
+		p[i] = pipelineStage{Stage: s, cached: cachedChecks[s.Name()]}
+		if j := stageIndex(defaults, s.Name()); j >= 0 {
+			p[i].cached, p[i].parallel = defaults[j].cached, defaults[j].parallel
+		}
+		if !p[i].cached {
+			last = i
+		}
+	}
+	// the verdict cache is consulted before the first cached stage, so
+	// nothing cached may precede an uncached stage
+	for i := range last {
+		p[i].cached = false
+	}
+	return p
+}
+
+// buildPipeline applies the config to the default stages.
+func buildPipeline(cfg config) ([]pipelineStage, error) {
+	p := defaultPipeline()
+	if cfg.stages != nil {
+		p = customPipeline(cfg.stages)
+	}
+	for name := range cfg.skipStages {
+		i := stageIndex(p, name)
+		if i < 0 {
+			return nil, fmt.Errorf("WithoutStages: unknown stage %q", name)
+		}
+		p = slices.Delete(p, i, i+1)
+	}
+	p = slices.DeleteFunc(p, func(s pipelineStage) bool { return cfg.disabled[s.Name()] })
+	for name, d := range cfg.stageTimeouts {
+		i := stageIndex(p, name)
+		if i < 0 {
+			return nil, fmt.Errorf("WithStageTimeout: unknown stage %q", name)
+		}
+		p[i].timeout = d
+	}
+	return p, nil
+}
+
+func stageIndex(p []pipelineStage, name string) int {
+	for i, s := range p {
+		if s.Name() == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// --- runner ---
+
+// StageTrace records one stage's run for a traced check.
+type StageTrace struct {
+	Stage    string        `json:"stage"`
+	Action   Action        `json:"action"`
+	Reason   Reason        `json:"reason,omitempty"`
+	Detail   string        `json:"detail,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Cached   bool          `json:"cached,omitempty"`   // served from the verdict cache
+	Disabled bool          `json:"disabled,omitempty"` // skipped by a kill switch; see Guard.SetKillSwitch
+	Family   string        `json:"family,omitempty"`   // "ipv4" or "ipv6", for checks that connect out
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// Traced records every stage the check ran in Verdict.Trace.
+func Traced() CheckOption {
+	return func(o *checkOptions) { o.trace = true }
+}
+
+// evalStage runs s under its timeout.
+func (g *Guard) evalStage(ctx context.Context, s pipelineStage, domain string) (Finding, error) {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+	return s.Eval(ctx, g, domain)
+}
+
+type stageOutcome struct {
+	f   Finding
+	err error
+	dur time.Duration
+}
+
+// run walks the pipeline, stopping at the first opinion. The verdict cache
+// is consulted before the first cached stage. An inconclusive stage stops
+// the walk with a non-nil Err and is not cached, unless WithStaleIfError
+// allows serving the expired cached result instead. trace, if non-nil, collects
+// every stage that ran.
+func (g *Guard) run(ctx context.Context, domain string, trace *[]StageTrace) LayerResult {
+	p := g.pipeline
+	cacheChecked := false
+	useCache := checkOptsFrom(ctx).dns == nil // injected DNS: decide afresh
+	fm := checkOptsFrom(ctx).failure
+	if fm != nil && fm.res != nil {
+		if trace != nil {
+			r := fm.res
+			*trace = append(*trace, StageTrace{Stage: r.Layer, Reason: r.Reason, Detail: r.Detail, Error: r.Err.Error(), Cached: true})
+		}
+		return *fm.res
+	}
+	for i := 0; i < len(p); {
+		if p[i].cached && useCache && !cacheChecked {
+			cacheChecked = true
+			if r, hit := g.getVerdictCached(domain); hit {
+				if trace != nil {
+					*trace = append(*trace, StageTrace{Stage: r.Layer, Action: r.Action, Reason: r.Reason, Detail: r.Detail, Cached: true})
+				}
+				return r
+			}
+			if r, hit := g.cachedFailure(domain); hit {
+				if sr, ok := g.staleVerdict(domain, r.Err); ok {
+					return sr
+				}
+				if trace != nil {
+					*trace = append(*trace, StageTrace{Stage: r.Layer, Reason: r.Reason, Detail: r.Detail, Error: r.Err.Error(), Cached: true})
+				}
+				return r
+			}
+		}
+		if g.switchedOff(p[i].Name()) {
+			if trace != nil {
+				*trace = append(*trace, StageTrace{Stage: p[i].Name(), Detail: "switched off", Disabled: true})
+			}
+			i++
+			continue
+		}
+		j := i + 1
+		if p[i].parallel {
+			for j < len(p) && p[j].parallel && p[j].cached == p[i].cached && !g.switchedOff(p[j].Name()) {
+				j++
+			}
+		}
+		group := p[i:j]
+		outs := g.runGroup(ctx, group, domain)
+		for k, s := range group {
+			o, ran := outs[k], outs[k] != nil
+			if !ran {
+				break // cancelled after an earlier stage decided
+			}
+			if trace != nil {
+				t := StageTrace{Stage: s.Name(), Action: o.f.Action, Reason: o.f.Reason, Detail: o.f.Detail, Duration: o.dur}
+				if o.err != nil {
+					t.Error = o.err.Error()
+				}
+				*trace = append(*trace, t)
+			}
+			if o.err != nil {
+				if cacheChecked {
+					if r, ok := g.staleVerdict(domain, o.err); ok {
+						return r
+					}
+				}
+				r := LayerResult{Layer: s.Name(), Reason: ReasonTemporary, Detail: o.f.Detail, Err: fmt.Errorf("%s: %s: %w", s.Name(), o.f.Detail, o.err)}
+				if cacheChecked && ctx.Err() == nil { // not the caller giving up
+					g.cacheFailure(domain, r)
+				}
+				if fm != nil {
+					fm.res = &r
+				}
+				return r
+			}
+			if o.f.Action == ActionNone {
+				continue
+			}
+			r := layerResult(s.Name(), o.f)
+			if s.cached && cacheChecked {
+				r = g.setVerdictCached(domain, r)
+			}
+			return r
+		}
+		i = j
+	}
+	r := g.fallbackResult()
+	if cacheChecked {
+		r = g.setVerdictCached(domain, r)
+	}
+	return r
+}
+
+// runGroup evaluates stages, concurrently when there is more than one. The
+// result for stage k is nil when it was abandoned because an earlier stage
+// in the group already decided; every goroutine has finished on return.
+func (g *Guard) runGroup(ctx context.Context, group []pipelineStage, domain string) []*stageOutcome {
+	outs := make([]*stageOutcome, len(group))
+	if len(group) == 1 {
+		start := time.Now()
+		f, err := g.evalStage(ctx, group[0], domain)
+		outs[0] = &stageOutcome{f: f, err: err, dur: time.Since(start)}
+		return outs
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	type done struct {
+		k int
+		o *stageOutcome
+	}
+	ch := make(chan done, len(group))
+	var wg sync.WaitGroup
+	for k, s := range group {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			f, err := g.evalStage(ctx, s, domain)
+			ch <- done{k, &stageOutcome{f: f, err: err, dur: time.Since(start)}}
+		}()
+	}
+
+	got := make([]*stageOutcome, len(group))
+	for range group {
+		d := <-ch
+		got[d.k] = d.o
+		// settled once a prefix of stages has reported and ends in an
+		// opinion or an error
+		settled := false
+		for k := range group {
+			if got[k] == nil {
+				break
+			}
+			if got[k].err != nil || got[k].f.Action != ActionNone {
+				settled = true
+				copy(outs, got[:k+1])
+				break
+			}
+		}
+		if settled {
+			cancel()
+			wg.Wait()
+			return outs
+		}
+	}
+	wg.Wait()
+	return got
+}
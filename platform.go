@@ -0,0 +1,80 @@
+package emailguard
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FlagPlatformSubdomain marks an address at a subdomain a platform assigns
+// to its customers, such as tenant.onmicrosoft.com or user.github.io.
+const FlagPlatformSubdomain = "platform_subdomain"
+
+// defaultPlatformSuffixes are platforms handing out subdomains that can
+// receive mail. Private suffixes in the public suffix list count as well.
+var defaultPlatformSuffixes = []string{
+	"onmicrosoft.com", "github.io", "gitlab.io", "vercel.app", "netlify.app",
+	"herokuapp.com", "pages.dev", "workers.dev", "web.app", "firebaseapp.com",
+	"appspot.com", "azurewebsites.net", "onrender.com", "fly.dev",
+	"glitch.me", "repl.co", "wixsite.com", "blogspot.com",
+}
+
+// WithPlatformSuffixes adds platforms whose customer subdomains get
+// FlagPlatformSubdomain and ClassPlatform.
+func WithPlatformSuffixes(suffixes ...string) Option {
+	return func(c *config) error {
+		for _, s := range suffixes {
+			s = strings.TrimPrefix(normDomain(s), ".")
+			if err := checkHostname(s); err != nil {
+				return fmt.Errorf("WithPlatformSuffixes: %w", err)
+			}
+			c.platformSuffixes = append(c.platformSuffixes, s)
+		}
+		return nil
+	}
+}
+
+// WithPlatformSubdomains decides addresses at platform subdomains in the
+// TLD layer: ActionDeny rejects them with ReasonPlatform,
+// ActionAllow accepts them without MX checks. The default, ActionNone,
+// only flags and classifies them. Overrides and tenant policy still win.
+func WithPlatformSubdomains(a Action) Option {
+	return func(c *config) error {
+		if a < ActionNone || a > ActionDeny {
+			return fmt.Errorf("WithPlatformSubdomains: unknown action %d", a)
+		}
+		c.platformAction = a
+		return nil
+	}
+}
+
+// platformSuffix returns the platform domain is a customer subdomain of.
+// The platform's own domain does not count.
+func (g *Guard) platformSuffix(domain string) (string, bool) {
+	for d := domain; ; {
+		_, parent, ok := strings.Cut(d, ".")
+		if !ok {
+			break
+		}
+		if g.platforms.contains(parent) {
+			return parent, true
+		}
+		d = parent
+	}
+	suffix, icann := publicSuffix(domain)
+	if !icann && strings.Contains(suffix, ".") && suffix != domain {
+		return suffix, true
+	}
+	return "", false
+}
+
+// evalPlatform applies WithPlatformSubdomains.
+func (g *Guard) evalPlatform(domain string) (Finding, bool) {
+	if g.cfg.platformAction == ActionNone {
+		return Finding{}, false
+	}
+	s, ok := g.platformSuffix(domain)
+	if !ok {
+		return Finding{}, false
+	}
+	return Finding{g.cfg.platformAction, ReasonPlatform, s, fmt.Sprintf("subdomain of platform %s: %s", s, g.cfg.platformAction)}, true
+}
@@ -0,0 +1,170 @@
+package emailguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	defaultDNSConcurrency  = 64
+	defaultSMTPConcurrency = 16
+	poolWaitTimeout        = 5 * time.Second // queueing beyond this is inconclusive
+)
+
+// errPoolSaturated is returned when a slot could not be obtained in time.
+var errPoolSaturated = errors.New("concurrency limit reached")
+
+// pool bounds concurrent operations of one kind. Callers beyond the limit
+// queue instead of opening more sockets. The queue is fair by key (the
+// domain or host being looked up): freed slots go round-robin to the keys
+// with waiters, so a bulk job hammering one slow domain waits behind itself
+// rather than in front of everyone else.
+type pool struct {
+	name  string
+	limit int
+
+	mu      sync.Mutex
+	held    int
+	waiting int
+	queues  map[string][]*poolWaiter // by key, oldest first
+	keys    []string                 // keys with waiters, in round-robin order
+	next    int                      // index into keys of the next one served
+}
+
+type poolWaiter struct {
+	ready chan struct{} // closed once the waiter holds a slot
+}
+
+func newPool(name string, n int) *pool {
+	return &pool{name: name, limit: n, queues: make(map[string][]*poolWaiter)}
+}
+
+// acquire waits for a free slot on behalf of key. The returned release func
+// must be called exactly once. Waiting longer than poolWaitTimeout yields an
+// error wrapping ErrTemporary.
+func (p *pool) acquire(ctx context.Context, key string) (release func(), err error) {
+	p.mu.Lock()
+	if p.held < p.limit && p.waiting == 0 {
+		p.held++
+		p.mu.Unlock()
+		return p.release, nil
+	}
+	w := &poolWaiter{ready: make(chan struct{})}
+	if len(p.queues[key]) == 0 {
+		p.keys = append(p.keys, key)
+	}
+	p.queues[key] = append(p.queues[key], w)
+	p.waiting++
+	p.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, poolWaitTimeout)
+	defer cancel()
+	select {
+	case <-w.ready:
+		return p.release, nil
+	case <-ctx.Done():
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	select {
+	case <-w.ready:
+		// granted while timing out; pass the slot on
+		p.held--
+		p.grant()
+	default:
+		p.dequeue(key, w)
+	}
+	return nil, fmt.Errorf("%w: %s pool: %w", ErrTemporary, p.name, errPoolSaturated)
+}
+
+func (p *pool) release() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.held--
+	p.grant()
+}
+
+// grant hands free slots to waiters, one key at a time. p.mu is held.
+func (p *pool) grant() {
+	for p.held < p.limit && p.waiting > 0 {
+		i := p.next % len(p.keys)
+		key := p.keys[i]
+		q := p.queues[key]
+		w := q[0]
+		if len(q) == 1 {
+			delete(p.queues, key)
+			p.keys = slices.Delete(p.keys, i, i+1)
+			p.next = i // now the key after
+		} else {
+			p.queues[key] = q[1:]
+			p.next = i + 1
+		}
+		p.waiting--
+		p.held++
+		close(w.ready)
+	}
+}
+
+// dequeue removes a waiter that gave up. p.mu is held.
+func (p *pool) dequeue(key string, w *poolWaiter) {
+	q := p.queues[key]
+	j := slices.Index(q, w)
+	if j < 0 {
+		return
+	}
+	p.waiting--
+	if len(q) > 1 {
+		p.queues[key] = slices.Delete(q, j, j+1)
+		return
+	}
+	delete(p.queues, key)
+	i := slices.Index(p.keys, key)
+	p.keys = slices.Delete(p.keys, i, i+1)
+	if i < p.next {
+		p.next--
+	}
+}
+
+// inUse reports the number of slots currently held.
+func (p *pool) inUse() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.held
+}
+
+// queued reports the number of callers waiting for a slot.
+func (p *pool) queued() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.waiting
+}
+
+// WithDNSConcurrency bounds the number of DNS lookups a Guard runs at once,
+// regardless of how many callers are checking addresses.
+func WithDNSConcurrency(n int) Option {
+	return func(c *config) error {
+		if n <= 0 {
+			return fmt.Errorf("WithDNSConcurrency: must be positive, got %d", n)
+		}
+		c.dnsConcurrency = n
+		return nil
+	}
+}
+
+// WithSMTPConcurrency bounds the number of SMTP conversations a Guard holds
+// open at once. It is separate from the DNS pool so slow mail servers cannot
+// starve lookups.
+func WithSMTPConcurrency(n int) Option {
+	return func(c *config) error {
+		if n <= 0 {
+			return fmt.Errorf("WithSMTPConcurrency: must be positive, got %d", n)
+		}
+		c.smtpConcurrency = n
+		return nil
+	}
+}
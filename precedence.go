@@ -0,0 +1,172 @@
+package emailguard
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Precedence layers, highest first. The first layer with an opinion decides.
+const (
+	LayerOverride    = "override"
+	LayerTenant      = "tenant"
+	LayerTLD         = "tld"
+	LayerAllowlist   = "allowlist"
+	LayerBlocklist   = "blocklist"
+	LayerFingerprint = "fingerprint"
+	LayerHeuristics  = "heuristics"
+	LayerDefault     = "default" // no layer had an opinion; see Lenient
+)
+
+// LayerResult is how a single precedence layer judged a domain.
+type LayerResult struct {
+	Layer  string
+	Action Action // ActionNone if the layer had no opinion
+	Reason Reason `json:",omitempty"`
+	Match  string `json:",omitempty"` // entry that triggered the decision
+	Detail string
+	Err    error // set when the layer was inconclusive
+
+	// Evaluated is when the pipeline last fully evaluated the domain, for
+	// results that were cached.
+	Evaluated time.Time `json:",omitzero"`
+
+	// Stale is set when an expired result was served because evaluation
+	// failed; see WithStaleIfError.
+	Stale bool `json:"-"`
+}
+
+func layerResult(name string, f Finding) LayerResult {
+	return LayerResult{Layer: name, Action: f.Action, Reason: f.Reason, Match: f.Match, Detail: f.Detail}
+}
+
+// PrecedenceExplanation lists every layer's opinion on a domain and which
+// layer's decision won.
+type PrecedenceExplanation struct {
+	Domain   string
+	Layers   []LayerResult // all layers, highest precedence first
+	Decisive string        // layer whose decision won
+	Action   Action
+}
+
+// ExplainPrecedence evaluates every layer for domain (bypassing the verdict
+// cache) and reports which one decides the outcome.
+func (g *Guard) ExplainPrecedence(domain string) PrecedenceExplanation {
+	domain = internDomain(normDomain(domain))
+	ex := PrecedenceExplanation{Domain: domain, Layers: make([]LayerResult, 0, len(g.pipeline))}
+	for _, s := range g.pipeline {
+		f, err := g.evalStage(context.Background(), s, domain)
+		r := layerResult(s.Name(), f)
+		r.Err = err
+		ex.Layers = append(ex.Layers, r)
+		if ex.Decisive == "" && f.Action != ActionNone {
+			ex.Decisive, ex.Action = s.Name(), f.Action
+		}
+	}
+	if ex.Decisive == "" {
+		ex.Decisive, ex.Action = LayerDefault, g.fallbackResult().Action
+	}
+	return ex
+}
+
+// fallbackResult ends a walk in which no stage had an opinion. With the full
+// pipeline that cannot happen: the heuristics always decide.
+func (g *Guard) fallbackResult() LayerResult {
+	if g.cfg.fallback == ActionAllow {
+		return LayerResult{Layer: LayerDefault, Action: ActionAllow, Reason: ReasonDefaultPolicy, Detail: "no check objected"}
+	}
+	return LayerResult{Layer: LayerDefault, Action: ActionDeny, Reason: ReasonDefaultPolicy, Detail: "no check vouched for the domain"}
+}
+
+// decide runs the pipeline for domain; see (*Guard).run.
+func (g *Guard) decide(ctx context.Context, domain string) LayerResult {
+	return g.run(ctx, domain, nil)
+}
+
+// --- layers ---
+
+func (g *Guard) evalOverride(ctx context.Context, domain string) (Finding, error) {
+	s := g.overrides.Load()
+	if s == nil {
+		return noOpinion("no override store"), nil
+	}
+	o, ok := s.Lookup(domain)
+	if !ok {
+		return noOpinion("no override"), nil
+	}
+	return Finding{o.Action, ReasonOverride, o.Domain, fmt.Sprintf("%s by %s: %s", o.Action, o.Actor, o.Reason)}, nil
+}
+
+func (g *Guard) evalTenant(ctx context.Context, domain string) (Finding, error) {
+	if g.cfg.tenant == nil {
+		return noOpinion("no tenant policy"), nil
+	}
+	a := g.cfg.tenant.Decide(domain)
+	return Finding{a, ReasonTenantPolicy, "", "tenant policy: " + a.String()}, nil
+}
+
+func (g *Guard) evalAllowlist(ctx context.Context, domain string) (Finding, error) {
+	p := g.cfg.subdomains
+	if m, ok := p.match(g.allow.Load(), domain, p.AllowSubdomains); ok {
+		if isListRule(m) {
+			return Finding{ActionAllow, ReasonAllowlisted, m, "allowlisted by rule " + m}, nil
+		}
+		if m != domain {
+			return Finding{ActionAllow, ReasonAllowlisted, m, "subdomain of allowlisted " + m}, nil
+		}
+		return Finding{ActionAllow, ReasonAllowlisted, domain, "allowlisted provider"}, nil
+	}
+	return noOpinion("not allowlisted"), nil
+}
+
+func (g *Guard) evalBlocklist(ctx context.Context, domain string) (Finding, error) {
+	// ensure blocklist is loaded (no-op after first call)
+	set := g.blocklist.load()
+
+	p := g.cfg.subdomains
+	if m, ok := p.match(set, domain, p.BlockSubdomains); ok {
+		if isListRule(m) {
+			return Finding{ActionDeny, ReasonDisposable, m, "disposable by rule " + m}, nil
+		}
+		if m != domain {
+			return Finding{ActionDeny, ReasonDisposable, m, "subdomain of disposable " + m}, nil
+		}
+		return Finding{ActionDeny, ReasonDisposable, domain, "disposable domain " + domain}, nil
+	}
+	return noOpinion("not on blocklist"), nil
+}
+
+// evalHeuristics is the MX checks in a row, allowing a domain none of
+// them objects to. With the MX check switched off it neither looks MX up
+// itself nor claims ReasonValidMX.
+func (g *Guard) evalHeuristics(ctx context.Context, domain string) (Finding, error) {
+	for _, check := range []struct {
+		name string
+		eval func(*Guard, context.Context, string) (Finding, error)
+	}{
+		{LayerMX, (*Guard).evalMX}, {LayerMXKeywords, (*Guard).evalMXKeywords}, {LayerDisposableMX, (*Guard).evalDisposableMX},
+		{LayerParked, (*Guard).evalParked},
+	} {
+		if g.cfg.disabled[check.name] || g.switchedOff(check.name) {
+			continue
+		}
+		if f, err := check.eval(g, ctx, domain); err != nil || f.Action != ActionNone {
+			return f, err
+		}
+	}
+	if g.cfg.disabled[LayerMX] || g.switchedOff(LayerMX) {
+		return Finding{ActionAllow, ReasonDefaultPolicy, "", "no check objected; MX check off"}, nil
+	}
+	mxHosts, via, err := g.resolveMX(ctx, domain) // cached by now
+	if err != nil {
+		return noOpinion("MX lookup failed"), err
+	}
+	if len(mxHosts) == 0 {
+		return Finding{ActionAllow, ReasonDefaultPolicy, "", "no check objected; no MX hosts"}, nil
+	}
+	if via != "" {
+		return Finding{ActionAllow, ReasonValidMX, via, "MX of parent " + via + ": " + strings.Join(mxHosts, ", ")}, nil
+	}
+	return Finding{ActionAllow, ReasonValidMX, "", "MX " + strings.Join(mxHosts, ", ")}, nil
+}
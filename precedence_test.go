@@ -0,0 +1,126 @@
+package emailguard
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// mxResolver answers MX lookups from a map; every other name and record
+// type does not exist.
+type mxResolver map[string][]string
+
+func notFound(name string) error {
+	return &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func (r mxResolver) LookupMX(_ context.Context, name string) ([]*net.MX, error) {
+	hosts, ok := r[strings.TrimSuffix(name, ".")]
+	if !ok {
+		return nil, notFound(name)
+	}
+	mx := make([]*net.MX, len(hosts))
+	for i, h := range hosts {
+		mx[i] = &net.MX{Host: h + ".", Pref: uint16(10 * (i + 1))}
+	}
+	return mx, nil
+}
+
+func (r mxResolver) LookupNS(_ context.Context, name string) ([]*net.NS, error) {
+	return nil, notFound(name)
+}
+
+func (r mxResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	return nil, notFound(name)
+}
+
+func (r mxResolver) LookupNetIP(_ context.Context, _, host string) ([]netip.Addr, error) {
+	return nil, notFound(host)
+}
+
+// TestPrecedence checks every pair of the layer order override > tenant >
+// allowlist > blocklist > heuristics: for each, a domain on which both
+// layers have opposite opinions, and the higher one must decide.
+func TestPrecedence(t *testing.T) {
+	type layers struct {
+		override, tenant Action
+		allow, block, mx bool
+	}
+	tests := []struct {
+		domain   string
+		on       layers
+		decisive string
+		action   Action
+		outvoted string // the lower layer, which must have objected
+	}{
+		{"override-tenant.com", layers{override: ActionDeny, tenant: ActionAllow, mx: true}, LayerOverride, ActionDeny, LayerTenant},
+		{"override-allowlist.com", layers{override: ActionDeny, allow: true, mx: true}, LayerOverride, ActionDeny, LayerAllowlist},
+		{"override-blocklist.com", layers{override: ActionAllow, block: true, mx: true}, LayerOverride, ActionAllow, LayerBlocklist},
+		{"override-heuristics.com", layers{override: ActionDeny, mx: true}, LayerOverride, ActionDeny, LayerHeuristics},
+		{"tenant-allowlist.com", layers{tenant: ActionDeny, allow: true, mx: true}, LayerTenant, ActionDeny, LayerAllowlist},
+		{"tenant-blocklist.com", layers{tenant: ActionAllow, block: true, mx: true}, LayerTenant, ActionAllow, LayerBlocklist},
+		{"tenant-heuristics.com", layers{tenant: ActionAllow}, LayerTenant, ActionAllow, LayerHeuristics},
+		{"allowlist-blocklist.com", layers{allow: true, block: true, mx: true}, LayerAllowlist, ActionAllow, LayerBlocklist},
+		{"allowlist-heuristics.com", layers{allow: true}, LayerAllowlist, ActionAllow, LayerHeuristics},
+		{"blocklist-heuristics.com", layers{block: true, mx: true}, LayerBlocklist, ActionDeny, LayerHeuristics},
+	}
+
+	overrides, err := NewOverrideStore("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tenant := make(map[string]Action)
+	var allow, block strings.Builder
+	mx := make(mxResolver)
+	for _, tt := range tests {
+		if a := tt.on.override; a != ActionNone {
+			if err := overrides.Set(newOverride(tt.domain, a, "test", "precedence", 0)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		tenant[tt.domain] = tt.on.tenant
+		if tt.on.allow {
+			allow.WriteString(tt.domain + "\n")
+		}
+		if tt.on.block {
+			block.WriteString(tt.domain + "\n")
+		}
+		if tt.on.mx {
+			mx[tt.domain] = []string{"mx1.mailhost.net", "mx2.mailhost.net"}
+		}
+	}
+	fsys := fstest.MapFS{
+		"allow.conf":     {Data: []byte(allow.String())},
+		"blocklist.conf": {Data: []byte(block.String())},
+	}
+	g, err := New(
+		WithBlocklistFS(fsys, "blocklist.conf"),
+		WithAllowlistFS(fsys, "allow.conf"),
+		WithOverrides(overrides),
+		WithTenantPolicy(TenantPolicyFunc(func(d string) Action { return tenant[d] })),
+		WithResolver(mx),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.domain, func(t *testing.T) {
+			ex := g.ExplainPrecedence(tt.domain)
+			if ex.Decisive != tt.decisive || ex.Action != tt.action {
+				t.Errorf("decided by %s (%s), want %s (%s)", ex.Decisive, ex.Action, tt.decisive, tt.action)
+			}
+			for _, l := range ex.Layers {
+				if l.Err != nil {
+					t.Errorf("layer %s: %v", l.Layer, l.Err)
+				}
+				if l.Layer == tt.outvoted && (l.Action == ActionNone || l.Action == tt.action) {
+					t.Errorf("layer %s said %s; the test needs it to object", l.Layer, l.Action)
+				}
+			}
+		})
+	}
+}
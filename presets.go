@@ -0,0 +1,63 @@
+package emailguard
+
+import (
+	"fmt"
+	"time"
+)
+
+// Policy is a named preset bundling options for a common risk appetite.
+type Policy int
+
+const (
+	// Moderate is the default: MX required, disposable and masked domains
+	// rejected, provider fingerprints on.
+	Moderate Policy = iota
+	// Strict is Moderate plus trial-abuse sequence detection, SPF flags and
+	// daily re-evaluation of allowed domains.
+	Strict
+	// Lenient only consults overrides, policy rules, the allowlist and the
+	// blocklist. It does no DNS and allows everything not listed, for flows
+	// where a false reject costs more than a throwaway signup.
+	Lenient
+)
+
+func (p Policy) String() string {
+	switch p {
+	case Moderate:
+		return "moderate"
+	case Strict:
+		return "strict"
+	case Lenient:
+		return "lenient"
+	default:
+		return fmt.Sprintf("Policy(%d)", int(p))
+	}
+}
+
+// options returns the preset's options.
+func (p Policy) options() ([]Option, error) {
+	switch p {
+	case Moderate:
+		return nil, nil
+	case Strict:
+		return []Option{
+			WithSequenceDetection(defaultSequenceWindow, defaultSequenceThreshold),
+			WithSPFCheck(),
+			WithReevaluation(24 * time.Hour),
+		}, nil
+	case Lenient:
+		return []Option{WithoutMXLookup()}, nil
+	default:
+		return nil, fmt.Errorf("unknown policy %v", p)
+	}
+}
+
+// NewWithPolicy returns a Guard configured by the preset p. opts are applied
+// after the preset and override it.
+func NewWithPolicy(p Policy, opts ...Option) (*Guard, error) {
+	preset, err := p.options()
+	if err != nil {
+		return nil, fmt.Errorf("NewWithPolicy: %w", err)
+	}
+	return New(append(preset, opts...)...)
+}
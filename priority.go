@@ -0,0 +1,64 @@
+package emailguard
+
+import (
+	"context"
+	"fmt"
+)
+
+// Priority classes route a check's network work through separate pools.
+type Priority int
+
+const (
+	// PriorityRealtime is the default, for live signup checks.
+	PriorityRealtime Priority = iota
+	// PriorityBatch is for imports and re-validation jobs. Batch checks use
+	// their own, smaller DNS and SMTP pools, so they queue behind each other
+	// instead of raising the latency of realtime checks on the same Guard.
+	PriorityBatch
+)
+
+const (
+	defaultBatchDNSConcurrency  = 16
+	defaultBatchSMTPConcurrency = 4
+)
+
+func (p Priority) String() string {
+	if p == PriorityBatch {
+		return "batch"
+	}
+	return "realtime"
+}
+
+// AtPriority runs the check in priority class p.
+func AtPriority(p Priority) CheckOption {
+	return func(o *checkOptions) { o.priority = p }
+}
+
+// WithBatchConcurrency bounds the DNS lookups and SMTP conversations that
+// PriorityBatch checks may run at once. These pools are separate from the
+// realtime ones set by WithDNSConcurrency and WithSMTPConcurrency.
+func WithBatchConcurrency(dns, smtp int) Option {
+	return func(c *config) error {
+		if dns <= 0 || smtp <= 0 {
+			return fmt.Errorf("WithBatchConcurrency: must be positive, got %d, %d", dns, smtp)
+		}
+		c.batchDNSConcurrency, c.batchSMTPConcurrency = dns, smtp
+		return nil
+	}
+}
+
+// dnsPoolFor returns the DNS pool of the check's priority class.
+func (g *Guard) dnsPoolFor(ctx context.Context) *pool {
+	if checkOptsFrom(ctx).priority == PriorityBatch {
+		return g.batchDNSPool
+	}
+	return g.dnsPool
+}
+
+// smtpPoolFor returns the SMTP pool of the check's priority class.
+func (g *Guard) smtpPoolFor(ctx context.Context) *pool {
+	if checkOptsFrom(ctx).priority == PriorityBatch {
+		return g.batchSMTPPool
+	}
+	return g.smtpPool
+}
@@ -0,0 +1,119 @@
+package emailguard
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// minPrivacyKey is the shortest HMAC key accepted.
+const minPrivacyKey = 16
+
+// WithPrivacy turns on data minimization. The guard then never keeps a raw
+// address: Verdict.Email, the recheck queue, sequence detection, debug
+// output and everything handed to the store carry an HMAC-SHA256 of the
+// local part under key instead, e.g. "3f9c0a1b2d4e5f60@company.com".
+// Domain-level data is unchanged. Equal local parts hash equally, so
+// sequences and repeat signups are still detected; keep the key secret and
+// stable, or those links are lost. Use WithPrivacyKeyring to rotate keys.
+func WithPrivacy(key []byte) Option {
+	return func(c *config) error {
+		k, err := NewStaticKeyring("", map[string][]byte{"": key})
+		if err != nil {
+			return fmt.Errorf("WithPrivacy: %w", err)
+		}
+		c.keyring = k
+		return nil
+	}
+}
+
+// Pseudonymize returns the form of email a guard in privacy mode keeps:
+// the local part's HMAC under the current key, prefixed with the key ID
+// when it has one ("k2.3f9c0a1b2d4e5f60@company.com"). Use it to hash
+// identifiers in your own audit logs consistently with the guard.
+func (g *Guard) Pseudonymize(email string) (string, error) {
+	if g.cfg.keyring == nil {
+		return "", errors.New("emailguard: privacy mode is off")
+	}
+	local, domain, ok := splitEmail(email)
+	if !ok {
+		local = strings.TrimSpace(email)
+	}
+	p, err := g.hashLocal(local)
+	if err != nil || !ok {
+		return p, err
+	}
+	return p + "@" + domain, nil
+}
+
+// MatchPseudonym reports whether pseudonym was made from email, with
+// whichever keyring key it names. Pseudonyms made before a rotation keep
+// verifying while the keyring holds their key.
+func (g *Guard) MatchPseudonym(email, pseudonym string) (bool, error) {
+	if g.cfg.keyring == nil {
+		return false, errors.New("emailguard: privacy mode is off")
+	}
+	local, domain, ok := splitEmail(email)
+	if !ok {
+		local = strings.TrimSpace(email)
+	}
+	hash, pdomain, _ := strings.Cut(pseudonym, "@")
+	if pdomain != domain {
+		return false, nil
+	}
+	id, sum := "", hash
+	if i := strings.IndexByte(hash, '.'); i >= 0 {
+		id, sum = hash[:i], hash[i+1:]
+	}
+	key, err := g.cfg.keyring.Key(id)
+	if err != nil {
+		return false, err
+	}
+	return hmac.Equal([]byte(macHex(key, local)), []byte(sum)), nil
+}
+
+// hashLocal is the keyed pseudonym of a local part under the current key.
+func (g *Guard) hashLocal(local string) (string, error) {
+	id, key, err := g.cfg.keyring.Current()
+	if err != nil {
+		return "", err
+	}
+	if id == "" {
+		return macHex(key, local), nil
+	}
+	return id + "." + macHex(key, local), nil
+}
+
+// macHex hashes a local part. Local parts are compared case-insensitively,
+// as every major provider does.
+func macHex(key []byte, local string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strings.ToLower(local)))
+	return hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// redactEmail returns the form of an address the guard may keep: email
+// itself, or its pseudonym in privacy mode. domain is empty for malformed
+// addresses, which are hashed whole. If no key is available the local part
+// is dropped rather than kept in the clear.
+func (g *Guard) redactEmail(email, local, domain string) string {
+	if g.cfg.keyring == nil {
+		return email
+	}
+	if domain == "" {
+		local = strings.TrimSpace(email)
+	}
+	h, err := g.hashLocal(local)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "WARN: pseudonymize: %v\n", err)
+		h = "*"
+	}
+	if domain == "" {
+		return h
+	}
+	return h + "@" + domain
+}
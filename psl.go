@@ -0,0 +1,17 @@
+package emailguard
+
+import "strings"
+
+// fillSuffix records where v's domain sits in the public suffix list. A
+// private suffix is one registered by a platform for its users, such as
+// github.io, rather than delegated through ICANN; the "registrable domain"
+// under it belongs to one of the platform's customers.
+func fillSuffix(v *Verdict) {
+	suffix, icann := publicSuffix(v.Domain)
+	v.PublicSuffix = suffix
+	// unlisted TLDs also report !icann, but only as a single label
+	v.PrivateSuffix = !icann && strings.Contains(suffix, ".")
+	if rd, err := registrableDomain(v.Domain); err == nil {
+		v.RegistrableDomain = rd
+	}
+}
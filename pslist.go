@@ -0,0 +1,310 @@
+package emailguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// PublicSuffixURL is where RefreshPublicSuffixList fetches the list by
+// default.
+const PublicSuffixURL = "https://publicsuffix.org/list/public_suffix_list.dat"
+
+// minSuffixRules rejects truncated downloads and error pages; the list has
+// about 10,000 rules.
+const minSuffixRules = 1000
+
+// loadedSuffixes is the list installed at runtime, nil until one is.
+var loadedSuffixes atomic.Pointer[suffixList]
+
+// LoadPublicSuffixList replaces the public suffix list built into the
+// package with one read from r, in the publicsuffix.org text format, for
+// every guard in the process. Newly delegated TLDs and private suffixes
+// are then told apart correctly without a rebuild. Verdicts cached before
+// keep their suffix fields until they expire.
+func LoadPublicSuffixList(r io.Reader) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("public suffix list: %w", err)
+	}
+	l, err := parseSuffixList(string(b))
+	if err != nil {
+		return err
+	}
+	loadedSuffixes.Store(l)
+	return nil
+}
+
+// RegistrableDomain returns domain's public suffix plus one label, such as
+// tempmail.com.tr for mx1.mail.tempmail.com.tr, by the list in use: the
+// one built in or the one installed with LoadPublicSuffixList.
+func RegistrableDomain(domain string) (string, error) {
+	return registrableDomain(domain)
+}
+
+// RefreshPublicSuffixList downloads the public suffix list from url,
+// PublicSuffixURL if empty, and installs it with LoadPublicSuffixList. With
+// a cache path the download is kept there: the first refresh in a process
+// installs the cached copy before going to the network, so a restart during
+// an outage keeps the newer list, and an unchanged list is not downloaded
+// again. On error the list in use stays.
+func RefreshPublicSuffixList(ctx context.Context, url, cache string) error {
+	if url == "" {
+		url = PublicSuffixURL
+	}
+	var since time.Time
+	if cache != "" {
+		if fi, err := os.Stat(cache); err == nil {
+			if loadedSuffixes.Load() == nil {
+				if err := loadSuffixFile(cache); err != nil {
+					fmt.Fprintf(os.Stderr, "WARN: %s: %v\n", cache, err)
+				}
+			}
+			if loadedSuffixes.Load() != nil {
+				since = fi.ModTime()
+			}
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("public suffix list: %w", err)
+	}
+	if !since.IsZero() {
+		req.Header.Set("If-Modified-Since", since.UTC().Format(http.TimeFormat))
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("public suffix list: %w", err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		now := time.Now()
+		return os.Chtimes(cache, now, now)
+	case http.StatusOK:
+	default:
+		return fmt.Errorf("public suffix list: GET %s: %s", url, resp.Status)
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("public suffix list: %w", err)
+	}
+	l, err := parseSuffixList(string(b))
+	if err != nil {
+		return fmt.Errorf("%s: %w", url, err)
+	}
+	loadedSuffixes.Store(l)
+	if cache != "" {
+		return writeFileAtomic(cache, b, 0o644)
+	}
+	return nil
+}
+
+// RunPublicSuffixRefresh calls RefreshPublicSuffixList now and every
+// interval (a day if zero; publicsuffix.org asks for no more) until ctx is
+// done, logging failures. Run it in its own goroutine.
+func RunPublicSuffixRefresh(ctx context.Context, url, cache string, interval time.Duration) {
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		if err := RefreshPublicSuffixList(ctx, url, cache); err != nil && ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "WARN: %v\n", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func loadSuffixFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return LoadPublicSuffixList(f)
+}
+
+// suffixList is a parsed public suffix list, indexed by rule in ASCII
+// form without the "!" of exceptions; wildcard rules keep their "*.".
+type suffixList struct {
+	rules map[string]suffixRule
+}
+
+type suffixRule struct {
+	exception bool // a "!" rule: the suffix is its parent
+	icann     bool
+}
+
+func parseSuffixList(text string) (*suffixList, error) {
+	l := &suffixList{rules: make(map[string]suffixRule, 10000)}
+	icann, sections := false, 0
+	for line := range strings.Lines(text) {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.Contains(line, "===BEGIN ICANN DOMAINS==="):
+			icann = true
+			sections++
+		case strings.Contains(line, "===END ICANN DOMAINS==="):
+			icann = false
+		case line == "" || strings.HasPrefix(line, "//"):
+		default:
+			line, _, _ = strings.Cut(line, " ")
+			rule, exception := strings.CutPrefix(line, "!")
+			l.rules[asciiDomain(rule)] = suffixRule{exception, icann}
+		}
+	}
+	switch {
+	case sections == 0:
+		return nil, errors.New("public suffix list: no ICANN section; not a public suffix list")
+	case len(l.rules) < minSuffixRules:
+		return nil, fmt.Errorf("public suffix list: only %d rules; truncated?", len(l.rules))
+	}
+	return l, nil
+}
+
+// publicSuffix returns domain's public suffix and whether it comes from the
+// ICANN section of the list rather than the private one. The longest
+// matching rule wins; an unlisted TLD is its own suffix.
+func (l *suffixList) publicSuffix(domain string) (string, bool) {
+	for d := domain; ; {
+		_, parent, ok := strings.Cut(d, ".")
+		if r, listed := l.rules[d]; listed {
+			if r.exception {
+				return parent, r.icann
+			}
+			return d, r.icann
+		}
+		if !ok {
+			return d, false
+		}
+		if r, listed := l.rules["*."+parent]; listed {
+			return d, r.icann
+		}
+		d = parent
+	}
+}
+
+// effectiveTLDPlusOne returns domain's public suffix plus one label, with
+// the errors of golang.org/x/net/publicsuffix.
+func (l *suffixList) effectiveTLDPlusOne(domain string) (string, error) {
+	if strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") || strings.Contains(domain, "..") {
+		return "", fmt.Errorf("publicsuffix: empty label in domain %q", domain)
+	}
+	suffix, _ := l.publicSuffix(domain)
+	if len(domain) <= len(suffix) {
+		return "", fmt.Errorf("publicsuffix: cannot derive eTLD+1 for domain %q", domain)
+	}
+	i := len(domain) - len(suffix) - 1
+	if domain[i] != '.' {
+		return "", fmt.Errorf("publicsuffix: invalid public suffix %q for domain %q", suffix, domain)
+	}
+	return domain[1+strings.LastIndexByte(domain[:i], '.'):], nil
+}
+
+// asciiDomain converts the labels of an internationalized domain to their
+// xn-- form, as the guard sees them.
+func asciiDomain(d string) string {
+	labels := strings.Split(strings.ToLower(d), ".")
+	for i, l := range labels {
+		if strings.IndexFunc(l, func(r rune) bool { return r >= 0x80 }) >= 0 {
+			labels[i] = "xn--" + punycode(l)
+		}
+	}
+	return strings.Join(labels, ".")
+}
+
+// Punycode parameters (RFC 3492 section 5).
+const (
+	pcBase        = 36
+	pcTMin        = 1
+	pcTMax        = 26
+	pcSkew        = 38
+	pcDamp        = 700
+	pcInitialBias = 72
+	pcInitialN    = 128
+)
+
+// punycode encodes a label (RFC 3492 section 6.3).
+func punycode(s string) string {
+	runes := []rune(s)
+	var out []byte
+	for _, r := range runes {
+		if r < 0x80 {
+			out = append(out, byte(r))
+		}
+	}
+	b := len(out)
+	if b > 0 {
+		out = append(out, '-')
+	}
+	n, delta, bias := rune(pcInitialN), 0, pcInitialBias
+	for h := b; h < len(runes); {
+		m := rune(math.MaxInt32)
+		for _, r := range runes {
+			if r >= n && r < m {
+				m = r
+			}
+		}
+		delta += int(m-n) * (h + 1)
+		n = m
+		for _, r := range runes {
+			if r < n {
+				delta++
+			}
+			if r != n {
+				continue
+			}
+			q := delta
+			for k := pcBase; ; k += pcBase {
+				t := min(max(k-bias, pcTMin), pcTMax)
+				if q < t {
+					break
+				}
+				out = append(out, punycodeDigit(t+(q-t)%(pcBase-t)))
+				q = (q - t) / (pcBase - t)
+			}
+			out = append(out, punycodeDigit(q))
+			bias = punycodeAdapt(delta, h+1, h == b)
+			delta = 0
+			h++
+		}
+		delta++
+		n++
+	}
+	return string(out)
+}
+
+func punycodeAdapt(delta, points int, first bool) int {
+	if first {
+		delta /= pcDamp
+	} else {
+		delta /= 2
+	}
+	delta += delta / points
+	k := 0
+	for delta > (pcBase-pcTMin)*pcTMax/2 {
+		delta /= pcBase - pcTMin
+		k += pcBase
+	}
+	return k + (pcBase-pcTMin+1)*delta/(delta+pcSkew)
+}
+
+func punycodeDigit(d int) byte {
+	if d < 26 {
+		return byte('a' + d)
+	}
+	return byte('0' + d - 26)
+}
@@ -0,0 +1,23 @@
+//go:build !emailguard_stdlib
+
+package emailguard
+
+import "golang.org/x/net/publicsuffix"
+
+// publicSuffix returns domain's public suffix and whether it comes from the
+// ICANN section of the list rather than the private one. A list installed
+// with LoadPublicSuffixList wins over golang.org/x/net's.
+func publicSuffix(domain string) (string, bool) {
+	if l := loadedSuffixes.Load(); l != nil {
+		return l.publicSuffix(domain)
+	}
+	return publicsuffix.PublicSuffix(domain)
+}
+
+// effectiveTLDPlusOne returns domain's public suffix plus one label.
+func effectiveTLDPlusOne(domain string) (string, error) {
+	if l := loadedSuffixes.Load(); l != nil {
+		return l.effectiveTLDPlusOne(domain)
+	}
+	return publicsuffix.EffectiveTLDPlusOne(domain)
+}
@@ -0,0 +1,41 @@
+//go:build emailguard_stdlib
+
+package emailguard
+
+import (
+	_ "embed"
+	"sync"
+)
+
+// publicSuffixList is a copy of https://publicsuffix.org/list/ for builds
+// without golang.org/x/net; it is as current as the release, unless
+// RefreshPublicSuffixList replaces it.
+//
+//go:embed public_suffix_list.dat
+var publicSuffixList string
+
+var embeddedSuffixes = sync.OnceValue(func() *suffixList {
+	l, err := parseSuffixList(publicSuffixList)
+	if err != nil {
+		panic(err) // the embedded copy is checked in
+	}
+	return l
+})
+
+func suffixes() *suffixList {
+	if l := loadedSuffixes.Load(); l != nil {
+		return l
+	}
+	return embeddedSuffixes()
+}
+
+// publicSuffix returns domain's public suffix and whether it comes from the
+// ICANN section of the list rather than the private one.
+func publicSuffix(domain string) (string, bool) {
+	return suffixes().publicSuffix(domain)
+}
+
+// effectiveTLDPlusOne returns domain's public suffix plus one label.
+func effectiveTLDPlusOne(domain string) (string, error) {
+	return suffixes().effectiveTLDPlusOne(domain)
+}
@@ -0,0 +1,90 @@
+package emailguard
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// FlagQuarantined marks an address on a domain first seen within the
+// WithQuarantine period, for routing to extra verification.
+const FlagQuarantined = "quarantined"
+
+// FirstSeenStore is a Store that remembers when each domain was first
+// checked. SQLStore implements it.
+type FirstSeenStore interface {
+	// FirstSeen records at as domain's first sighting unless there is one
+	// already, and returns the first sighting.
+	FirstSeen(domain string, at time.Time) (time.Time, error)
+}
+
+// WithQuarantine flags addresses with FlagQuarantined for period after the
+// guard first sees their registrable domain. A new subdomain of an
+// established domain is not quarantined. Sightings persist in the store
+// when it implements FirstSeenStore and are kept in memory otherwise.
+func WithQuarantine(period time.Duration) Option {
+	return func(c *config) error {
+		if period <= 0 {
+			return fmt.Errorf("WithQuarantine: period must be positive, got %s", period)
+		}
+		c.quarantine = period
+		return nil
+	}
+}
+
+// firstSeenSet caches first sightings in front of the store; they never
+// change once recorded.
+type firstSeenSet struct {
+	mu sync.Mutex
+	m  map[string]time.Time
+}
+
+// firstSeen returns domain's first sighting, recording now if it is new.
+func (g *Guard) firstSeen(domain string, now time.Time) (time.Time, error) {
+	s := &g.seen
+	s.mu.Lock()
+	if t, ok := s.m[domain]; ok {
+		s.mu.Unlock()
+		return t, nil
+	}
+	s.mu.Unlock()
+
+	t := now
+	if fs, ok := g.cfg.store.(FirstSeenStore); ok {
+		var err error
+		if t, err = fs.FirstSeen(domain, now); err != nil {
+			return time.Time{}, err
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.m == nil {
+		s.m = make(map[string]time.Time)
+	}
+	if prev, ok := s.m[domain]; ok && prev.Before(t) {
+		t = prev
+	}
+	s.m[domain] = t
+	return t, nil
+}
+
+// quarantined records the sighting of domain and its registrable domain
+// and reports whether the latter is still in quarantine.
+func (g *Guard) quarantined(domain string) bool {
+	if g.cfg.quarantine == 0 {
+		return false
+	}
+	now := time.Now()
+	first, err := g.firstSeen(domain, now)
+	if rd, rerr := registrableDomain(domain); err == nil && rerr == nil && rd != domain {
+		var rfirst time.Time
+		if rfirst, err = g.firstSeen(rd, now); err == nil && rfirst.Before(first) {
+			first = rfirst
+		}
+	}
+	if err != nil {
+		warnStore("first seen", err)
+		return false
+	}
+	return now.Sub(first) < g.cfg.quarantine
+}
@@ -0,0 +1,153 @@
+package emailguard
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"slices"
+	"time"
+)
+
+// Reverse DNS flags, under WithReverseDNS.
+const (
+	FlagMXNoPTR       = "mx_no_ptr"       // an address of the primary MX host has no PTR record
+	FlagMXPTRMismatch = "mx_ptr_mismatch" // its PTR names neither the MX host's domain nor resolve back to it
+)
+
+// AddrResolver is a Resolver that can look up PTR records, which
+// WithReverseDNS needs. *net.Resolver and DoHResolver implement it.
+type AddrResolver interface {
+	// LookupAddr returns the names addr maps to.
+	LookupAddr(ctx context.Context, addr string) ([]string, error)
+}
+
+// errNoPTR is returned by lookupAddr for resolvers without PTR lookups.
+var errNoPTR = errors.New("resolver cannot look up PTR records")
+
+// PTRInfo is what WithReverseDNS found for one MX address.
+type PTRInfo struct {
+	Host  string     `json:"host"` // the MX host
+	IP    netip.Addr `json:"ip"`
+	Names []string   `json:"names,omitempty"` // the PTR records
+	Match bool       `json:"match"`           // a name is under the MX host's domain or resolves back to IP
+}
+
+// WithReverseDNS looks up the PTR records of the primary MX host's
+// addresses for allowed domains. Mail servers run properly have one,
+// naming the host's domain or at least resolving back to the address, as
+// receiving servers insist on; servers set up in a hurry often do not. An
+// address without a PTR record adds FlagMXNoPTR, one whose PTR matches
+// neither adds FlagMXPTRMismatch; both raise Score and the outcome is
+// unaffected. The records are reported in Verdict.PTR. It needs a resolver
+// implementing AddrResolver and costs up to two lookups per address.
+func WithReverseDNS() Option {
+	return func(c *config) error {
+		c.reverseDNS = true
+		return nil
+	}
+}
+
+// checkPTR adds the reverse DNS of v's primary MX host.
+func (g *Guard) checkPTR(ctx context.Context, v *Verdict) {
+	hosts, ok := g.knownMX(ctx, v.MXDomain)
+	if !ok || len(hosts) == 0 {
+		return
+	}
+	host := normDomain(hosts[0])
+	addrs, err := g.lookupAddrs(ctx, []string{host})
+	if err != nil {
+		return
+	}
+	var missing, mismatch bool
+	for _, ip := range addrs {
+		names, err := g.lookupPTR(ctx, ip)
+		if err != nil {
+			continue
+		}
+		info := PTRInfo{Host: host, IP: ip, Names: names}
+		info.Match = g.ptrMatches(ctx, host, ip, names)
+		missing = missing || len(names) == 0
+		mismatch = mismatch || len(names) > 0 && !info.Match
+		v.PTR = append(v.PTR, info)
+	}
+	if missing {
+		v.Flags = append(v.Flags, FlagMXNoPTR)
+	}
+	if mismatch {
+		v.Flags = append(v.Flags, FlagMXPTRMismatch)
+	}
+}
+
+// ptrMatches reports whether one of names, the PTR records of host's
+// address ip, is under host's registrable domain or resolves back to ip.
+// Large providers name their addresses under a domain of their own, such
+// as 1e100.net for Google, but keep them forward-confirmed.
+func (g *Guard) ptrMatches(ctx context.Context, host string, ip netip.Addr, names []string) bool {
+	reg, err := registrableDomain(host)
+	if err != nil {
+		reg = host
+	}
+	for _, n := range names {
+		if hostUnder(n, reg) {
+			return true
+		}
+	}
+	for _, n := range names {
+		if back, err := g.lookupAddrs(ctx, []string{n}); err == nil && slices.Contains(back, ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupPTR returns the names ip maps to, normalized.
+func (g *Guard) lookupPTR(ctx context.Context, ip netip.Addr) ([]string, error) {
+	key := ip.String()
+	if v, ok := g.ptrCache.Load(key); ok {
+		if e := v.(dnsCacheEntry); time.Now().Before(e.exp) {
+			return e.hosts, nil
+		}
+	}
+	if g.cfg.airGapped {
+		return nil, ErrAirGapped
+	}
+	if err := g.spend(ctx); err != nil {
+		return nil, err
+	}
+	release, err := g.dnsPoolFor(ctx).acquire(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	ctx, cancel := g.dnsContext(ctx)
+	defer cancel()
+	names, err := lookupAddr(ctx, g.resolver(), key)
+	if err = classifyDNSError(err); err != nil {
+		return nil, err
+	}
+	for i, n := range names {
+		names[i] = normDomain(n)
+	}
+	g.ptrCache.Store(key, dnsCacheEntry{hosts: names, exp: time.Now().Add(g.dnsTTL(len(names)))})
+	return names, nil
+}
+
+// lookupAddr looks addr up with r if it is an AddrResolver.
+func lookupAddr(ctx context.Context, r Resolver, addr string) ([]string, error) {
+	ar, ok := r.(AddrResolver)
+	if !ok {
+		return nil, errNoPTR
+	}
+	return ar.LookupAddr(ctx, addr)
+}
+
+// canLookupAddr reports whether the configured resolver, or the system
+// one, looks up PTR records.
+func (c *config) canLookupAddr() bool {
+	if c.resolver == nil {
+		return true
+	}
+	_, ok := c.resolver.(AddrResolver)
+	return ok
+}
@@ -0,0 +1,34 @@
+package emailguard
+
+// Reason is a stable, machine-readable code for why a verdict came out the
+// way it did, suitable for mapping to user-facing messages.
+type Reason string
+
+const (
+	ReasonSyntax         Reason = "syntax"          // malformed address
+	ReasonSubaddressed   Reason = "subaddressed"    // WithSubaddressPolicy(SubaddressReject)
+	ReasonOverride       Reason = "override"        // operator override
+	ReasonTenantPolicy   Reason = "tenant_policy"   // WithTenantPolicy
+	ReasonTLDPolicy      Reason = "tld_policy"      // WithTLDRules
+	ReasonPlatform       Reason = "platform"        // WithPlatformSubdomains
+	ReasonAllowlisted    Reason = "allowlisted"     // known mailbox provider
+	ReasonDisposable     Reason = "disposable"      // on the disposable blocklist
+	ReasonTempMailInfra  Reason = "temp_mail_infra" // provider fingerprint match
+	ReasonNoMX           Reason = "no_mx"           // domain has no MX records
+	ReasonInsufficientMX Reason = "insufficient_mx" // fewer MX hosts than WithMinMX
+	ReasonMaskedMX       Reason = "masked_mx"       // MX host looks like a masking/forwarding service
+	ReasonDisposableMX   Reason = "disposable_mx"   // MX host belongs to a disposable domain
+	ReasonBlockedMX      Reason = "blocked_mx"      // MX host is on WithMXBlocklist
+	ReasonParked         Reason = "parked"          // parked at a parking service; WithParkedDomains
+	ReasonListedDomain   Reason = "listed_domain"   // on a domain blocklist; WithDomainBlocklists
+	ReasonValidMX        Reason = "valid_mx"        // passed the MX heuristics
+	ReasonTemporary      Reason = "temporary"       // could not verify right now
+	ReasonHook           Reason = "hook"            // WithPreHook or WithPostHook
+	ReasonDefaultPolicy  Reason = "default_policy"  // no check had an opinion
+	ReasonShadow         Reason = "shadow"          // shadow mode allowed what enforcement would not
+)
+
+// noOpinion is a Finding that defers to lower stages.
+func noOpinion(detail string) Finding {
+	return Finding{Detail: detail}
+}
@@ -0,0 +1,137 @@
+package emailguard
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+)
+
+const (
+	recheckTick     = 5 * time.Second  // how often the scheduler scans for due work
+	maxRecheckDelay = 30 * time.Minute // backoff ceiling
+	maxRechecks     = 8                // give up after this many inconclusive attempts
+)
+
+// RecheckFunc is called when a domain that was previously inconclusive gets
+// a final verdict from the re-check scheduler (or when the scheduler gives up,
+// in which case the verdict is still OutcomeRetryLater).
+type RecheckFunc func(v Verdict)
+
+// WithRecheckCallback registers fn to be fired by RunRechecks when a pending
+// domain reaches a final decision.
+func WithRecheckCallback(fn RecheckFunc) Option {
+	return func(c *config) error {
+		c.onRecheck = fn
+		return nil
+	}
+}
+
+type pendingCheck struct {
+	email    string   // most recent address seen for the domain
+	corrID   string   // and the correlation ID it came with,
+	campaign string   // campaign
+	flags    []string // and flags about its local part, which is not kept
+	due      time.Time
+	attempts int
+}
+
+// localFlags are the flags annotate derives from the local part.
+var localFlags = []string{FlagSequentialLocalPart, FlagRoleAccount, FlagSubaddressed}
+
+func keptFlags(v Verdict) []string {
+	var out []string
+	for _, f := range v.Flags {
+		if slices.Contains(localFlags, f) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// scheduleRecheck queues v's domain for background re-evaluation.
+func (g *Guard) scheduleRecheck(v Verdict) {
+	g.recheckMu.Lock()
+	defer g.recheckMu.Unlock()
+	if p, ok := g.pending[v.Domain]; ok {
+		p.email, p.corrID, p.campaign, p.flags = v.Email, v.CorrelationID, v.Campaign, keptFlags(v)
+		return
+	}
+	g.pending[v.Domain] = &pendingCheck{email: v.Email, corrID: v.CorrelationID, campaign: v.Campaign, flags: keptFlags(v), due: time.Now().Add(v.RetryAfter)}
+}
+
+// PendingRechecks returns the domains currently queued for re-evaluation.
+func (g *Guard) PendingRechecks() []string {
+	g.recheckMu.Lock()
+	out := make([]string, 0, len(g.pending))
+	for d := range g.pending {
+		out = append(out, d)
+	}
+	g.recheckMu.Unlock()
+	sort.Strings(out)
+	return out
+}
+
+// RunRechecks re-evaluates domains whose last verdict was OutcomeRetryLater,
+// backing off exponentially between attempts. Final verdicts are completed
+// as Validate completes them, land in the verdict cache and decision
+// history, and are reported to the WithRecheckCallback function. It
+// blocks until ctx is done; run it in its own goroutine.
+func (g *Guard) RunRechecks(ctx context.Context) {
+	t := time.NewTicker(recheckTick)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			g.recheckDue(ctx, now)
+		}
+	}
+}
+
+func (g *Guard) recheckDue(ctx context.Context, now time.Time) {
+	g.recheckMu.Lock()
+	due := make(map[string]pendingCheck)
+	for d, p := range g.pending {
+		if !now.Before(p.due) {
+			due[d] = *p
+		}
+	}
+	g.recheckMu.Unlock()
+
+	for domain, p := range due {
+		v := Verdict{Email: p.email, Domain: domain, CorrelationID: p.corrID, Campaign: p.campaign, Classification: ClassUnknown, Confidence: 1}
+		v.Flags = slices.Clone(p.flags)
+		fillSuffix(&v)
+		r := g.decide(ctx, domain)
+		fillVerdict(&v, r)
+		// the local part is not kept, so its flags carry over instead
+		r.Err = g.conclude(ctx, &v, r, "")
+
+		g.recheckMu.Lock()
+		final := (r.Err == nil && !r.Stale) || p.attempts+1 >= maxRechecks
+		if final {
+			delete(g.pending, domain)
+		} else if cur, ok := g.pending[domain]; ok {
+			cur.attempts++
+			cur.due = now.Add(recheckBackoff(cur.attempts))
+		}
+		g.recheckMu.Unlock()
+
+		if final {
+			g.recordDecision(v)
+			if g.cfg.onRecheck != nil {
+				g.cfg.onRecheck(v)
+			}
+		}
+	}
+}
+
+func recheckBackoff(attempts int) time.Duration {
+	d := retryAfter << attempts
+	if d <= 0 || d > maxRecheckDelay {
+		return maxRecheckDelay
+	}
+	return d
+}
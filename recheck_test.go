@@ -0,0 +1,129 @@
+package emailguard
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// outageResolver times out while down and answers from mx otherwise.
+type outageResolver struct {
+	down atomic.Bool
+	mx   mxResolver
+}
+
+func (r *outageResolver) err(name string) error {
+	return &net.DNSError{Err: "i/o timeout", Name: name, IsTimeout: true, IsTemporary: true}
+}
+
+func (r *outageResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	if r.down.Load() {
+		return nil, r.err(name)
+	}
+	return r.mx.LookupMX(ctx, name)
+}
+
+func (r *outageResolver) LookupNS(ctx context.Context, name string) ([]*net.NS, error) {
+	if r.down.Load() {
+		return nil, r.err(name)
+	}
+	return r.mx.LookupNS(ctx, name)
+}
+
+func (r *outageResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if r.down.Load() {
+		return nil, r.err(name)
+	}
+	return r.mx.LookupTXT(ctx, name)
+}
+
+func (r *outageResolver) LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error) {
+	if r.down.Load() {
+		return nil, r.err(host)
+	}
+	return r.mx.LookupNetIP(ctx, network, host)
+}
+
+// historyStore is a Store keeping only decision history.
+type historyStore struct {
+	mu        sync.Mutex
+	decisions []DecisionRecord
+}
+
+func (s *historyStore) LoadOverrides() ([]Override, []AuditEntry, error) { return nil, nil, nil }
+func (s *historyStore) SaveOverride(Override, AuditEntry) error          { return nil }
+func (s *historyStore) DeleteOverride(string, AuditEntry) error          { return nil }
+func (s *historyStore) LoadLearned() ([]LearnedDomain, error)            { return nil, nil }
+func (s *historyStore) SaveLearned(LearnedDomain) error                  { return nil }
+func (s *historyStore) LoadList(string) ([]string, error)                { return nil, ErrNoSuchList }
+func (s *historyStore) SaveList(string, []string) error                  { return nil }
+func (s *historyStore) RecordVerdict(Verdict, time.Time) error           { return nil }
+func (s *historyStore) SaveCachedVerdict(string, LayerResult, time.Time) error {
+	return nil
+}
+
+func (s *historyStore) LoadCachedVerdict(string) (LayerResult, time.Time, bool, error) {
+	return LayerResult{}, time.Time{}, false, nil
+}
+
+func (s *historyStore) RecordDecision(d DecisionRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.decisions = append(s.decisions, d)
+	return nil
+}
+
+func (s *historyStore) History(domain string, limit int) ([]DecisionRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []DecisionRecord
+	for _, d := range slices.Backward(s.decisions) {
+		if d.Domain == domain && len(out) < limit {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+// A recheck's final verdict must be the one Validate gives once the domain
+// resolves, and be recorded like it.
+func TestRecheckVerdictMatchesValidate(t *testing.T) {
+	r := &outageResolver{mx: mxResolver{"acme-corp.com": {"mx1.mailhost.net", "mx2.mailhost.net"}}}
+	r.down.Store(true)
+	store := &historyStore{}
+	var got []Verdict
+	g := newTestGuard(t, WithResolver(r), WithStore(store), WithRecheckCallback(func(v Verdict) { got = append(got, v) }))
+	ctx := context.Background()
+
+	if v, _ := g.Validate(ctx, "info@acme-corp.com", Campaign("spring")); v.Outcome != OutcomeRetryLater {
+		t.Fatalf("during the outage: got %s, want retry_later", v.Outcome)
+	}
+	r.down.Store(false)
+	g.recheckDue(ctx, time.Now().Add(time.Hour))
+	if len(got) != 1 {
+		t.Fatalf("callback fired %d times, want once", len(got))
+	}
+	v := got[0]
+
+	want, err := g.Validate(ctx, "info@acme-corp.com", Campaign("spring"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Outcome != want.Outcome || v.Reason != want.Reason || v.Classification != want.Classification ||
+		v.Confidence != want.Confidence || v.Campaign != want.Campaign || v.MXCount != want.MXCount {
+		t.Errorf("recheck verdict %+v\nwant as Validate gives %+v", v, want)
+	}
+	slices.Sort(v.Flags)
+	slices.Sort(want.Flags)
+	if !slices.Equal(v.Flags, want.Flags) || len(v.Signals) != len(want.Signals) {
+		t.Errorf("recheck flags %q, signals %v; Validate gives %q, %v", v.Flags, v.Signals, want.Flags, want.Signals)
+	}
+	if h := g.History("acme-corp.com"); len(h) != 3 || h[1].Outcome != OutcomeAllow || h[1].Campaign != "spring" {
+		t.Errorf("history %+v, want the retry, the recheck's allow and the second check", h)
+	}
+}
@@ -0,0 +1,37 @@
+package emailguard
+
+import (
+	"fmt"
+	"time"
+)
+
+// defaultReevaluation bounds how long an allow verdict is trusted without a
+// fresh evaluation.
+const defaultReevaluation = 7 * 24 * time.Hour
+
+// WithReevaluation caps how long an allow verdict is served from cache,
+// counted from its last full evaluation, however long the cache TTL or a
+// shared store would otherwise keep it. Disposable operators buy up and
+// repurpose domains, so yesterday's allow is not forever. Reject verdicts
+// keep the cache TTL. The default is one week.
+func WithReevaluation(every time.Duration) Option {
+	return func(c *config) error {
+		if every <= 0 {
+			return fmt.Errorf("WithReevaluation: need a positive interval, got %v", every)
+		}
+		c.reevaluate = every
+		return nil
+	}
+}
+
+// verdictExpiry is when a cached result stops being served: exp, or sooner
+// for an allow whose last full evaluation is due for a repeat.
+func (g *Guard) verdictExpiry(r LayerResult, exp time.Time) time.Time {
+	if r.Action != ActionAllow || r.Evaluated.IsZero() {
+		return exp
+	}
+	if due := r.Evaluated.Add(g.cfg.reevaluate); due.Before(exp) {
+		return due
+	}
+	return exp
+}
@@ -0,0 +1,178 @@
+package emailguard
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Standard Webhooks event types sent by WithRefreshWebhook.
+const (
+	EventRefreshFailing   = "blocklist.refresh_failing"
+	EventRefreshRecovered = "blocklist.refresh_recovered"
+)
+
+const webhookTimeout = 10 * time.Second
+
+// RefreshAlert reports that blocklist refreshes have been failing for
+// longer than the WithRefreshAlert threshold, or that they recovered.
+type RefreshAlert struct {
+	Failing       bool      `json:"failing"`               // false: a refresh succeeded again
+	Since         time.Time `json:"since,omitzero"`        // first failure in the current run
+	LastSuccess   time.Time `json:"last_success,omitzero"` // zero if no refresh ever succeeded
+	Failures      int       `json:"failures"`              // consecutive failed refreshes
+	Error         string    `json:"error,omitempty"`       // the latest failure
+	BlocklistSize int       `json:"blocklist_size"`        // entries in the list still in use
+}
+
+// RefreshAlertFunc receives refresh alerts.
+type RefreshAlertFunc func(a RefreshAlert)
+
+// WithRefreshAlert calls fn from RunBlocklistRefresh once refreshes have
+// failed continuously for longer than after, and again when one next
+// succeeds. Running on a weeks-old list quietly lets new disposable domains
+// through, so this deserves a page.
+func WithRefreshAlert(after time.Duration, fn RefreshAlertFunc) Option {
+	return func(c *config) error {
+		if after <= 0 {
+			return fmt.Errorf("WithRefreshAlert: threshold must be positive, got %s", after)
+		}
+		if fn == nil {
+			return errors.New("WithRefreshAlert: nil func")
+		}
+		c.refreshAfter = after
+		c.refreshAlerts = append(c.refreshAlerts, fn)
+		return nil
+	}
+}
+
+// WithRefreshWebhook is WithRefreshAlert posting to endpoint as a Standard
+// Webhook (https://www.standardwebhooks.com) of type EventRefreshFailing or
+// EventRefreshRecovered. A non-empty secret ("whsec_" followed by base64)
+// signs each delivery.
+func WithRefreshWebhook(endpoint, secret string, after time.Duration) Option {
+	return func(c *config) error {
+		if u, err := url.Parse(endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("WithRefreshWebhook: invalid URL %q", endpoint)
+		}
+		var key []byte
+		if secret != "" {
+			var err error
+			if key, err = base64.StdEncoding.DecodeString(strings.TrimPrefix(secret, "whsec_")); err != nil || len(key) == 0 {
+				return errors.New("WithRefreshWebhook: secret must be whsec_ followed by base64")
+			}
+		}
+		return WithRefreshAlert(after, func(a RefreshAlert) {
+			if err := postWebhook(endpoint, key, a); err != nil {
+				fmt.Fprintf(os.Stderr, "WARN: refresh webhook: %v\n", err)
+			}
+		})(c)
+	}
+}
+
+// RunBlocklistRefresh re-syncs the blocklist from its sources every
+// interval (30 minutes if zero) and raises refresh alerts. A failed refresh
+// keeps the list in use. It blocks until ctx is done; run it in its own
+// goroutine.
+func (g *Guard) RunBlocklistRefresh(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = pullCooldown
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		g.blocklist.refresh()
+		g.checkRefreshAlert(time.Now())
+		if g.cfg.dataGC != nil {
+			if _, err := g.CollectGarbage(*g.cfg.dataGC); err != nil {
+				fmt.Fprintf(os.Stderr, "WARN: data gc: %v\n", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// checkRefreshAlert fires the alert functions on entering or leaving the
+// alerting state.
+func (g *Guard) checkRefreshAlert(now time.Time) {
+	if len(g.cfg.refreshAlerts) == 0 {
+		return
+	}
+	st := g.blocklist.refreshStatus()
+	failing := st.failures > 0 && now.Sub(st.failingSince) >= g.cfg.refreshAfter
+	if failing == g.refreshAlerting.Load() || (!failing && st.failures > 0) {
+		return
+	}
+	g.refreshAlerting.Store(failing)
+	a := RefreshAlert{Failing: failing, LastSuccess: st.lastSuccess, Failures: st.failures, BlocklistSize: g.blocklist.load().Len()}
+	if failing {
+		a.Since, a.Error = st.failingSince, st.lastErr.Error()
+	}
+	for _, fn := range g.cfg.refreshAlerts {
+		fn(a)
+	}
+}
+
+type webhookEvent struct {
+	Type      string       `json:"type"`
+	Timestamp time.Time    `json:"timestamp"`
+	Data      RefreshAlert `json:"data"`
+}
+
+// postWebhook delivers a as a Standard Webhook, signed when key is set.
+func postWebhook(endpoint string, key []byte, a RefreshAlert) error {
+	ev := webhookEvent{Type: EventRefreshRecovered, Timestamp: time.Now().UTC(), Data: a}
+	if a.Failing {
+		ev.Type = EventRefreshFailing
+	}
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	var idb [16]byte
+	rand.Read(idb[:])
+	id := "msg_" + hex.EncodeToString(idb[:])
+	ts := strconv.FormatInt(ev.Timestamp.Unix(), 10)
+
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("webhook-id", id)
+	req.Header.Set("webhook-timestamp", ts)
+	if key != nil {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(id + "." + ts + "."))
+		mac.Write(body)
+		req.Header.Set("webhook-signature", "v1,"+base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("POST %s: %s", endpoint, resp.Status)
+	}
+	return nil
+}
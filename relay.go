@@ -0,0 +1,113 @@
+package emailguard
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// FlagOpenRelay marks a domain whose mail server relayed for an outside
+// recipient. It asks for human review; the outcome is not changed.
+const FlagOpenRelay = "open_relay"
+
+// SignalSMTPOpenRelay is the risk signal attached to open relays.
+const SignalSMTPOpenRelay = "smtp_open_relay"
+
+const (
+	relayCacheTTL  = 24 * time.Hour
+	relayRecipient = "relay-test@example.com" // RFC 2606 reserved domain
+)
+
+// WithOpenRelayProbe enables a quick open-relay test of allowed domains'
+// primary MX, at most once per every (burst of one) per Guard and once per
+// host per day. It requires WithDeepVerification, whose ProbeFrom, if set,
+// must be at a qualified domain. The probe stops at RCPT TO and never sends
+// a message.
+func WithOpenRelayProbe(every time.Duration) Option {
+	return func(c *config) error {
+		if every <= 0 {
+			return fmt.Errorf("WithOpenRelayProbe: interval must be positive, got %v", every)
+		}
+		c.relayEvery = every
+		return nil
+	}
+}
+
+// RelayInfo is the result of an open-relay probe.
+type RelayInfo struct {
+	Host  string `json:"host"`
+	Open  bool   `json:"open"`
+	Reply string `json:"reply,omitempty"` // server reply to RCPT TO
+	Error string `json:"error,omitempty"`
+}
+
+// checkProbeFrom accepts an empty sender, for the null sender, or an address
+// at a qualified domain: servers reject or greylist probes from "localhost"
+// or bare names, and such a probe says nothing about relaying.
+func checkProbeFrom(from string) error {
+	if from == "" {
+		return nil
+	}
+	_, domain, ok := strings.Cut(from, "@")
+	if !ok {
+		return fmt.Errorf("ProbeFrom %q is not an address", from)
+	}
+	if err := checkHostname(normDomain(domain)); err != nil {
+		return fmt.Errorf("ProbeFrom %q: %w", from, err)
+	}
+	return nil
+}
+
+// probeRelay returns cached results, or probes host if the rate limit allows.
+func (g *Guard) probeRelay(ctx context.Context, host string) (RelayInfo, bool) {
+	key := "relay|" + host
+	if v, ok := g.smtpCache.Load(key); ok {
+		if e := v.(smtpCacheEntry); time.Now().Before(e.exp) {
+			return e.relay, true
+		}
+	}
+	if !g.relayLimit.allow() || g.spend(ctx) != nil {
+		return RelayInfo{}, false
+	}
+	release, err := g.smtpPoolFor(ctx).acquire(ctx, host)
+	if err != nil {
+		return RelayInfo{}, false
+	}
+	defer release()
+
+	if err := g.faults.smtpFault(); err != nil {
+		return RelayInfo{Host: host, Error: err.Error()}, false
+	}
+	info := testOpenRelay(ctx, g.cfg.ipPolicy.tcp(), host, *g.cfg.smtp)
+	if info.Error == "" {
+		g.smtpCache.Store(key, smtpCacheEntry{relay: info, exp: time.Now().Add(relayCacheTTL)})
+	}
+	return info, info.Error == ""
+}
+
+func testOpenRelay(ctx context.Context, network, host string, c SMTPConfig) RelayInfo {
+	info := RelayInfo{Host: host}
+	cl, _, err := dialSMTP(ctx, network, host, c)
+	if err != nil {
+		info.Error = err.Error()
+		return info
+	}
+	defer cl.Close()
+
+	// an empty ProbeFrom is sent as MAIL FROM:<>
+	if err := cl.Mail(c.ProbeFrom); err != nil {
+		info.Error = err.Error()
+		return info
+	}
+	if err := cl.Rcpt(relayRecipient); err != nil {
+		// a refusal is the healthy answer
+		info.Reply = err.Error()
+	} else {
+		info.Open = true
+		info.Reply = "250 recipient accepted"
+	}
+	cl.Reset()
+	cl.Quit()
+	return info
+}
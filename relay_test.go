@@ -0,0 +1,30 @@
+package emailguard
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestOpenRelayProbeFrom(t *testing.T) {
+	tests := []struct {
+		from string
+		ok   bool
+	}{
+		{"", true},
+		{"postmaster@probe.example.net", true},
+		{"relay-probe@localhost", false},
+		{"relay-probe@mailhost", false},
+		{"probe.example.net", false},
+	}
+	for _, tt := range tests {
+		_, err := New(
+			WithBlocklistFS(fstest.MapFS{"blocklist.conf": {}}, "blocklist.conf"),
+			WithDeepVerification(SMTPConfig{ProbeFrom: tt.from}),
+			WithOpenRelayProbe(time.Minute),
+		)
+		if (err == nil) != tt.ok {
+			t.Errorf("ProbeFrom %q: New returned %v, want ok=%v", tt.from, err, tt.ok)
+		}
+	}
+}
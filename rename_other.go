@@ -0,0 +1,9 @@
+//go:build !windows || emailguard_stdlib
+
+package emailguard
+
+import "os"
+
+func rename(from, to string) error { return os.Rename(from, to) }
+
+func removeAll(path string) error { return os.RemoveAll(path) }
@@ -0,0 +1,53 @@
+//go:build !windows
+
+package emailguard
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// A list replaced while a reader has it open reads back new to new
+// readers, while the open reader keeps the old contents.
+func TestWriteFileAtomicUnderReader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), blocklistFile)
+	if err := writeFileAtomic(path, []byte("one.example\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := writeFileAtomic(path, []byte("two.example\n"), 0o644); err != nil {
+		t.Fatalf("replacing an open list: %v", err)
+	}
+	if b, _ := io.ReadAll(f); string(b) != "one.example\n" {
+		t.Errorf("open reader saw %q, want the old list", b)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "two.example\n" {
+		t.Errorf("list reads back %q, want the new one", b)
+	}
+	if ents, _ := os.ReadDir(filepath.Dir(path)); len(ents) != 1 {
+		t.Errorf("directory holds %d entries, want only the list (temporary file left behind?)", len(ents))
+	}
+}
+
+func TestRemoveAllReadOnly(t *testing.T) {
+	clone := filepath.Join(t.TempDir(), "clone")
+	obj := filepath.Join(clone, ".git", "objects", "pack", "pack-1.pack")
+	if err := os.MkdirAll(filepath.Dir(obj), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(obj, []byte("PACK"), 0o444); err != nil {
+		t.Fatal(err)
+	}
+	if err := removeAll(clone); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(clone); !os.IsNotExist(err) {
+		t.Errorf("checkout still there: %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+//go:build !emailguard_stdlib
+
+package emailguard
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+// On Windows a file cannot be renamed over or deleted while another
+// process, such as a virus scanner, the search indexer or a second guard
+// reading the list, has it open. Those holds are brief, so retry a while.
+const sharingRetryFor = 2 * time.Second
+
+func rename(from, to string) error {
+	return retrySharing(func() error { return os.Rename(from, to) })
+}
+
+func removeAll(path string) error {
+	return retrySharing(func() error { return os.RemoveAll(path) })
+}
+
+func retrySharing(op func() error) error {
+	deadline := time.Now().Add(sharingRetryFor)
+	for delay := 10 * time.Millisecond; ; delay *= 2 {
+		err := op()
+		if err == nil || !isSharingViolation(err) || time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(delay)
+	}
+}
+
+func isSharingViolation(err error) bool {
+	return errors.Is(err, windows.ERROR_SHARING_VIOLATION) ||
+		errors.Is(err, windows.ERROR_LOCK_VIOLATION) ||
+		errors.Is(err, windows.ERROR_ACCESS_DENIED)
+}
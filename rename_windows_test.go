@@ -0,0 +1,74 @@
+//go:build !emailguard_stdlib
+
+package emailguard
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+// holdExclusive opens path without sharing, as a virus scanner might, and
+// releases it after d.
+func holdExclusive(t *testing.T, path string, d time.Duration) {
+	t.Helper()
+	name, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := windows.CreateFile(name, windows.GENERIC_READ, 0, nil, windows.OPEN_EXISTING, windows.FILE_ATTRIBUTE_NORMAL, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.AfterFunc(d, func() { windows.CloseHandle(h) })
+}
+
+func TestWriteFileAtomicWaitsOutSharingViolation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), blocklistFile)
+	if err := writeFileAtomic(path, []byte("one.example\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	holdExclusive(t, path, 200*time.Millisecond)
+	if err := writeFileAtomic(path, []byte("two.example\n"), 0o644); err != nil {
+		t.Fatalf("replacing a list held open: %v", err)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "two.example\n" {
+		t.Errorf("list reads back %q, want the new one", b)
+	}
+	if ents, _ := os.ReadDir(filepath.Dir(path)); len(ents) != 1 {
+		t.Errorf("directory holds %d entries, want only the list (temporary file left behind?)", len(ents))
+	}
+}
+
+func TestRemoveAllWaitsOutSharingViolation(t *testing.T) {
+	clone := filepath.Join(t.TempDir(), "clone")
+	obj := filepath.Join(clone, ".git", "objects", "pack", "pack-1.pack")
+	if err := os.MkdirAll(filepath.Dir(obj), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(obj, []byte("PACK"), 0o444); err != nil {
+		t.Fatal(err)
+	}
+	holdExclusive(t, obj, 200*time.Millisecond)
+	if err := removeAll(clone); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(clone); !os.IsNotExist(err) {
+		t.Errorf("checkout still there: %v", err)
+	}
+}
+
+func TestIsSharingViolation(t *testing.T) {
+	for _, err := range []error{windows.ERROR_SHARING_VIOLATION, windows.ERROR_LOCK_VIOLATION, windows.ERROR_ACCESS_DENIED} {
+		if !isSharingViolation(&os.LinkError{Op: "rename", Old: "a", New: "b", Err: err}) {
+			t.Errorf("%v: not a sharing violation", err)
+		}
+	}
+	if isSharingViolation(fmt.Errorf("rename: %w", windows.ERROR_FILE_NOT_FOUND)) {
+		t.Error("ERROR_FILE_NOT_FOUND counted as a sharing violation")
+	}
+}
@@ -0,0 +1,103 @@
+package emailguard
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"sync"
+)
+
+// LayerReputation is the stage that looks domains up on domain blocklists;
+// see WithDomainBlocklists.
+const LayerReputation = "reputation"
+
+// FlagDomainListed marks a domain on a domain blocklist under
+// WithDomainBlocklists(ActionNone).
+const FlagDomainListed = "domain_listed"
+
+// Zones of well-known domain blocklists. Like the IP lists, they limit free
+// use and refuse queries relayed by public resolvers.
+const (
+	DBLSpamhaus = "dbl.spamhaus.org"
+	DBLSURBL    = "multi.surbl.org"
+)
+
+// WithDomainBlocklists looks the domain itself up on DNS-based domain
+// blocklists such as DBLSpamhaus and DBLSURBL, which list domains seen in
+// spam and phishing. Both the domain and its registrable domain are
+// queried, on all lists concurrently. ActionDeny rejects listed domains
+// with ReasonListedDomain in the reputation stage, ahead of the MX checks;
+// ActionNone only adds FlagDomainListed to allowed verdicts, which raises
+// Score. Answers are cached like other DNS results, and failed or refused
+// queries count as not listed. Calling it again adds lists.
+func WithDomainBlocklists(a Action, lists ...DNSBL) Option {
+	return func(c *config) error {
+		if a != ActionNone && a != ActionDeny {
+			return fmt.Errorf("WithDomainBlocklists: action must be none or deny, got %d", a)
+		}
+		for _, b := range lists {
+			if err := b.validate(); err != nil {
+				return fmt.Errorf("WithDomainBlocklists: %w", err)
+			}
+			b.Zone = normDomain(b.Zone)
+			c.domainLists = append(c.domainLists, b)
+		}
+		c.domainListAction = a
+		return nil
+	}
+}
+
+// evalReputation rejects listed domains under WithDomainBlocklists(ActionDeny).
+func (g *Guard) evalReputation(ctx context.Context, domain string) (Finding, error) {
+	if len(g.cfg.domainLists) == 0 || g.cfg.domainListAction != ActionDeny {
+		return noOpinion("domain blocklists off"), nil
+	}
+	name, bl, code := g.listedDomain(ctx, domain)
+	if !code.IsValid() {
+		return noOpinion("not on a domain blocklist"), nil
+	}
+	return Finding{ActionDeny, ReasonListedDomain, bl, fmt.Sprintf("%s listed on %s (%s)", name, bl, code)}, nil
+}
+
+// flagListedDomain adds FlagDomainListed to an allowed verdict of a listed
+// domain.
+func (g *Guard) flagListedDomain(ctx context.Context, v *Verdict) {
+	if _, _, code := g.listedDomain(ctx, v.Domain); code.IsValid() {
+		v.Flags = append(v.Flags, FlagDomainListed)
+	}
+}
+
+// listedDomain returns the first listing of domain, or of its registrable
+// domain, in list order: the name found, the list's zone and its answer.
+// code is invalid if neither is listed.
+func (g *Guard) listedDomain(ctx context.Context, domain string) (name, zone string, code netip.Addr) {
+	names := []string{domain}
+	if reg, err := registrableDomain(domain); err == nil && reg != domain {
+		names = append(names, reg)
+	}
+	type hit struct {
+		name string
+		code netip.Addr
+	}
+	hits := make([]hit, len(g.cfg.domainLists))
+	var wg sync.WaitGroup
+	for i, bl := range g.cfg.domainLists {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, n := range names {
+				if c, err := g.queryDNSBL(ctx, bl, n); err == nil && c.IsValid() {
+					hits[i] = hit{n, c}
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	for i, h := range hits {
+		if h.code.IsValid() {
+			return h.name, g.cfg.domainLists[i].Zone, h.code
+		}
+	}
+	return "", "", netip.Addr{}
+}
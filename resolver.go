@@ -0,0 +1,221 @@
+package emailguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+// Public DNS-over-HTTPS endpoints.
+const (
+	DoHCloudflare = "https://cloudflare-dns.com/dns-query"
+	DoHGoogle     = "https://dns.google/dns-query"
+)
+
+// Resolver is the DNS client a Guard looks domains up with. *net.Resolver
+// implements it, and net.DefaultResolver is the default; wrap miekg/dns, an
+// internal resolver or a test fake to replace it.
+//
+// A definitive "no such domain" or "no records" answer must come back as a
+// *net.DNSError with IsNotFound set, which the guard treats as an empty
+// answer. Every other error counts as temporary.
+type Resolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupNS(ctx context.Context, name string) ([]*net.NS, error)
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+	// LookupNetIP looks up host's addresses; network is "ip", "ip4" or
+	// "ip6".
+	LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error)
+}
+
+// WithResolver makes the guard send its DNS lookups to r instead of
+// net.DefaultResolver. Caching, pooling and timeouts still apply. SMTP
+// probes dial MX hosts by name and so still resolve through the system.
+func WithResolver(r Resolver) Option {
+	return func(c *config) error {
+		if r == nil {
+			return errors.New("WithResolver: nil resolver")
+		}
+		c.resolver = r
+		return nil
+	}
+}
+
+// WithDNSRetry retries DNS lookups that fail temporarily, a timeout or
+// SERVFAIL, up to attempts times in all, instead of giving up after one;
+// on a busy resolver a single dropped packet otherwise turns into
+// OutcomeRetryLater. Each attempt gets WithMXTimeout; between attempts the
+// guard waits backoff, doubling each time, varied by up to the jitter
+// fraction (0 to 1) so that retries from many checks do not arrive
+// together. A caller deadline still bounds the whole lookup. Answers that
+// a domain does not exist are never retried. The default is a single
+// attempt; 3 attempts with a 50ms backoff and 0.2 jitter suit most
+// resolvers.
+func WithDNSRetry(attempts int, backoff time.Duration, jitter float64) Option {
+	return func(c *config) error {
+		switch {
+		case attempts < 1 || attempts > maxDNSAttempts:
+			return fmt.Errorf("WithDNSRetry: attempts must be between 1 and %d, got %d", maxDNSAttempts, attempts)
+		case backoff < 0:
+			return fmt.Errorf("WithDNSRetry: need a non-negative backoff, got %v", backoff)
+		case jitter < 0 || jitter > 1:
+			return fmt.Errorf("WithDNSRetry: jitter must be between 0 and 1, got %g", jitter)
+		}
+		c.dnsAttempts, c.dnsBackoff, c.dnsJitter = attempts, backoff, jitter
+		return nil
+	}
+}
+
+// WithSearchDomains lets the system resolver try the search domains of
+// resolv.conf on the names the guard looks up. By default every name is
+// looked up as written, fully qualified: in a Kubernetes pod, whose
+// resolv.conf has ndots:5 and several search domains, example.com is
+// otherwise first tried as example.com.default.svc.cluster.local and so
+// on, which costs lookups and, where a search domain has wildcard
+// records, answers with MX hosts that are not the domain's. Only deployments
+// that validate internal short names need it. Resolvers other than the
+// system's (WithResolver, WithDoH) get names as written either way.
+func WithSearchDomains() Option {
+	return func(c *config) error {
+		c.searchDomains = true
+		return nil
+	}
+}
+
+const (
+	maxDNSAttempts    = 10 // keeps a dead resolver from holding a check for long
+	defaultDNSBackoff = 50 * time.Millisecond
+	defaultDNSJitter  = 0.2
+)
+
+// resolver returns the configured resolver.
+func (g *Guard) resolver() Resolver {
+	var r Resolver = net.DefaultResolver
+	if g.cfg.resolver != nil {
+		r = g.cfg.resolver
+	}
+	if !g.cfg.searchDomains && usesSearchList(r) {
+		r = fqdnResolver{r}
+	}
+	if g.faults != nil {
+		r = faultResolver{Resolver: r, fi: g.faults, wait: g.cfg.mxTimeout}
+	}
+	if g.cfg.dnsAttempts > 1 {
+		r = retryResolver{Resolver: r, cfg: &g.cfg}
+	}
+	return r
+}
+
+// dnsBudget is how long a lookup may take with every attempt and backoff,
+// when the caller sets no deadline.
+func (c *config) dnsBudget() time.Duration {
+	d := c.mxTimeout
+	for i := 1; i < c.dnsAttempts; i++ {
+		d += c.mxTimeout + time.Duration(float64(c.dnsBackoff<<(i-1))*(1+c.dnsJitter))
+	}
+	return d
+}
+
+// retryResolver retries the temporary failures of Resolver under
+// WithDNSRetry.
+type retryResolver struct {
+	Resolver
+	cfg *config
+}
+
+// retry calls lookup until it succeeds, fails for good, runs out of
+// attempts or ctx ends.
+func retry[T any](ctx context.Context, cfg *config, lookup func(context.Context) (T, error)) (T, error) {
+	for i := 0; ; i++ {
+		actx, cancel := context.WithTimeout(ctx, cfg.mxTimeout)
+		res, err := lookup(actx)
+		cancel()
+		if classifyDNSError(err) == nil || i+1 >= cfg.dnsAttempts || ctx.Err() != nil {
+			return res, err
+		}
+		d := cfg.dnsBackoff << i
+		if cfg.dnsJitter > 0 {
+			d = time.Duration(float64(d) * (1 + cfg.dnsJitter*(2*rand.Float64()-1)))
+		}
+		t := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return res, err
+		case <-t.C:
+		}
+	}
+}
+
+func (r retryResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	return retry(ctx, r.cfg, func(ctx context.Context) ([]*net.MX, error) { return r.Resolver.LookupMX(ctx, name) })
+}
+
+func (r retryResolver) LookupNS(ctx context.Context, name string) ([]*net.NS, error) {
+	return retry(ctx, r.cfg, func(ctx context.Context) ([]*net.NS, error) { return r.Resolver.LookupNS(ctx, name) })
+}
+
+func (r retryResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	return retry(ctx, r.cfg, func(ctx context.Context) ([]string, error) { return r.Resolver.LookupTXT(ctx, name) })
+}
+
+func (r retryResolver) LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error) {
+	return retry(ctx, r.cfg, func(ctx context.Context) ([]netip.Addr, error) { return r.Resolver.LookupNetIP(ctx, network, host) })
+}
+
+func (r retryResolver) LookupAddr(ctx context.Context, addr string) ([]string, error) {
+	return retry(ctx, r.cfg, func(ctx context.Context) ([]string, error) { return lookupAddr(ctx, r.Resolver, addr) })
+}
+
+// usesSearchList reports whether r applies the search domains of
+// resolv.conf to names without a trailing dot.
+func usesSearchList(r Resolver) bool {
+	switch r.(type) {
+	case *net.Resolver, *upstreamResolver:
+		return true
+	}
+	return false
+}
+
+// absName returns name fully qualified, with a trailing dot, so resolvers
+// look it up as written. IP addresses and empty names are left alone.
+func absName(name string) string {
+	if name == "" || strings.HasSuffix(name, ".") {
+		return name
+	}
+	if _, err := netip.ParseAddr(name); err == nil {
+		return name
+	}
+	return name + "."
+}
+
+// fqdnResolver keeps Resolver from trying search domains, unless
+// WithSearchDomains.
+type fqdnResolver struct {
+	Resolver
+}
+
+func (r fqdnResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	return r.Resolver.LookupMX(ctx, absName(name))
+}
+
+func (r fqdnResolver) LookupNS(ctx context.Context, name string) ([]*net.NS, error) {
+	return r.Resolver.LookupNS(ctx, absName(name))
+}
+
+func (r fqdnResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	return r.Resolver.LookupTXT(ctx, absName(name))
+}
+
+func (r fqdnResolver) LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error) {
+	return r.Resolver.LookupNetIP(ctx, network, absName(host))
+}
+
+func (r fqdnResolver) LookupAddr(ctx context.Context, addr string) ([]string, error) {
+	return lookupAddr(ctx, r.Resolver, addr)
+}
@@ -0,0 +1,57 @@
+package emailguard
+
+import (
+	"errors"
+	"slices"
+	"strings"
+)
+
+// FlagRoleAccount marks a shared mailbox such as info@ or sales@ rather
+// than a named person.
+const FlagRoleAccount = "role_account"
+
+var defaultRoleAccounts = []string{
+	"abuse", "admin", "administrator", "billing", "contact", "enquiries",
+	"hello", "help", "hostmaster", "hr", "info", "inquiries", "jobs",
+	"marketing", "no-reply", "noreply", "office", "postmaster", "press",
+	"sales", "security", "support", "team", "webmaster",
+}
+
+// WithRoleAccounts flags role addresses with FlagRoleAccount. A local part
+// matches a prefix exactly or followed by ".", "-" or "_" (sales-emea@),
+// ignoring case and any +tag. Without prefixes the built-in list is used;
+// to extend it, pass append(DefaultRoleAccounts(), ...).
+func WithRoleAccounts(prefixes ...string) Option {
+	return func(c *config) error {
+		if len(prefixes) == 0 {
+			prefixes = defaultRoleAccounts
+		}
+		roles := make([]string, 0, len(prefixes))
+		for _, p := range prefixes {
+			if p = strings.ToLower(strings.TrimSpace(p)); p == "" {
+				return errors.New("WithRoleAccounts: empty prefix")
+			}
+			roles = append(roles, p)
+		}
+		c.roleAccounts = roles
+		return nil
+	}
+}
+
+// DefaultRoleAccounts returns a copy of the built-in role prefixes.
+func DefaultRoleAccounts() []string {
+	return slices.Clone(defaultRoleAccounts)
+}
+
+// isRoleAccount reports whether local matches one of roles.
+func isRoleAccount(local string, roles []string) bool {
+	local = strings.ToLower(local)
+	local, _, _ = strings.Cut(local, "+")
+	for _, r := range roles {
+		rest, ok := strings.CutPrefix(local, r)
+		if ok && (rest == "" || strings.ContainsRune(".-_", rune(rest[0]))) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package emailguard
+
+import "context"
+
+// reasonRisk is the risk a decision contributes to Score, by reason. Allow
+// decisions contribute nothing; signals still can.
+var reasonRisk = map[Reason]int{
+	ReasonSyntax:         100,
+	ReasonOverride:       100,
+	ReasonTenantPolicy:   100,
+	ReasonTLDPolicy:      100,
+	ReasonHook:           100,
+	ReasonDisposable:     100,
+	ReasonTempMailInfra:  100,
+	ReasonNoMX:           100,
+	ReasonDisposableMX:   90,
+	ReasonBlockedMX:      100,
+	ReasonParked:         80,
+	ReasonListedDomain:   90,
+	ReasonMaskedMX:       80,
+	ReasonInsufficientMX: 40,
+	ReasonTemporary:      50, // unknown, not known-bad
+	ReasonDefaultPolicy:  50,
+}
+
+// flagRisk weighs verdict flags. Flags without an entry (e.g. TLD rule
+// flags) count defaultFlagRisk; FlagOpenRelay is already scored as a signal.
+var flagRisk = map[string]int{
+	FlagSequentialLocalPart: 20,
+	FlagNoSPF:               20,
+	FlagSPFPassAll:          25,
+	FlagParked:              40,
+	FlagDNSBLListed:         30,
+	FlagDomainListed:        40,
+	FlagMXNoPTR:             20,
+	FlagMXPTRMismatch:       10,
+	FlagOpenRelay:           0,
+}
+
+const defaultFlagRisk = 15
+
+// Contribution is one line of a risk Breakdown.
+type Contribution struct {
+	Name   string `json:"name"` // reason, signal or flag
+	Points int    `json:"points"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Breakdown explains a risk score.
+type Breakdown struct {
+	Score         int            `json:"score"`
+	Contributions []Contribution `json:"contributions"`
+	Verdict       Verdict        `json:"verdict"`
+}
+
+// Score rates email with the default guard.
+func Score(email string) (int, Breakdown) {
+	return defaultGuard.Load().Score(email)
+}
+
+// Score rates email from 0 (no risk) to 100 (certainly bad) and itemizes
+// every contribution: the decision's reason, each signal and each flag.
+// Route mid-range scores to manual review instead of hard-rejecting.
+func (g *Guard) Score(email string) (int, Breakdown) {
+	return g.ScoreContext(context.Background(), email)
+}
+
+// ScoreContext is Score bounded by ctx.
+func (g *Guard) ScoreContext(ctx context.Context, email string, opts ...CheckOption) (int, Breakdown) {
+	v, _ := g.Validate(ctx, email, opts...)
+	b := breakdown(v)
+	return b.Score, b
+}
+
+func breakdown(v Verdict) Breakdown {
+	b := Breakdown{Verdict: v}
+	if v.Outcome != OutcomeAllow {
+		if pts, ok := reasonRisk[v.Reason]; ok {
+			b.Contributions = append(b.Contributions, Contribution{Name: string(v.Reason), Points: pts, Detail: v.Detail})
+		}
+	}
+	for _, s := range v.Signals {
+		if s.Weight != 0 {
+			b.Contributions = append(b.Contributions, Contribution{Name: s.Name, Points: s.Weight, Detail: s.Detail})
+		}
+	}
+	for _, f := range v.Flags {
+		pts, ok := flagRisk[f]
+		if !ok {
+			pts = defaultFlagRisk
+		}
+		if pts != 0 {
+			b.Contributions = append(b.Contributions, Contribution{Name: f, Points: pts})
+		}
+	}
+	for _, c := range b.Contributions {
+		b.Score += c.Points
+	}
+	b.Score = min(max(b.Score, 0), 100)
+	return b
+}
@@ -0,0 +1,140 @@
+package emailguard
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FlagSequentialLocalPart marks an address that continues a run like
+// test1@, test2@, test3@ on the same domain within the detection window.
+const FlagSequentialLocalPart = "sequential_local_part"
+
+const (
+	defaultSequenceWindow    = 1 * time.Hour
+	defaultSequenceThreshold = 3
+)
+
+// SequenceStore remembers numbered local parts per domain. The default
+// in-memory store is per Guard; supply a shared implementation (e.g. backed
+// by Redis) to detect sequences spread across instances.
+type SequenceStore interface {
+	// Observe records base+num on domain at time at and returns how many
+	// distinct numbers for that base were seen within window, including
+	// this one. In privacy mode base is a keyed hash.
+	Observe(domain, base string, num uint64, at time.Time, window time.Duration) int
+}
+
+// WithSequenceDetection flags signups once threshold addresses sharing a
+// base and differing only in a trailing number (test1@, test2@, ...) have
+// hit the same domain within window.
+func WithSequenceDetection(window time.Duration, threshold int) Option {
+	return func(c *config) error {
+		if window <= 0 || threshold < 2 {
+			return fmt.Errorf("WithSequenceDetection: need window > 0 and threshold >= 2, got %v/%d", window, threshold)
+		}
+		c.seqWindow, c.seqThreshold = window, threshold
+		return nil
+	}
+}
+
+// WithSequenceStore replaces the in-memory sequence store. It implies
+// sequence detection with default window and threshold unless
+// WithSequenceDetection is also given.
+func WithSequenceStore(s SequenceStore) Option {
+	return func(c *config) error {
+		if s == nil {
+			return fmt.Errorf("WithSequenceStore: nil store")
+		}
+		c.seqStore = s
+		if c.seqWindow == 0 {
+			c.seqWindow, c.seqThreshold = defaultSequenceWindow, defaultSequenceThreshold
+		}
+		return nil
+	}
+}
+
+// splitSequence splits "test12" into ("test", 12). Local parts without a
+// trailing number, or that are all digits, are not sequences.
+func splitSequence(local string) (string, uint64, bool) {
+	local = strings.ToLower(local)
+	if i := strings.IndexByte(local, '+'); i >= 0 {
+		local = local[:i] // test1+a and test1+b are the same mailbox
+	}
+	end := len(local)
+	for end > 0 && local[end-1] >= '0' && local[end-1] <= '9' {
+		end--
+	}
+	if end == len(local) || end == 0 {
+		return "", 0, false
+	}
+	n, err := strconv.ParseUint(local[end:], 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return local[:end], n, true
+}
+
+// sequenceFlag records the address and reports whether it is part of a run.
+func (g *Guard) sequenceFlag(local, domain string) bool {
+	if g.seqStore == nil {
+		return false
+	}
+	base, n, ok := splitSequence(local)
+	if !ok {
+		return false
+	}
+	if g.cfg.keyring != nil {
+		var err error
+		if base, err = g.hashLocal(base); err != nil {
+			return false
+		}
+	}
+	return g.seqStore.Observe(domain, base, n, time.Now(), g.cfg.seqWindow) >= g.cfg.seqThreshold
+}
+
+// memSequenceStore is the default per-Guard SequenceStore.
+type memSequenceStore struct {
+	mu   sync.Mutex
+	seen map[string][]seqHit // domain + "\x00" + base
+	ops  int
+}
+
+type seqHit struct {
+	num uint64
+	at  time.Time
+}
+
+func newMemSequenceStore() *memSequenceStore {
+	return &memSequenceStore{seen: make(map[string][]seqHit)}
+}
+
+func (m *memSequenceStore) Observe(domain, base string, num uint64, at time.Time, window time.Duration) int {
+	key := domain + "\x00" + base
+	cutoff := at.Add(-window)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	hits := m.seen[key][:0:0]
+	distinct := map[uint64]struct{}{num: {}}
+	for _, h := range m.seen[key] {
+		if h.at.After(cutoff) && h.num != num {
+			hits = append(hits, h)
+			distinct[h.num] = struct{}{}
+		}
+	}
+	m.seen[key] = append(hits, seqHit{num: num, at: at})
+
+	// occasionally drop keys that went quiet
+	if m.ops++; m.ops%1024 == 0 {
+		for k, hs := range m.seen {
+			if !hs[len(hs)-1].at.After(cutoff) {
+				delete(m.seen, k)
+			}
+		}
+	}
+	return len(distinct)
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// DefaultSocketMode lets the owning user and group connect to a Unix socket
+// and nobody else.
+const DefaultSocketMode os.FileMode = 0o660
+
+// ListenConfig describes where a Server listens.
+type ListenConfig struct {
+	// Addr is "host:port" for TCP or "unix:/path/to.sock" for a Unix domain
+	// socket.
+	Addr string
+
+	// SocketMode is applied to the Unix socket file. Access control for the
+	// sidecar pattern is plain file permissions: put the app and emailguard
+	// in the same group and keep the mode at 0660. Zero means
+	// DefaultSocketMode.
+	SocketMode os.FileMode
+}
+
+// Listen opens the configured listener. For Unix sockets a stale socket
+// file left by a previous process is removed first; any other existing file
+// at that path is left alone and reported as an error.
+func (c ListenConfig) Listen() (net.Listener, error) {
+	path, ok := strings.CutPrefix(c.Addr, "unix:")
+	if !ok {
+		return net.Listen("tcp", c.Addr)
+	}
+	if path == "" {
+		return nil, errors.New("listen: empty unix socket path")
+	}
+	if fi, err := os.Lstat(path); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("listen: %s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("listen: removing stale socket: %w", err)
+		}
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	mode := c.SocketMode
+	if mode == 0 {
+		mode = DefaultSocketMode
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("listen: chmod socket: %w", err)
+	}
+	return ln, nil
+}
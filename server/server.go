@@ -0,0 +1,236 @@
+// Package server exposes an emailguard.Guard over HTTP, either as a network
+// service or as a sidecar reachable through a Unix domain socket.
+//
+// Endpoints:
+//
+//	GET  /v1/check?email=user@example.com
+//	POST /v1/check   {"email": "user@example.com"}
+//	GET  /healthz
+//
+// EnableDebug adds operator endpoints and EnableUI a web UI for support
+// staff.
+//
+// Checks carrying an X-Emailguard-Tenant header are charged to that tenant's
+// budget; an exhausted budget answers 429 with Retry-After. Bulk callers
+// should send X-Emailguard-Priority: batch. Accept-Language steers typo
+// suggestions towards providers popular in the user's locale. An
+// X-Request-ID (or X-Correlation-ID) header is echoed back and kept with
+// the verdict, its history record and any recheck it schedules, and
+// X-Emailguard-Campaign with the verdict and its history record.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vandit1604/emailguard"
+)
+
+// Server serves check requests against a Guard.
+type Server struct {
+	guard *emailguard.Guard
+	mux   *http.ServeMux
+}
+
+// New returns a Server backed by g.
+func New(g *emailguard.Guard) *Server {
+	s := &Server{guard: g, mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET /v1/check", s.handleCheck)
+	s.mux.HandleFunc("POST /v1/check", s.handleCheck)
+	s.mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok\n"))
+		if ks := s.guard.KillSwitches(); len(ks) > 0 {
+			fmt.Fprintf(w, "switched off: %s\n", strings.Join(ks, ", "))
+		}
+	})
+	return s
+}
+
+// EnableDebug mounts the guard's debug handler at /debug/emailguard and the
+// expvar registry at /debug/vars. Only expose these to operators.
+//
+// It also mounts the kill switches (see emailguard.Guard.SetKillSwitch):
+// GET /debug/emailguard/kill-switches lists the checks switched off, PUT
+// /debug/emailguard/kill-switches/{check} switches one off and DELETE
+// switches it back on.
+//
+// GET /debug/emailguard/funnel reports the lead-quality funnel (see
+// emailguard.Guard.FunnelReport) of the checks between the RFC 3339 times
+// from and to, by default the last 7 days, as JSON or, with format=text or
+// format=markdown, rendered.
+func (s *Server) EnableDebug() {
+	s.mux.Handle("GET /debug/emailguard", s.guard.DebugHandler())
+	s.mux.Handle("GET /debug/vars", expvar.Handler())
+	s.mux.HandleFunc("GET /debug/emailguard/kill-switches", s.handleKillSwitches)
+	s.mux.HandleFunc("PUT /debug/emailguard/kill-switches/{check}", s.handleKillSwitches)
+	s.mux.HandleFunc("DELETE /debug/emailguard/kill-switches/{check}", s.handleKillSwitches)
+	s.mux.HandleFunc("GET /debug/emailguard/funnel", s.handleFunnel)
+}
+
+func (s *Server) handleFunnel(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	to := time.Now()
+	var err error
+	if v := q.Get("to"); v != "" {
+		if to, err = time.Parse(time.RFC3339, v); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "to: " + err.Error()})
+			return
+		}
+	}
+	from := to.AddDate(0, 0, -7)
+	if v := q.Get("from"); v != "" {
+		if from, err = time.Parse(time.RFC3339, v); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "from: " + err.Error()})
+			return
+		}
+	}
+	rep, err := s.guard.FunnelReport(from, to)
+	if err != nil {
+		writeJSON(w, http.StatusNotImplemented, map[string]string{"error": err.Error()})
+		return
+	}
+	switch q.Get("format") {
+	case "text":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(rep.Text()))
+	case "markdown":
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.Write([]byte(rep.Markdown()))
+	default:
+		writeJSON(w, http.StatusOK, rep)
+	}
+}
+
+func (s *Server) handleKillSwitches(w http.ResponseWriter, r *http.Request) {
+	if check := r.PathValue("check"); check != "" {
+		if err := s.guard.SetKillSwitch(check, r.Method == http.MethodPut); err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+	ks := s.guard.KillSwitches()
+	if ks == nil {
+		ks = []string{}
+	}
+	writeJSON(w, http.StatusOK, map[string][]string{"kill_switches": ks})
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+const (
+	// TenantHeader names the tenant a check is charged to; see
+	// emailguard.WithTenantBudget.
+	TenantHeader = "X-Emailguard-Tenant"
+	// PriorityHeader set to "batch" runs the check as emailguard.PriorityBatch.
+	PriorityHeader = "X-Emailguard-Priority"
+	// RequestIDHeader carries the caller's correlation ID; see
+	// emailguard.CorrelationID. X-Correlation-ID is accepted too.
+	RequestIDHeader = "X-Request-ID"
+	// CampaignHeader names the campaign a signup came from; see
+	// emailguard.Campaign.
+	CampaignHeader = "X-Emailguard-Campaign"
+)
+
+type checkRequest struct {
+	Email string `json:"email"`
+}
+
+type checkResponse struct {
+	emailguard.Verdict
+	RetryAfterSeconds float64 `json:"retry_after_seconds,omitempty"`
+	DidYouMean        string  `json:"did_you_mean,omitempty"`
+	Error             string  `json:"error,omitempty"`
+}
+
+func (s *Server) handleCheck(w http.ResponseWriter, r *http.Request) {
+	var req checkRequest
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body"})
+			return
+		}
+	} else {
+		req.Email = r.URL.Query().Get("email")
+	}
+	if req.Email == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "missing email"})
+		return
+	}
+
+	var opts []emailguard.CheckOption
+	if t := r.Header.Get(TenantHeader); t != "" {
+		opts = append(opts, emailguard.ForTenant(t))
+	}
+	if r.Header.Get(PriorityHeader) == "batch" {
+		opts = append(opts, emailguard.AtPriority(emailguard.PriorityBatch))
+	}
+	if tag := primaryLanguage(r.Header.Get("Accept-Language")); tag != "" {
+		opts = append(opts, emailguard.InLocale(tag))
+	}
+	if c := r.Header.Get(CampaignHeader); c != "" {
+		opts = append(opts, emailguard.Campaign(c))
+	}
+	if id := requestID(r); id != "" {
+		opts = append(opts, emailguard.CorrelationID(id))
+		w.Header().Set(RequestIDHeader, id)
+	}
+	v, err := s.guard.Validate(r.Context(), req.Email, opts...)
+	resp := checkResponse{Verdict: v, RetryAfterSeconds: v.RetryAfter.Seconds(), DidYouMean: v.DidYouMean()}
+	status := http.StatusOK
+	if err != nil {
+		resp.Error = err.Error()
+		switch {
+		case errors.Is(err, emailguard.ErrBudgetExhausted):
+			status = http.StatusTooManyRequests
+			w.Header().Set("Retry-After", retryAfterHeader(v.RetryAfter))
+		case errors.Is(err, emailguard.ErrTemporary):
+			status = http.StatusServiceUnavailable
+			w.Header().Set("Retry-After", retryAfterHeader(v.RetryAfter))
+		}
+	}
+	writeJSON(w, status, resp)
+}
+
+// requestID returns the caller's correlation ID, if it sent a usable one.
+func requestID(r *http.Request) string {
+	id := r.Header.Get(RequestIDHeader)
+	if id == "" {
+		id = r.Header.Get("X-Correlation-ID")
+	}
+	if len(id) > 128 || strings.ContainsFunc(id, func(c rune) bool { return c < 0x21 || c > 0x7e }) {
+		return ""
+	}
+	return id
+}
+
+// primaryLanguage returns the first tag of an Accept-Language header.
+func primaryLanguage(h string) string {
+	tag, _, _ := strings.Cut(h, ",")
+	tag, _, _ = strings.Cut(tag, ";")
+	if tag = strings.TrimSpace(tag); tag == "*" {
+		return ""
+	}
+	return tag
+}
+
+func retryAfterHeader(d time.Duration) string {
+	secs := int(d.Round(time.Second) / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+	return strconv.Itoa(secs)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
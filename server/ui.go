@@ -0,0 +1,124 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	_ "embed"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/vandit1604/emailguard"
+)
+
+// maxUpload bounds a bulk upload from the UI.
+const maxUpload = 32 << 20
+
+//go:embed ui.html
+var uiHTML string
+
+var uiTemplate = template.Must(template.New("ui").Parse(uiHTML))
+
+var tableContentTypes = map[string]string{
+	"csv":     "text/csv; charset=utf-8",
+	"xlsx":    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"parquet": "application/vnd.apache.parquet",
+}
+
+// EnableUI mounts a small web UI at /ui/ for support staff: check an
+// address and see every check's opinion (see emailguard.Guard.Explain)
+// with the domain's decision history, check a file in bulk, and see list
+// statistics and recent rejects. Every request must pass auth, for example
+// BasicAuth. Cross-origin form posts are refused.
+func (s *Server) EnableUI(auth func(*http.Request) bool) {
+	if auth == nil {
+		panic("server: EnableUI without auth")
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /ui/{$}", s.handleUI)
+	mux.HandleFunc("POST /ui/bulk", s.handleUIBulk)
+	ui := new(http.CrossOriginProtection).Handler(mux)
+	s.mux.Handle("/ui/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !auth(r) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="emailguard", charset="UTF-8"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		ui.ServeHTTP(w, r)
+	}))
+	s.mux.Handle("GET /ui", http.RedirectHandler("/ui/", http.StatusMovedPermanently))
+}
+
+// BasicAuth returns an authenticator for EnableUI that accepts user and
+// password with HTTP basic authentication.
+func BasicAuth(user, password string) func(*http.Request) bool {
+	want := sha256.Sum256([]byte(user + "\x00" + password))
+	return func(r *http.Request) bool {
+		u, p, ok := r.BasicAuth()
+		got := sha256.Sum256([]byte(u + "\x00" + p))
+		return ok && subtle.ConstantTimeCompare(got[:], want[:]) == 1
+	}
+}
+
+type uiPage struct {
+	Email        string
+	Explanation  *emailguard.Explanation
+	History      []emailguard.DecisionRecord
+	Stats        emailguard.DebugStats
+	BlocklistAge time.Duration
+	MaxUploadMB  int
+}
+
+func (s *Server) handleUI(w http.ResponseWriter, r *http.Request) {
+	p := uiPage{
+		Email:       r.URL.Query().Get("email"),
+		Stats:       s.guard.DebugStats(1),
+		MaxUploadMB: maxUpload >> 20,
+	}
+	p.BlocklistAge = p.Stats.BlocklistAge.Round(time.Second)
+	if p.Email != "" {
+		ex := s.guard.ExplainContext(r.Context(), p.Email)
+		p.Explanation = &ex
+		if ex.Verdict.Domain != "" {
+			p.History = s.guard.History(ex.Verdict.Domain)
+		}
+	}
+	var buf bytes.Buffer
+	if err := uiTemplate.Execute(&buf, p); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+func (s *Server) handleUIBulk(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxUpload)
+	f, _, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "missing or oversized file: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer f.Close()
+	format := r.FormValue("format")
+	ctype, ok := tableContentTypes[format]
+	if !ok {
+		http.Error(w, "unknown format "+format, http.StatusBadRequest)
+		return
+	}
+	// buffered, so a failure halfway still gets a proper error status
+	var buf bytes.Buffer
+	tw, _ := emailguard.NewTableWriter(&buf, format)
+	err = s.guard.BulkValidateTable(r.Context(), f, tw, emailguard.BatchOptions{})
+	if cerr := tw.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", ctype)
+	w.Header().Set("Content-Disposition", `attachment; filename="checked.`+format+`"`)
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,68 @@
+package emailguard
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// LayerShadow is reported in Verdict.Layer when shadow mode overrode the
+// decision.
+const LayerShadow = "shadow"
+
+// ShadowFunc receives the verdict a guard in shadow mode would have
+// returned, with its error.
+type ShadowFunc func(v Verdict, err error)
+
+// WithShadowMode evaluates every check as usual, reports the real verdict to
+// fn and then allows the address, so the guard can be measured against
+// production traffic before it enforces anything. Returned verdicts that
+// differ from the real one carry Layer "shadow", Reason ReasonShadow and
+// the would-be outcome in Detail; flags and signals are kept. Use
+// ShadowLog to write decisions to a log.
+func WithShadowMode(fn ShadowFunc) Option {
+	return func(c *config) error {
+		if fn == nil {
+			return errors.New("WithShadowMode: nil sink")
+		}
+		c.shadow = fn
+		return nil
+	}
+}
+
+// ShadowLog returns a ShadowFunc writing one JSON object per check to w.
+// Writes are serialized; errors are dropped.
+func ShadowLog(w io.Writer) ShadowFunc {
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+	return func(v Verdict, err error) {
+		rec := struct {
+			Time  time.Time `json:"time"`
+			Would Verdict   `json:"would"`
+			Error string    `json:"error,omitempty"`
+		}{Time: time.Now(), Would: v}
+		if err != nil {
+			rec.Error = err.Error()
+		}
+		mu.Lock()
+		enc.Encode(rec)
+		mu.Unlock()
+	}
+}
+
+// shadowed reports v to the shadow sink and returns what the caller gets.
+func (g *Guard) shadowed(v Verdict, err error) (Verdict, error) {
+	if g.cfg.shadow == nil {
+		return v, err
+	}
+	g.cfg.shadow(v, err)
+	if v.Outcome == OutcomeAllow {
+		return v, nil
+	}
+	would := v.Outcome
+	fillVerdict(&v, LayerResult{Layer: LayerShadow, Action: ActionAllow, Reason: ReasonShadow, Match: v.Match, Detail: fmt.Sprintf("shadow mode: would %s (%s)", would, v.Reason)})
+	return v, nil
+}
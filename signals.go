@@ -0,0 +1,17 @@
+package emailguard
+
+// Signal is one piece of evidence gathered while checking an address.
+// Positive weights add risk; negative weights are reassuring.
+type Signal struct {
+	Name   string `json:"name"`
+	Weight int    `json:"weight"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Signal names.
+const (
+	SignalMXRedundant         = "mx_redundant"
+	SignalMXSingle            = "mx_single"
+	SignalMXSingleResidential = "mx_single_residential"
+	SignalMXImplicit          = "mx_implicit" // WithImplicitMX
+)
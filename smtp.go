@@ -0,0 +1,212 @@
+package emailguard
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+const (
+	defaultSMTPTimeout = 5 * time.Second
+	defaultSMTPPort    = "25"
+	defaultHeloName    = "localhost"
+)
+
+// SMTP signal names.
+const (
+	SignalSMTPNoStartTLS  = "smtp_no_starttls"
+	SignalSMTPTLSBroken   = "smtp_tls_broken"
+	SignalSMTPCertInvalid = "smtp_cert_invalid"
+	SignalSMTPTLSVerified = "smtp_tls_verified"
+	SignalSMTPUnreachable = "smtp_unreachable"
+)
+
+// SMTPConfig configures deep verification, which talks SMTP to a domain's
+// mail servers. Outbound port 25 is blocked in many clouds; failures to
+// connect are reported but never penalized.
+type SMTPConfig struct {
+	HeloName string        // EHLO name; default "localhost"
+	Timeout  time.Duration // per conversation; default 5s
+	Port     string        // default "25"
+
+	// ProbeFrom is the MAIL FROM of open-relay probes: an address at a
+	// domain you own and publish MX/SPF for. Empty sends the null
+	// sender <>.
+	ProbeFrom string
+}
+
+// WithDeepVerification enables SMTP-level inspection of allowed domains'
+// primary MX: whether it offers STARTTLS and whether its certificate is
+// valid for the host. Results land in Verdict.SMTP and as signals.
+func WithDeepVerification(c SMTPConfig) Option {
+	return func(cfg *config) error {
+		if c.HeloName == "" {
+			c.HeloName = defaultHeloName
+		}
+		if c.Timeout <= 0 {
+			c.Timeout = defaultSMTPTimeout
+		}
+		if c.Port == "" {
+			c.Port = defaultSMTPPort
+		}
+		cfg.smtp = &c
+		return nil
+	}
+}
+
+// SMTPInfo is what deep verification learned about a mail server.
+type SMTPInfo struct {
+	Host       string `json:"host"`
+	Addr       string `json:"addr,omitempty"`        // the address connected to; see WithIPPolicy
+	StartTLS   bool   `json:"starttls"`              // advertised in EHLO
+	TLSVersion string `json:"tls_version,omitempty"` // negotiated, if any
+	CertValid  bool   `json:"cert_valid"`            // chain and hostname verified
+	CertError  string `json:"cert_error,omitempty"`  // certificate verification failure
+	TLSError   string `json:"tls_error,omitempty"`   // any other handshake failure
+	Error      string `json:"error,omitempty"`       // connection-level failure
+}
+
+// traceDetail describes i for the smtp entry of a verdict's trace, e.g.
+// "mx.example.com at 192.0.2.1:25: STARTTLS, TLS 1.3, certificate valid".
+func (i SMTPInfo) traceDetail() string {
+	d := i.Host
+	if i.Addr != "" {
+		d += " at " + i.Addr
+	}
+	switch {
+	case i.Error != "":
+		return d
+	case !i.StartTLS:
+		return d + ": no STARTTLS"
+	case i.TLSError != "":
+		return d + ": STARTTLS, handshake failed: " + i.TLSError
+	}
+	d += ": STARTTLS, " + i.TLSVersion
+	if i.CertValid {
+		return d + ", certificate valid"
+	}
+	return d + ", certificate invalid: " + i.CertError
+}
+
+type smtpCacheEntry struct {
+	info  SMTPInfo
+	relay RelayInfo
+	exp   time.Time
+}
+
+// inspectSMTP returns cached or fresh STARTTLS details for host.
+func (g *Guard) inspectSMTP(ctx context.Context, host string) SMTPInfo {
+	key := "starttls|" + host
+	if v, ok := g.smtpCache.Load(key); ok {
+		if e := v.(smtpCacheEntry); time.Now().Before(e.exp) {
+			return e.info
+		}
+	}
+
+	if err := g.spend(ctx); err != nil {
+		return SMTPInfo{Host: host, Error: err.Error()}
+	}
+	release, err := g.smtpPoolFor(ctx).acquire(ctx, host)
+	if err != nil {
+		return SMTPInfo{Host: host, Error: err.Error()}
+	}
+	defer release()
+
+	if err := g.faults.smtpFault(); err != nil {
+		return SMTPInfo{Host: host, Error: err.Error()}
+	}
+	info := probeStartTLS(ctx, g.cfg.ipPolicy.tcp(), host, *g.cfg.smtp)
+	if info.Error == "" {
+		g.smtpCache.Store(key, smtpCacheEntry{info: info, exp: time.Now().Add(g.cfg.cacheTTL)})
+	}
+	return info
+}
+
+// dialSMTP opens an SMTP session with host over network ("tcp", "tcp4" or
+// "tcp6") and says EHLO.
+func dialSMTP(ctx context.Context, network, host string, c SMTPConfig) (*smtp.Client, net.Conn, error) {
+	d := net.Dialer{Timeout: c.Timeout}
+	// MX hosts are fully qualified; dialing them so skips the search domains
+	conn, err := d.DialContext(ctx, network, net.JoinHostPort(absName(host), c.Port))
+	if err != nil {
+		return nil, nil, err
+	}
+	deadline := time.Now().Add(c.Timeout)
+	if cd, ok := ctx.Deadline(); ok && cd.Before(deadline) {
+		deadline = cd
+	}
+	conn.SetDeadline(deadline)
+	cl, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	if err := cl.Hello(c.HeloName); err != nil {
+		cl.Close()
+		return nil, nil, err
+	}
+	return cl, conn, nil
+}
+
+func probeStartTLS(ctx context.Context, network, host string, c SMTPConfig) SMTPInfo {
+	info := SMTPInfo{Host: host}
+	cl, conn, err := dialSMTP(ctx, network, host, c)
+	if err != nil {
+		info.Error = err.Error()
+		return info
+	}
+	defer cl.Close()
+	info.Addr = conn.RemoteAddr().String()
+
+	info.StartTLS, _ = cl.Extension("STARTTLS")
+	if !info.StartTLS {
+		cl.Quit()
+		return info
+	}
+	err = cl.StartTLS(&tls.Config{ServerName: host})
+	if err != nil {
+		if isCertError(err) {
+			info.CertError = err.Error()
+		} else {
+			info.TLSError = err.Error()
+		}
+		return info
+	}
+	if st, ok := cl.TLSConnectionState(); ok {
+		info.TLSVersion = tls.VersionName(st.Version)
+		info.CertValid = true
+	}
+	cl.Quit()
+	return info
+}
+
+func isCertError(err error) bool {
+	var (
+		verr *tls.CertificateVerificationError
+		herr x509.HostnameError
+		uerr x509.UnknownAuthorityError
+		cerr x509.CertificateInvalidError
+	)
+	return errors.As(err, &verr) || errors.As(err, &herr) || errors.As(err, &uerr) || errors.As(err, &cerr)
+}
+
+// smtpSignals turns inspection results into risk signals.
+func smtpSignals(info SMTPInfo) []Signal {
+	switch {
+	case info.Error != "":
+		return []Signal{{Name: SignalSMTPUnreachable, Weight: 0, Detail: info.Error}}
+	case !info.StartTLS:
+		return []Signal{{Name: SignalSMTPNoStartTLS, Weight: 10, Detail: info.Host + " does not offer STARTTLS"}}
+	case info.CertError != "":
+		return []Signal{{Name: SignalSMTPCertInvalid, Weight: 15, Detail: fmt.Sprintf("%s: %s", info.Host, info.CertError)}}
+	case !info.CertValid:
+		return []Signal{{Name: SignalSMTPTLSBroken, Weight: 15, Detail: fmt.Sprintf("%s: %s", info.Host, info.TLSError)}}
+	default:
+		return []Signal{{Name: SignalSMTPTLSVerified, Weight: -5, Detail: fmt.Sprintf("%s: %s, certificate valid", info.Host, info.TLSVersion)}}
+	}
+}
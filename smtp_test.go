@@ -0,0 +1,22 @@
+package emailguard
+
+import "testing"
+
+func TestSMTPInfoTraceDetail(t *testing.T) {
+	tests := []struct {
+		info SMTPInfo
+		want string
+	}{
+		{SMTPInfo{Host: "mx.example.com", Error: "dial tcp: timeout"}, "mx.example.com"},
+		{SMTPInfo{Host: "mx.example.com", Addr: "192.0.2.1:25"}, "mx.example.com at 192.0.2.1:25: no STARTTLS"},
+		{SMTPInfo{Host: "mx.example.com", StartTLS: true, TLSError: "EOF"}, "mx.example.com: STARTTLS, handshake failed: EOF"},
+		{SMTPInfo{Host: "mx.example.com", StartTLS: true, TLSVersion: "TLS 1.3", CertValid: true}, "mx.example.com: STARTTLS, TLS 1.3, certificate valid"},
+		{SMTPInfo{Host: "mx.example.com", StartTLS: true, TLSVersion: "TLS 1.2", CertError: "x509: certificate has expired"},
+			"mx.example.com: STARTTLS, TLS 1.2, certificate invalid: x509: certificate has expired"},
+	}
+	for _, tt := range tests {
+		if got := tt.info.traceDetail(); got != tt.want {
+			t.Errorf("traceDetail(%+v) = %q, want %q", tt.info, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package emailguard
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNoSigner is returned by Snapshot when no key was configured with
+// WithSnapshotSigner.
+var ErrNoSigner = errors.New("emailguard: no snapshot signer configured")
+
+// ErrBadSignature is returned by VerifySnapshot for tampered snapshots or
+// the wrong key.
+var ErrBadSignature = errors.New("emailguard: snapshot signature mismatch")
+
+// Snapshot is a signed, timestamped record of every piece of evidence behind
+// a domain's current decision. Store it with the user record to answer "why
+// was this customer rejected?" later; VerifySnapshot proves it unaltered.
+type Snapshot struct {
+	Version int       `json:"version"`
+	Domain  string    `json:"domain"`
+	Taken   time.Time `json:"taken"`
+
+	// decision
+	Outcome Outcome `json:"outcome"`
+	Reason  Reason  `json:"reason,omitempty"`
+	Layer   string  `json:"layer,omitempty"`
+	Match   string  `json:"match,omitempty"`
+	Detail  string  `json:"detail,omitempty"`
+
+	// evidence
+	Layers        []SnapshotLayer `json:"layers"` // every layer's opinion, precedence order
+	MXHosts       []string        `json:"mx_hosts,omitempty"`
+	Flags         []string        `json:"flags,omitempty"`
+	Signals       []Signal        `json:"signals,omitempty"`
+	SMTP          *SMTPInfo       `json:"smtp,omitempty"`
+	BlocklistSize int             `json:"blocklist_size"`
+	Policy        string          `json:"policy"` // fingerprint of the guard's configuration
+
+	KeyID     string `json:"key_id"`
+	Signature []byte `json:"signature"`
+}
+
+// SnapshotLayer is one layer's opinion recorded in a Snapshot.
+type SnapshotLayer struct {
+	Layer  string `json:"layer"`
+	Action Action `json:"action"`
+	Reason Reason `json:"reason,omitempty"`
+	Match  string `json:"match,omitempty"`
+	Detail string `json:"detail,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// WithSnapshotSigner sets the key Snapshot signs with. Ed25519, ECDSA and
+// RSA keys are supported; any crypto.Signer works, so keys may live in an
+// HSM or KMS.
+func WithSnapshotSigner(s crypto.Signer) Option {
+	return func(c *config) error {
+		if s == nil {
+			return errors.New("WithSnapshotSigner: nil signer")
+		}
+		if _, err := keyID(s.Public()); err != nil {
+			return fmt.Errorf("WithSnapshotSigner: %w", err)
+		}
+		c.signer = s
+		return nil
+	}
+}
+
+// Snapshot evaluates every layer for domain, bypassing the verdict cache,
+// and returns the signed record of the decision and its evidence.
+func (g *Guard) Snapshot(domain string) (Snapshot, error) {
+	if g.cfg.signer == nil {
+		return Snapshot{}, ErrNoSigner
+	}
+	ex := g.ExplainPrecedence(domain)
+	s := Snapshot{
+		Version:       snapshotFormat,
+		Domain:        ex.Domain,
+		Taken:         time.Now().UTC(),
+		Layers:        make([]SnapshotLayer, 0, len(ex.Layers)),
+		BlocklistSize: g.blocklist.load().Len(),
+		Policy:        g.policy(),
+	}
+
+	for _, l := range ex.Layers {
+		sl := SnapshotLayer{Layer: l.Layer, Action: l.Action, Reason: l.Reason, Match: l.Match, Detail: l.Detail}
+		if l.Err != nil {
+			sl.Error = l.Err.Error()
+		}
+		s.Layers = append(s.Layers, sl)
+	}
+	v := Verdict{Domain: ex.Domain}
+	g.verdictOf(context.Background(), &v, ex.Layers)
+	s.Outcome, s.Reason, s.Layer, s.Match, s.Detail = v.Outcome, v.Reason, v.Layer, v.Match, v.Detail
+	s.MXHosts, s.Flags, s.Signals, s.SMTP = v.MXHosts, v.Flags, v.Signals, v.SMTP
+
+	var err error
+	if s.KeyID, err = keyID(g.cfg.signer.Public()); err != nil {
+		return Snapshot{}, err
+	}
+	msg, err := s.payload()
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if s.Signature, err = sign(g.cfg.signer, msg); err != nil {
+		return Snapshot{}, fmt.Errorf("sign snapshot: %w", err)
+	}
+	return s, nil
+}
+
+// VerifySnapshot checks s against the signer's public key.
+func VerifySnapshot(s Snapshot, pub crypto.PublicKey) error {
+	if err := checkFormat("snapshot", s.Version, snapshotFormat); err != nil {
+		return err
+	}
+	if id, err := keyID(pub); err != nil {
+		return err
+	} else if id != s.KeyID {
+		return fmt.Errorf("%w: signed by key %s, not %s", ErrBadSignature, s.KeyID, id)
+	}
+	msg, err := s.payload()
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256(msg)
+	ok := false
+	switch k := pub.(type) {
+	case ed25519.PublicKey:
+		ok = ed25519.Verify(k, msg, s.Signature)
+	case *ecdsa.PublicKey:
+		ok = ecdsa.VerifyASN1(k, digest[:], s.Signature)
+	case *rsa.PublicKey:
+		ok = rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], s.Signature) == nil
+	}
+	if !ok {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// payload is the signed encoding: the snapshot without its signature.
+func (s Snapshot) payload() ([]byte, error) {
+	s.Signature = nil
+	return json.Marshal(s)
+}
+
+func sign(signer crypto.Signer, msg []byte) ([]byte, error) {
+	if _, ok := signer.Public().(ed25519.PublicKey); ok {
+		return signer.Sign(rand.Reader, msg, crypto.Hash(0))
+	}
+	digest := sha256.Sum256(msg)
+	return signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+}
+
+// keyID is a short fingerprint of a public key.
+func keyID(pub crypto.PublicKey) (string, error) {
+	switch pub.(type) {
+	case ed25519.PublicKey, *ecdsa.PublicKey, *rsa.PublicKey:
+	default:
+		return "", fmt.Errorf("unsupported key type %T", pub)
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:8]), nil
+}
@@ -0,0 +1,74 @@
+package emailguard
+
+import (
+	"context"
+	"strings"
+)
+
+// SPF flags, added under WithSPFCheck.
+const (
+	// FlagNoSPF marks a domain that publishes no SPF record. Businesses
+	// that send mail almost always do.
+	FlagNoSPF = "no_spf"
+	// FlagSPFPassAll marks an SPF record ending in +all, which lets anyone
+	// send as the domain.
+	FlagSPFPassAll = "spf_pass_all"
+)
+
+// SPFInfo is what WithSPFCheck found in a domain's SPF record.
+type SPFInfo struct {
+	Record string `json:"record,omitempty"` // the v=spf1 TXT record; empty if none
+	All    string `json:"all,omitempty"`    // its all mechanism, e.g. "-all" or "~all"; empty if it has none
+}
+
+// WithSPFCheck looks up the SPF record of each allowed domain and flags
+// domains with none (FlagNoSPF) or with +all (FlagSPFPassAll). The record
+// is reported in Verdict.SPF. The outcome is unaffected; the flags feed
+// Score. A failed lookup adds nothing.
+func WithSPFCheck() Option {
+	return func(c *config) error {
+		c.spf = true
+		return nil
+	}
+}
+
+// inspectSPF adds v's SPF record and flags.
+func (g *Guard) inspectSPF(ctx context.Context, v *Verdict) {
+	txt, err := g.lookupTXT(ctx, v.Domain)
+	if err != nil {
+		return
+	}
+	info := parseSPF(txt)
+	v.SPF = &info
+	switch {
+	case info.Record == "":
+		v.Flags = append(v.Flags, FlagNoSPF)
+	case info.All == "+all":
+		v.Flags = append(v.Flags, FlagSPFPassAll)
+	}
+}
+
+// parseSPF picks the SPF record out of a domain's TXT records (RFC 7208
+// section 4.5) and its all mechanism. With several SPF records, which is
+// itself an error, the first is reported.
+func parseSPF(txt []string) SPFInfo {
+	for _, t := range txt {
+		fields := strings.Fields(t)
+		if len(fields) == 0 || !strings.EqualFold(fields[0], "v=spf1") {
+			continue
+		}
+		info := SPFInfo{Record: strings.Join(fields, " ")}
+		for _, f := range fields[1:] {
+			f = strings.ToLower(f)
+			if f == "all" {
+				f = "+all" // the qualifier defaults to pass
+			}
+			if len(f) == 4 && strings.HasSuffix(f, "all") && strings.ContainsRune("+-~?", rune(f[0])) {
+				info.All = f
+				break
+			}
+		}
+		return info
+	}
+	return SPFInfo{}
+}
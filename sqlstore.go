@@ -0,0 +1,476 @@
+package emailguard
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// sqliteDrivers are the database/sql driver names registered by the common
+// SQLite packages (modernc.org/sqlite and mattn/go-sqlite3).
+var sqliteDrivers = []string{"sqlite", "sqlite3"}
+
+// SQLStore is a Store in a single SQLite file. emailguard does not bundle a
+// driver; import one in your main package:
+//
+//	import _ "modernc.org/sqlite" // pure Go
+type SQLStore struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens (creating if needed) the SQLite database at path using
+// whichever SQLite driver is registered.
+func OpenSQLite(path string) (*SQLStore, error) {
+	registered := sql.Drivers()
+	for _, name := range sqliteDrivers {
+		if slices.Contains(registered, name) {
+			db, err := sql.Open(name, path)
+			if err != nil {
+				return nil, err
+			}
+			db.SetMaxOpenConns(1) // SQLite has a single writer
+			s, err := NewSQLStore(db)
+			if err != nil {
+				db.Close()
+				return nil, err
+			}
+			return s, nil
+		}
+	}
+	return nil, errors.New("sqlstore: no SQLite driver registered; import modernc.org/sqlite or github.com/mattn/go-sqlite3")
+}
+
+// NewSQLStore uses an already opened SQLite database, creating or
+// upgrading the schema as needed.
+func NewSQLStore(db *sql.DB) (*SQLStore, error) {
+	s := &SQLStore{db: db}
+	if err := s.migrate(); err != nil {
+		return nil, fmt.Errorf("sqlstore: %w", err)
+	}
+	return s, nil
+}
+
+// migrate applies pending sqlMigrations, one transaction per step. The
+// schema version lives in PRAGMA user_version.
+func (s *SQLStore) migrate() error {
+	var version int
+	if err := s.db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return err
+	}
+	if err := checkFormat("schema", version, sqlSchemaFormat); err != nil {
+		return err
+	}
+	for v := version; v < len(sqlMigrations); v++ {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(sqlMigrations[v]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migrate schema v%d -> v%d: %w", v, v+1, err)
+		}
+		// PRAGMA takes no bind parameters
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, v+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const sqlSchema = `
+CREATE TABLE IF NOT EXISTS overrides (
+	domain  TEXT PRIMARY KEY,
+	action  TEXT NOT NULL,
+	actor   TEXT NOT NULL,
+	reason  TEXT NOT NULL,
+	created INTEGER NOT NULL,
+	expires INTEGER NOT NULL -- unix nanos, 0 = never
+);
+CREATE TABLE IF NOT EXISTS override_audit (
+	id     INTEGER PRIMARY KEY AUTOINCREMENT,
+	time   INTEGER NOT NULL,
+	op     TEXT NOT NULL,
+	actor  TEXT NOT NULL,
+	reason TEXT NOT NULL,
+	override TEXT NOT NULL -- JSON
+);
+CREATE TABLE IF NOT EXISTS learned (
+	domain     TEXT PRIMARY KEY,
+	provider   TEXT NOT NULL,
+	indicator  TEXT NOT NULL,
+	first_seen INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS lists (
+	name    TEXT PRIMARY KEY,
+	updated INTEGER NOT NULL,
+	body    TEXT NOT NULL -- newline separated
+);
+CREATE TABLE IF NOT EXISTS verdicts (
+	id      INTEGER PRIMARY KEY AUTOINCREMENT,
+	domain  TEXT NOT NULL,
+	at      INTEGER NOT NULL,
+	outcome TEXT NOT NULL,
+	layer   TEXT NOT NULL,
+	detail  TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS verdicts_domain_at ON verdicts(domain, at);
+CREATE TABLE IF NOT EXISTS verdict_cache (
+	domain TEXT PRIMARY KEY,
+	result TEXT NOT NULL, -- JSON LayerResult
+	exp    INTEGER NOT NULL
+);
+`
+
+// Close closes the underlying database.
+func (s *SQLStore) Close() error { return s.db.Close() }
+
+// DB exposes the underlying database for tooling.
+func (s *SQLStore) DB() *sql.DB { return s.db }
+
+func nanos(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func fromNanos(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+// --- overrides ---
+
+func (s *SQLStore) LoadOverrides() ([]Override, []AuditEntry, error) {
+	rows, err := s.db.Query(`SELECT domain, action, actor, reason, created, expires FROM overrides ORDER BY domain`)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	var out []Override
+	for rows.Next() {
+		var o Override
+		var action string
+		var created, expires int64
+		if err := rows.Scan(&o.Domain, &action, &o.Actor, &o.Reason, &created, &expires); err != nil {
+			return nil, nil, err
+		}
+		if err := o.Action.UnmarshalText([]byte(action)); err != nil {
+			return nil, nil, err
+		}
+		o.Created, o.Expires = fromNanos(created), fromNanos(expires)
+		out = append(out, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	arows, err := s.db.Query(`SELECT time, op, actor, reason, override FROM override_audit ORDER BY id`)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer arows.Close()
+	var audit []AuditEntry
+	for arows.Next() {
+		var e AuditEntry
+		var at int64
+		var blob string
+		if err := arows.Scan(&at, &e.Op, &e.Actor, &e.Reason, &blob); err != nil {
+			return nil, nil, err
+		}
+		e.Time = fromNanos(at)
+		if err := json.Unmarshal([]byte(blob), &e.Override); err != nil {
+			return nil, nil, err
+		}
+		audit = append(audit, e)
+	}
+	return out, audit, arows.Err()
+}
+
+func (s *SQLStore) SaveOverride(o Override, e AuditEntry) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`INSERT OR REPLACE INTO overrides (domain, action, actor, reason, created, expires) VALUES (?, ?, ?, ?, ?, ?)`,
+		o.Domain, o.Action.String(), o.Actor, o.Reason, nanos(o.Created), nanos(o.Expires)); err != nil {
+		return err
+	}
+	if err := insertAudit(tx, e); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLStore) DeleteOverride(domain string, e AuditEntry) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM overrides WHERE domain = ?`, domain); err != nil {
+		return err
+	}
+	if err := insertAudit(tx, e); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func insertAudit(tx *sql.Tx, e AuditEntry) error {
+	blob, err := json.Marshal(e.Override)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO override_audit (time, op, actor, reason, override) VALUES (?, ?, ?, ?, ?)`,
+		nanos(e.Time), e.Op, e.Actor, e.Reason, string(blob))
+	return err
+}
+
+// --- learned ---
+
+func (s *SQLStore) LoadLearned() ([]LearnedDomain, error) {
+	rows, err := s.db.Query(`SELECT domain, provider, indicator, first_seen FROM learned ORDER BY domain`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []LearnedDomain
+	for rows.Next() {
+		var d LearnedDomain
+		var seen int64
+		if err := rows.Scan(&d.Domain, &d.Provider, &d.Indicator, &seen); err != nil {
+			return nil, err
+		}
+		d.FirstSeen = fromNanos(seen)
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+func (s *SQLStore) SaveLearned(d LearnedDomain) error {
+	_, err := s.db.Exec(`INSERT OR IGNORE INTO learned (domain, provider, indicator, first_seen) VALUES (?, ?, ?, ?)`,
+		d.Domain, d.Provider, d.Indicator, nanos(d.FirstSeen))
+	return err
+}
+
+// --- lists ---
+
+func (s *SQLStore) LoadList(name string) ([]string, error) {
+	var body string
+	err := s.db.QueryRow(`SELECT body FROM lists WHERE name = ?`, name).Scan(&body)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoSuchList
+	}
+	if err != nil {
+		return nil, err
+	}
+	if body == "" {
+		return nil, nil
+	}
+	return strings.Split(body, "\n"), nil
+}
+
+func (s *SQLStore) SaveList(name string, domains []string) error {
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO lists (name, updated, body) VALUES (?, ?, ?)`,
+		name, time.Now().UnixNano(), strings.Join(domains, "\n"))
+	return err
+}
+
+// --- verdicts ---
+
+func (s *SQLStore) RecordVerdict(v Verdict, at time.Time) error {
+	_, err := s.db.Exec(`INSERT INTO verdicts (domain, at, outcome, layer, detail) VALUES (?, ?, ?, ?, ?)`,
+		v.Domain, nanos(at), v.Outcome.String(), v.Layer, v.Detail)
+	return err
+}
+
+// RecordDecision implements HistoryStore.
+func (s *SQLStore) RecordDecision(d DecisionRecord) error {
+	_, err := s.db.Exec(`INSERT INTO verdicts (domain, at, outcome, layer, detail, reason, match, list_version, policy, correlation_id, campaign) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		d.Domain, nanos(d.At), d.Outcome.String(), d.Layer, d.Detail, string(d.Reason), d.Match, d.ListVersion, d.Policy, d.CorrelationID, d.Campaign)
+	return err
+}
+
+// History implements HistoryStore.
+func (s *SQLStore) History(domain string, limit int) ([]DecisionRecord, error) {
+	rows, err := s.db.Query(`SELECT domain, at, outcome, layer, detail, reason, match, list_version, policy, correlation_id, campaign FROM verdicts
+		WHERE domain = ? ORDER BY at DESC LIMIT ?`, domain, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanDecisions(rows)
+}
+
+// Decisions implements DecisionLog.
+func (s *SQLStore) Decisions(from, to time.Time) ([]DecisionRecord, error) {
+	rows, err := s.db.Query(`SELECT domain, at, outcome, layer, detail, reason, match, list_version, policy, correlation_id, campaign FROM verdicts
+		WHERE at >= ? AND at < ? ORDER BY at`, nanos(from), nanos(to))
+	if err != nil {
+		return nil, err
+	}
+	return scanDecisions(rows)
+}
+
+func scanDecisions(rows *sql.Rows) ([]DecisionRecord, error) {
+	defer rows.Close()
+	var out []DecisionRecord
+	for rows.Next() {
+		var d DecisionRecord
+		var at int64
+		var outcome, reason string
+		if err := rows.Scan(&d.Domain, &at, &outcome, &d.Layer, &d.Detail, &reason, &d.Match, &d.ListVersion, &d.Policy, &d.CorrelationID, &d.Campaign); err != nil {
+			return nil, err
+		}
+		if err := d.Outcome.UnmarshalText([]byte(outcome)); err != nil {
+			return nil, err
+		}
+		d.At, d.Reason = fromNanos(at), Reason(reason)
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+func (s *SQLStore) LoadCachedVerdict(key string) (LayerResult, time.Time, bool, error) {
+	var blob string
+	var exp int64
+	err := s.db.QueryRow(`SELECT result, exp FROM verdict_cache WHERE cache_key = ?`, key).Scan(&blob, &exp)
+	if errors.Is(err, sql.ErrNoRows) {
+		return LayerResult{}, time.Time{}, false, nil
+	}
+	if err != nil {
+		return LayerResult{}, time.Time{}, false, err
+	}
+	expAt := fromNanos(exp)
+	if time.Now().After(expAt) {
+		return LayerResult{}, time.Time{}, false, nil
+	}
+	var r LayerResult
+	if err := json.Unmarshal([]byte(blob), &r); err != nil {
+		return LayerResult{}, time.Time{}, false, err
+	}
+	return r, expAt, true, nil
+}
+
+func (s *SQLStore) SaveCachedVerdict(key string, r LayerResult, exp time.Time) error {
+	blob, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT OR REPLACE INTO verdict_cache (cache_key, result, exp) VALUES (?, ?, ?)`, key, string(blob), nanos(exp))
+	return err
+}
+
+// PutCachedVerdictIfAbsent implements ConsistentStore: an unexpired entry
+// always beats the new one.
+func (s *SQLStore) PutCachedVerdictIfAbsent(key string, r LayerResult, exp time.Time) (LayerResult, time.Time, error) {
+	blob, err := json.Marshal(r)
+	if err != nil {
+		return LayerResult{}, time.Time{}, err
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return LayerResult{}, time.Time{}, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`INSERT INTO verdict_cache (cache_key, result, exp) VALUES (?, ?, ?)
+		ON CONFLICT(cache_key) DO UPDATE SET result = excluded.result, exp = excluded.exp
+		WHERE verdict_cache.exp < ?`, key, string(blob), nanos(exp), time.Now().UnixNano()); err != nil {
+		return LayerResult{}, time.Time{}, err
+	}
+	var cur string
+	var curExp int64
+	if err := tx.QueryRow(`SELECT result, exp FROM verdict_cache WHERE cache_key = ?`, key).Scan(&cur, &curExp); err != nil {
+		return LayerResult{}, time.Time{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return LayerResult{}, time.Time{}, err
+	}
+	var won LayerResult
+	if err := json.Unmarshal([]byte(cur), &won); err != nil {
+		return LayerResult{}, time.Time{}, err
+	}
+	return won, fromNanos(curExp), nil
+}
+
+// FirstSeen implements FirstSeenStore.
+func (s *SQLStore) FirstSeen(domain string, at time.Time) (time.Time, error) {
+	if _, err := s.db.Exec(`INSERT OR IGNORE INTO first_seen (domain, at) VALUES (?, ?)`, domain, nanos(at)); err != nil {
+		return time.Time{}, err
+	}
+	var first int64
+	if err := s.db.QueryRow(`SELECT at FROM first_seen WHERE domain = ?`, domain).Scan(&first); err != nil {
+		return time.Time{}, err
+	}
+	return fromNanos(first), nil
+}
+
+// --- maintenance ---
+
+// Vacuum drops expired cache rows and compacts the database file.
+func (s *SQLStore) Vacuum() error {
+	if _, err := s.db.Exec(`DELETE FROM verdict_cache WHERE exp < ?`, time.Now().UnixNano()); err != nil {
+		return err
+	}
+	_, err := s.db.Exec(`VACUUM`)
+	return err
+}
+
+// sqlExport is the JSON document written by Export.
+type sqlExport struct {
+	Overrides []Override      `json:"overrides"`
+	Audit     []AuditEntry    `json:"audit"`
+	Learned   []LearnedDomain `json:"learned"`
+	Lists     map[string]int  `json:"lists"` // name -> entries
+	Verdicts  int             `json:"verdicts"`
+}
+
+// Export writes a JSON summary of the store: all overrides with their audit
+// trail, learned domains, list sizes and the verdict count.
+func (s *SQLStore) Export(w io.Writer) error {
+	var ex sqlExport
+	var err error
+	if ex.Overrides, ex.Audit, err = s.LoadOverrides(); err != nil {
+		return err
+	}
+	if ex.Learned, err = s.LoadLearned(); err != nil {
+		return err
+	}
+	ex.Lists = make(map[string]int)
+	rows, err := s.db.Query(`SELECT name, body FROM lists ORDER BY name`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name, body string
+		if err := rows.Scan(&name, &body); err != nil {
+			return err
+		}
+		ex.Lists[name] = strings.Count(body, "\n") + 1
+		if body == "" {
+			ex.Lists[name] = 0
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM verdicts`).Scan(&ex.Verdicts); err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(ex)
+}
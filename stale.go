@@ -0,0 +1,45 @@
+package emailguard
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// FlagStale marks a verdict served from an expired cache entry because the
+// fresh evaluation failed transiently; see WithStaleIfError.
+const FlagStale = "stale"
+
+// WithStaleIfError keeps serving a domain's expired cached verdict for up
+// to maxStale past its expiry when re-evaluating it fails with ErrTemporary,
+// instead of answering OutcomeRetryLater. Such verdicts carry FlagStale and
+// the domain is queued for RunRechecks, which replaces the entry once the
+// resolver answers again.
+func WithStaleIfError(maxStale time.Duration) Option {
+	return func(c *config) error {
+		if maxStale <= 0 {
+			return fmt.Errorf("WithStaleIfError: need a positive duration, got %s", maxStale)
+		}
+		c.staleIfError = maxStale
+		return nil
+	}
+}
+
+// staleVerdict returns domain's expired in-memory verdict if it expired no
+// more than the WithStaleIfError bound ago and err is transient.
+func (g *Guard) staleVerdict(domain string, err error) (LayerResult, bool) {
+	if g.cfg.staleIfError <= 0 || !errors.Is(err, ErrTemporary) {
+		return LayerResult{}, false
+	}
+	v, ok := g.verdicts.Load(domain)
+	if !ok {
+		return LayerResult{}, false
+	}
+	e := v.(verdictEntry)
+	if time.Now().After(g.verdictExpiry(e.res, e.exp).Add(g.cfg.staleIfError)) {
+		return LayerResult{}, false
+	}
+	r := e.res
+	r.Stale = true
+	return r, true
+}
@@ -0,0 +1,60 @@
+package emailguard
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Store persists guard state so small deployments keep overrides, learned
+// domains, list snapshots, verdict history and warm caches across restarts.
+// SQLStore is the bundled implementation.
+type Store interface {
+	// overrides and their audit trail
+	LoadOverrides() ([]Override, []AuditEntry, error)
+	SaveOverride(o Override, e AuditEntry) error
+	DeleteOverride(domain string, e AuditEntry) error
+
+	// domains learned through provider fingerprints
+	LoadLearned() ([]LearnedDomain, error)
+	SaveLearned(d LearnedDomain) error
+
+	// named list snapshots (e.g. the disposable blocklist)
+	LoadList(name string) ([]string, error)
+	SaveList(name string, domains []string) error
+
+	// verdict history
+	RecordVerdict(v Verdict, at time.Time) error
+
+	// verdict cache, keyed by policy version and domain; found is false for
+	// missing or expired entries
+	LoadCachedVerdict(key string) (r LayerResult, exp time.Time, found bool, err error)
+	SaveCachedVerdict(key string, r LayerResult, exp time.Time) error
+}
+
+// ErrNoSuchList is returned by Store.LoadList for unknown names.
+var ErrNoSuchList = errors.New("emailguard: no such list")
+
+// blocklistListName is the Store key of the disposable blocklist snapshot.
+const blocklistListName = "disposable"
+
+// WithStore persists the guard's state in s: overrides (unless
+// WithOverrides is given), learned domains, a blocklist snapshot used when
+// every upstream is down, verdict history, and the verdict cache.
+func WithStore(s Store) Option {
+	return func(c *config) error {
+		if s == nil {
+			return errors.New("WithStore: nil store")
+		}
+		c.store = s
+		return nil
+	}
+}
+
+// warnStore reports a non-fatal persistence failure.
+func warnStore(op string, err error) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "WARN: store %s: %v\n", op, err)
+	}
+}
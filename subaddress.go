@@ -0,0 +1,88 @@
+package emailguard
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FlagSubaddressed marks an address using a subaddress tag (user+tag@) or
+// a provider alias trick; see WithSubaddressPolicy.
+const FlagSubaddressed = "subaddressed"
+
+// LayerSubaddress is the layer of verdicts rejected by SubaddressReject.
+const LayerSubaddress = "subaddress"
+
+// SubaddressPolicy says what to do with subaddressed addresses, which let
+// one mailbox sign up many times.
+type SubaddressPolicy int
+
+const (
+	SubaddressAllow  SubaddressPolicy = iota // no effect (the default)
+	SubaddressFlag                           // add FlagSubaddressed
+	SubaddressReject                         // reject with ReasonSubaddressed
+)
+
+func (p SubaddressPolicy) String() string {
+	switch p {
+	case SubaddressFlag:
+		return "flag"
+	case SubaddressReject:
+		return "reject"
+	default:
+		return "allow"
+	}
+}
+
+func (p SubaddressPolicy) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *SubaddressPolicy) UnmarshalText(b []byte) error {
+	switch string(b) {
+	case "allow", "":
+		*p = SubaddressAllow
+	case "flag":
+		*p = SubaddressFlag
+	case "reject":
+		*p = SubaddressReject
+	default:
+		return fmt.Errorf("unknown subaddress policy %q; use allow, flag or reject", b)
+	}
+	return nil
+}
+
+// WithSubaddressPolicy flags or rejects addresses with a subaddress tag:
+// the provider's separator from the WithProviderRules table, or "+" for
+// domains not in it. At providers that ignore dots, a local part with more
+// than two dots (j.o.h.n.doe@) counts too; first.middle.last does not.
+func WithSubaddressPolicy(p SubaddressPolicy) Option {
+	return func(c *config) error {
+		if p < SubaddressAllow || p > SubaddressReject {
+			return fmt.Errorf("WithSubaddressPolicy: unknown policy %d", p)
+		}
+		c.subaddress = p
+		return nil
+	}
+}
+
+// subaddressed reports whether local@domain uses a tag or alias trick, and
+// which.
+func (g *Guard) subaddressed(local, domain string) (string, bool) {
+	if strings.HasPrefix(local, `"`) {
+		return "", false
+	}
+	r, known := g.providers[domain]
+	sep := "+"
+	if known {
+		sep = r.TagSep
+	}
+	if sep != "" {
+		if base, tag, ok := strings.Cut(local, sep); ok && base != "" && tag != "" {
+			return "tag " + sep + tag, true
+		}
+	}
+	if known && r.IgnoreDots && strings.Count(local, ".") > 2 {
+		return "dots ignored by " + domain, true
+	}
+	return "", false
+}
@@ -0,0 +1,63 @@
+package emailguard
+
+import "strings"
+
+// SubdomainPolicy decides how list entries apply to subdomains. The
+// allowlist and blocklist share one matcher, so an entry means the same on
+// both sides.
+type SubdomainPolicy struct {
+	// BlockSubdomains blocks every subdomain of a blocklisted domain.
+	BlockSubdomains bool `json:"block_subdomains"`
+	// AllowSubdomains allows every subdomain of an allowlisted domain.
+	AllowSubdomains bool `json:"allow_subdomains"`
+	// CollapseToRegistrable matches subdomains by their registrable domain
+	// (eTLD+1) only: mail.foo.bar.tempmail.com is looked up as
+	// tempmail.com, never as bar.tempmail.com. Otherwise every parent down
+	// to the registrable domain is tried, nearest first.
+	CollapseToRegistrable bool `json:"collapse_to_registrable"`
+}
+
+// defaultSubdomainPolicy blocks subdomains of listed domains by their
+// registrable domain and allowlists exact domains only.
+var defaultSubdomainPolicy = SubdomainPolicy{BlockSubdomains: true, CollapseToRegistrable: true}
+
+// WithSubdomainPolicy replaces the default policy, which blocks
+// subdomains of blocklisted registrable domains and allowlists exact
+// domains only.
+func WithSubdomainPolicy(p SubdomainPolicy) Option {
+	return func(c *config) error {
+		c.subdomains = p
+		return nil
+	}
+}
+
+// match returns the entry of set that domain matches: domain itself, a
+// wildcard or regexp rule, or with inherit a parent chosen according to p.
+func (p SubdomainPolicy) match(set *domainSet, domain string, inherit bool) (string, bool) {
+	if set.contains(domain) {
+		return domain, true
+	}
+	if r, ok := set.rule(domain); ok {
+		return r, true
+	}
+	if !inherit {
+		return "", false
+	}
+	rd, err := registrableDomain(domain)
+	if err != nil || rd == domain {
+		return "", false
+	}
+	if p.CollapseToRegistrable {
+		if set.contains(rd) {
+			return rd, true
+		}
+		return "", false
+	}
+	for d := domain; d != rd; {
+		_, d, _ = strings.Cut(d, ".")
+		if set.contains(d) {
+			return d, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,173 @@
+package emailguard
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// popularDomains are mailbox providers common worldwide, most popular first.
+// Typo suggestions are drawn from these and the locale's own providers.
+var popularDomains = []string{
+	"gmail.com",
+	"yahoo.com",
+	"hotmail.com",
+	"outlook.com",
+	"icloud.com",
+	"aol.com",
+	"live.com",
+	"msn.com",
+	"protonmail.com",
+	"proton.me",
+	"gmx.com",
+	"mail.com",
+	"zoho.com",
+	"yandex.com",
+}
+
+// localeDomains are providers popular in a locale, most popular first. Keys
+// are lowercase BCP 47 tags: a language, or language-region where the
+// region's providers differ.
+var localeDomains = map[string][]string{
+	"de":    {"web.de", "gmx.de", "gmx.net", "t-online.de", "freenet.de", "posteo.de"},
+	"fr":    {"orange.fr", "free.fr", "sfr.fr", "laposte.net", "wanadoo.fr", "yahoo.fr", "hotmail.fr"},
+	"en-gb": {"btinternet.com", "sky.com", "virginmedia.com", "yahoo.co.uk", "hotmail.co.uk", "talktalk.net"},
+	"it":    {"libero.it", "virgilio.it", "alice.it", "tiscali.it", "yahoo.it", "hotmail.it"},
+	"es":    {"telefonica.net", "yahoo.es", "hotmail.es"},
+	"nl":    {"ziggo.nl", "kpnmail.nl", "hetnet.nl", "home.nl"},
+	"pl":    {"wp.pl", "o2.pl", "onet.pl", "interia.pl"},
+	"ru":    {"yandex.ru", "mail.ru", "rambler.ru", "bk.ru", "list.ru", "inbox.ru"},
+	"ja":    {"yahoo.co.jp", "docomo.ne.jp", "ezweb.ne.jp", "softbank.ne.jp"},
+	"pt-br": {"uol.com.br", "bol.com.br", "terra.com.br", "ig.com.br"},
+	"zh":    {"qq.com", "163.com", "126.com", "sina.com", "sohu.com"},
+	"ko":    {"naver.com", "daum.net", "hanmail.net"},
+}
+
+// InLocale hints the user's locale as a BCP 47 tag ("de-DE", "fr",
+// "en-GB"), so typo suggestions favour providers popular there: "wbe.de"
+// becomes web.de for a German user.
+func InLocale(tag string) CheckOption {
+	return func(o *checkOptions) { o.locale = tag }
+}
+
+// WithPopularDomains replaces the worldwide providers typo suggestions are
+// drawn from, e.g. with the domains most common among your own users. The
+// locale's providers and the allowlist are still considered.
+func WithPopularDomains(domains ...string) Option {
+	return func(c *config) error {
+		popular := make([]string, 0, len(domains))
+		for _, d := range domains {
+			d = normDomain(d)
+			if err := checkHostname(d); err != nil {
+				return fmt.Errorf("WithPopularDomains: %w", err)
+			}
+			popular = append(popular, d)
+		}
+		c.popular = popular
+		return nil
+	}
+}
+
+// DefaultPopularDomains returns the built-in worldwide providers.
+func DefaultPopularDomains() []string {
+	return slices.Clone(popularDomains)
+}
+
+// suggestionCandidates lists the domains a typo may be corrected to, the
+// locale's providers first.
+func suggestionCandidates(popular []string, locale string) []string {
+	tag := strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+	local := localeDomains[tag]
+	if lang, _, ok := strings.Cut(tag, "-"); ok && local == nil {
+		local = localeDomains[lang]
+	}
+	if len(local) == 0 {
+		return popular
+	}
+	return append(local[:len(local):len(local)], popular...)
+}
+
+// suggestDomain returns the domain most likely meant by domain, or "" if
+// domain is not a near miss of a provider or an allowlisted domain. The
+// closest candidate wins; ties go to the one considered first, so local
+// providers beat global ones and providers beat the allowlist.
+func (g *Guard) suggestDomain(domain, locale string) string {
+	allow := g.allow.Load()
+	if allow.contains(domain) {
+		return ""
+	}
+	best, bestDist := "", maxTypoDistance(domain)+1
+	exact := false
+	consider := func(c string) {
+		if isListRule(c) {
+			return
+		}
+		if c == domain {
+			exact = true // a real provider, not a typo
+		} else if d := editDistance(domain, c); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	for _, c := range suggestionCandidates(g.cfg.popular, locale) {
+		consider(c)
+	}
+	allow.all(consider)
+	if exact {
+		return ""
+	}
+	return best
+}
+
+// DidYouMean phrases v.Suggestion as a prompt for the user, e.g. "did you
+// mean max@gmail.com?", or returns "" when there is no suggestion.
+func (v Verdict) DidYouMean() string {
+	if v.Suggestion == "" {
+		return ""
+	}
+	local := v.Email
+	if at := strings.LastIndexByte(local, '@'); at >= 0 {
+		local = local[:at]
+	}
+	return "did you mean " + local + "@" + v.Suggestion + "?"
+}
+
+// maxTypoDistance is how many edits still count as a typo: one for short
+// domains, where two edits reach unrelated names, else two.
+func maxTypoDistance(domain string) int {
+	if len(domain) < 8 {
+		return 1
+	}
+	return 2
+}
+
+// editDistance is the optimal string alignment distance: insertions,
+// deletions, substitutions and transpositions of adjacent bytes each cost 1.
+func editDistance(a, b string) int {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	if len(b)-len(a) > 2 {
+		return len(b) - len(a) // further than any typo
+	}
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(b)]
+}
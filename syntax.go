@@ -0,0 +1,90 @@
+package emailguard
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// RFC 5321 limits, in octets.
+const (
+	maxAddressLen = 254 // a 256-octet path less the angle brackets
+	maxLocalLen   = 64
+)
+
+// checkAddress applies the RFC 5321/5322 addr-spec rules to an address
+// split by splitEmail, so malformed input is rejected before any DNS work.
+// The local part is a dot-atom or a single quoted string; UTF-8 is allowed
+// as in RFC 6531. Comments, obsolete forms and domain literals are not
+// accepted.
+func checkAddress(local, domain string) error {
+	if len(local)+1+len(domain) > maxAddressLen {
+		return fmt.Errorf("address longer than %d characters", maxAddressLen)
+	}
+	if !utf8.ValidString(local) || !utf8.ValidString(domain) {
+		return errors.New("address is not valid UTF-8")
+	}
+	if err := checkLocalPart(local); err != nil {
+		return err
+	}
+	if strings.HasPrefix(domain, "[") {
+		return errors.New("domain literals are not accepted")
+	}
+	// internationalized labels are checked for shape; their ASCII form is
+	// the resolver's business
+	return checkHostname(strings.Map(func(r rune) rune {
+		if r >= utf8.RuneSelf {
+			return 'x'
+		}
+		return r
+	}, domain))
+}
+
+func checkLocalPart(local string) error {
+	if len(local) > maxLocalLen {
+		return fmt.Errorf("local part longer than %d characters", maxLocalLen)
+	}
+	if len(local) >= 2 && local[0] == '"' && local[len(local)-1] == '"' {
+		return checkQuotedLocal(local[1 : len(local)-1])
+	}
+	if local[0] == '.' || local[len(local)-1] == '.' {
+		return errors.New("local part starts or ends with a dot")
+	}
+	if strings.Contains(local, "..") {
+		return errors.New("consecutive dots in local part")
+	}
+	for i := 0; i < len(local); i++ {
+		if c := local[i]; c != '.' && !isAtext(c) {
+			return fmt.Errorf("invalid character %q in local part", c)
+		}
+	}
+	return nil
+}
+
+// checkQuotedLocal checks the inside of a quoted-string local part.
+func checkQuotedLocal(s string) error {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\':
+			if i++; i == len(s) || s[i] < ' ' || s[i] == 0x7f {
+				return errors.New("bad escape in quoted local part")
+			}
+		case c == '"':
+			return errors.New("unescaped quote in quoted local part")
+		case c < ' ' || c == 0x7f:
+			return fmt.Errorf("control character %q in quoted local part", c)
+		}
+	}
+	return nil
+}
+
+// isAtext reports whether c may appear in an atom (RFC 5322 3.2.3), or
+// is part of a UTF-8 sequence.
+func isAtext(c byte) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c >= utf8.RuneSelf:
+		return true
+	}
+	return strings.IndexByte("!#$%&'*+-/=?^_`{|}~", c) >= 0
+}
@@ -0,0 +1,83 @@
+package emailguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// TLDRule is a policy on a domain's top-level (or public) suffix, such as
+// "flag .ru/.su signups" or "auto-allow .gov/.mil".
+type TLDRule struct {
+	// Suffixes the rule applies to, without leading dot: "ru", "gov.uk".
+	// A domain matches when it equals a suffix or ends with "."+suffix.
+	Suffixes []string
+	// Action decides the domain (ActionAllow/ActionDeny) or, with
+	// ActionNone, leaves the decision to lower layers.
+	Action Action
+	// Flag, if set, is attached to every matching verdict regardless of
+	// which layer decides, e.g. "high_risk_tld".
+	Flag string
+}
+
+// WithTLDRules installs suffix rules. They form their own precedence layer
+// right below the tenant policy; the first matching rule with an Action
+// decides, and every matching rule contributes its Flag.
+func WithTLDRules(rules ...TLDRule) Option {
+	return func(c *config) error {
+		for i, r := range rules {
+			if len(r.Suffixes) == 0 {
+				return fmt.Errorf("WithTLDRules: rule %d has no suffixes", i)
+			}
+			if r.Action == ActionNone && r.Flag == "" {
+				return errors.New("WithTLDRules: rule has neither action nor flag")
+			}
+			suffixes := make([]string, len(r.Suffixes))
+			for j, s := range r.Suffixes {
+				suffixes[j] = strings.TrimPrefix(normDomain(s), ".")
+			}
+			r.Suffixes = suffixes
+			c.tldRules = append(c.tldRules, r)
+		}
+		return nil
+	}
+}
+
+func (r TLDRule) matches(domain string) (string, bool) {
+	for _, s := range r.Suffixes {
+		if domain == s || strings.HasSuffix(domain, "."+s) {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+func (g *Guard) evalTLD(ctx context.Context, domain string) (Finding, error) {
+	for _, r := range g.cfg.tldRules {
+		if r.Action == ActionNone {
+			continue
+		}
+		if s, ok := r.matches(domain); ok {
+			return Finding{r.Action, ReasonTLDPolicy, s, fmt.Sprintf("TLD rule .%s: %s", s, r.Action)}, nil
+		}
+	}
+	if f, ok := g.evalPlatform(domain); ok {
+		return f, nil
+	}
+	return noOpinion("no TLD rule"), nil
+}
+
+// tldFlags returns the flags of every rule matching domain.
+func (g *Guard) tldFlags(domain string) []string {
+	var flags []string
+	for _, r := range g.cfg.tldRules {
+		if r.Flag == "" {
+			continue
+		}
+		if _, ok := r.matches(domain); ok {
+			flags = append(flags, r.Flag)
+		}
+	}
+	return flags
+}
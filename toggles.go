@@ -0,0 +1,63 @@
+package emailguard
+
+import "errors"
+
+// ErrAirGapped is returned by DNS lookups on a guard built WithAirGapped,
+// e.g. from a custom stage that resolves records.
+var ErrAirGapped = errors.New("emailguard: DNS disabled (air-gapped)")
+
+// mxChecks need the domain's MX records.
+var mxChecks = []string{LayerMX, LayerMXKeywords, LayerDisposableMX, LayerFingerprint, LayerHeuristics}
+
+// WithoutMXLookup skips every check that resolves MX records: the MX,
+// keyword and disposable-MX checks and provider fingerprints. Domains that
+// pass the lists are allowed.
+func WithoutMXLookup() Option {
+	return func(c *config) error {
+		c.disable(mxChecks...)
+		if c.fallback == ActionNone {
+			c.fallback = ActionAllow
+		}
+		return nil
+	}
+}
+
+// WithoutMXKeywords skips the scan of MX hostnames for masking and
+// forwarding keywords.
+func WithoutMXKeywords() Option {
+	return func(c *config) error {
+		c.disable(LayerMXKeywords)
+		return nil
+	}
+}
+
+// WithoutBlocklist skips the disposable blocklist check. MX hosts on the
+// blocklist are still rejected unless WithoutMXLookup is also given.
+func WithoutBlocklist() Option {
+	return func(c *config) error {
+		c.disable(LayerBlocklist)
+		return nil
+	}
+}
+
+// WithAirGapped is for deployments without DNS. It implies WithoutMXLookup,
+// keeping the list checks, and makes any remaining lookup fail with
+// ErrAirGapped instead of reaching the network. It cannot be combined with
+// WithDeepVerification.
+func WithAirGapped() Option {
+	return func(c *config) error {
+		c.airGapped = true
+		return WithoutMXLookup()(c)
+	}
+}
+
+// disable turns checks off. Unlike WithoutStages, names that are not in the
+// pipeline are ignored, so the toggles work with any WithPipeline.
+func (c *config) disable(names ...string) {
+	if c.disabled == nil {
+		c.disabled = make(map[string]bool)
+	}
+	for _, n := range names {
+		c.disabled[n] = true
+	}
+}
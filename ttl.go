@@ -0,0 +1,178 @@
+package emailguard
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// WithVerdictTTLs caches allow and reject verdicts for different lengths
+// of time, and DNS failures at all. A zero allow or reject TTL falls back
+// to WithCacheTTL; a zero failure TTL leaves failures uncached, so every
+// check of a failing domain asks the resolver again. Reasonable values are
+// an hour for allows, a day for rejects and a minute for failures;
+// WithRuleTTLs refines them per rule. DNS results themselves keep
+// WithCacheTTL or WithNegativeCacheTTL, and allows remain subject to
+// WithReevaluation.
+func WithVerdictTTLs(allow, reject, failure time.Duration) Option {
+	return func(c *config) error {
+		if allow < 0 || reject < 0 || failure < 0 {
+			return fmt.Errorf("WithVerdictTTLs: need non-negative TTLs, got %v, %v, %v", allow, reject, failure)
+		}
+		c.allowTTL, c.rejectTTL, c.failureTTL = allow, reject, failure
+		return nil
+	}
+}
+
+// defaultNegativeTTL is how long answers that a name or record does not
+// exist are cached.
+const defaultNegativeTTL = time.Minute
+
+// WithNegativeCacheTTL sets how long DNS answers that a domain does not
+// exist (NXDOMAIN) or has no records of the type asked are cached, apart
+// from WithCacheTTL. Repeated signups with the same garbage domain then
+// stop reaching the resolver, while a domain that fixes its DNS is noticed
+// soon. The default is a minute. DebugStats reports the hit rate.
+func WithNegativeCacheTTL(d time.Duration) Option {
+	return func(c *config) error {
+		if d <= 0 {
+			return fmt.Errorf("WithNegativeCacheTTL: need a positive TTL, got %v", d)
+		}
+		c.negativeTTL = d
+		return nil
+	}
+}
+
+// dnsTTL is how long a DNS answer with n records is cached.
+func (g *Guard) dnsTTL(n int) time.Duration {
+	if n == 0 {
+		return g.cfg.negativeTTL
+	}
+	return g.cfg.cacheTTL
+}
+
+// countDNS counts a lookup for NegativeCacheStats; negative says it was
+// answered from a negative cache entry.
+func (g *Guard) countDNS(negative bool) {
+	g.dnsLookups.Add(1)
+	if negative {
+		g.negativeHits.Add(1)
+	}
+}
+
+// RuleTTL caches the verdicts a rule decides for TTL. Layer, Action and
+// Reason select the verdicts; empty ones match any, so {Layer:
+// LayerHeuristics, Action: ActionDeny} covers every heuristic reject and
+// {Reason: ReasonAllowlisted} every allowlist hit.
+type RuleTTL struct {
+	Layer  string        `json:"layer,omitempty"`
+	Action Action        `json:"action,omitempty"`
+	Reason Reason        `json:"reason,omitempty"`
+	TTL    time.Duration `json:"ttl"`
+}
+
+func (r RuleTTL) match(res LayerResult) bool {
+	return (r.Layer == "" || r.Layer == res.Layer) &&
+		(r.Action == ActionNone || r.Action == res.Action) &&
+		(r.Reason == "" || r.Reason == res.Reason)
+}
+
+type ruleTTLJSON struct {
+	Layer  string `json:"layer,omitempty"`
+	Action Action `json:"action,omitempty"`
+	Reason Reason `json:"reason,omitempty"`
+	TTL    string `json:"ttl"`
+}
+
+func (r RuleTTL) MarshalJSON() ([]byte, error) {
+	return json.Marshal(ruleTTLJSON{r.Layer, r.Action, r.Reason, r.TTL.String()})
+}
+
+func (r *RuleTTL) UnmarshalJSON(b []byte) error {
+	var j ruleTTLJSON
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	ttl, err := time.ParseDuration(j.TTL)
+	if err != nil {
+		return fmt.Errorf("ttl: %q is not a duration like \"15m\" or \"24h\"", j.TTL)
+	}
+	*r = RuleTTL{j.Layer, j.Action, j.Reason, ttl}
+	return nil
+}
+
+// validate reports what is wrong with r, if anything.
+func (r RuleTTL) validate() error {
+	switch {
+	case r.TTL <= 0:
+		return fmt.Errorf("need a positive TTL, got %v", r.TTL)
+	case r.Action < ActionNone || r.Action > ActionDeny:
+		return fmt.Errorf("unknown action %d", r.Action)
+	case r.Layer == "" && r.Action == ActionNone && r.Reason == "":
+		return errors.New("rule matches every verdict; use WithCacheTTL")
+	}
+	return nil
+}
+
+// WithRuleTTLs caches the verdicts of particular rules for their own
+// length of time, say allowlist hits for a day and heuristic rejects for
+// 15 minutes. The first rule matching a verdict wins, so list narrow rules
+// before broad ones; verdicts no rule matches keep WithVerdictTTLs.
+// Repeated calls append. Allows remain subject to WithReevaluation.
+func WithRuleTTLs(rules ...RuleTTL) Option {
+	return func(c *config) error {
+		for _, r := range rules {
+			if err := r.validate(); err != nil {
+				return fmt.Errorf("WithRuleTTLs: %w", err)
+			}
+		}
+		c.ruleTTLs = append(c.ruleTTLs, rules...)
+		return nil
+	}
+}
+
+// verdictTTL is how long r is cached.
+func (g *Guard) verdictTTL(r LayerResult) time.Duration {
+	for _, rt := range g.cfg.ruleTTLs {
+		if rt.match(r) {
+			return rt.TTL
+		}
+	}
+	ttl := g.cfg.rejectTTL
+	if r.Action == ActionAllow {
+		ttl = g.cfg.allowTTL
+	}
+	if ttl == 0 {
+		return g.cfg.cacheTTL
+	}
+	return ttl
+}
+
+// failureEntry is a cached inconclusive result. Failures are kept apart
+// from verdicts so they never displace one that WithStaleIfError could
+// still serve, and never reach the store.
+type failureEntry struct {
+	res LayerResult
+	exp time.Time
+}
+
+// cachedFailure returns domain's cached failure, if it has not expired.
+func (g *Guard) cachedFailure(domain string) (LayerResult, bool) {
+	v, ok := g.failures.Load(domain)
+	if !ok {
+		return LayerResult{}, false
+	}
+	if e := v.(failureEntry); time.Now().Before(e.exp) {
+		return e.res, true
+	}
+	g.failures.CompareAndDelete(domain, v)
+	return LayerResult{}, false
+}
+
+// cacheFailure remembers r for the failure TTL, if there is one.
+func (g *Guard) cacheFailure(domain string, r LayerResult) {
+	if g.cfg.failureTTL > 0 {
+		g.failures.Store(domain, failureEntry{res: r, exp: time.Now().Add(g.cfg.failureTTL)})
+	}
+}
@@ -0,0 +1,79 @@
+package emailguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// upstreamResolver is a net.Resolver that sends its queries to fixed
+// servers in turn rather than to those in the system configuration.
+type upstreamResolver struct {
+	*net.Resolver
+	servers []string
+}
+
+// WithDNSServers sends DNS lookups to servers, such as "1.1.1.1:53" or an
+// internal resolver, instead of those the system is configured with. Each
+// query goes to the next server in turn, and a query that times out is
+// retried on the next; the port defaults to 53.
+func WithDNSServers(servers ...string) Option {
+	return func(c *config) error {
+		if len(servers) == 0 {
+			return errors.New("WithDNSServers: no servers")
+		}
+		addrs := make([]string, len(servers))
+		for i, s := range servers {
+			a, err := dnsServerAddr(s)
+			if err != nil {
+				return fmt.Errorf("WithDNSServers: %w", err)
+			}
+			addrs[i] = a
+		}
+		var next atomic.Uint32
+		var d net.Dialer
+		c.resolver = &upstreamResolver{
+			Resolver: &net.Resolver{
+				PreferGo: true,
+				Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+					n := next.Add(1) - 1
+					return d.DialContext(ctx, network, addrs[n%uint32(len(addrs))])
+				},
+			},
+			servers: addrs,
+		}
+		return nil
+	}
+}
+
+// dnsServerAddr adds the default port to s if it has none. s may be a
+// bare or bracketed IPv6 address.
+func dnsServerAddr(s string) (string, error) {
+	host, port, err := net.SplitHostPort(s)
+	if err != nil {
+		host, port = s, "53"
+		if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+			host = s[1 : len(s)-1]
+		}
+	}
+	if host == "" {
+		return "", fmt.Errorf("DNS server %q: need a host", s)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return "", fmt.Errorf("DNS server %q: bad port %q", s, port)
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// dnsServers returns r's servers if it came from WithDNSServers.
+func dnsServers(r Resolver) []string {
+	if u, ok := r.(*upstreamResolver); ok {
+		return slices.Clone(u.servers)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package emailguard
+
+import "testing"
+
+func TestDNSServerAddr(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"1.1.1.1", "1.1.1.1:53"},
+		{"1.1.1.1:5353", "1.1.1.1:5353"},
+		{"dns.internal", "dns.internal:53"},
+		{"::1", "[::1]:53"},
+		{"[::1]", "[::1]:53"},
+		{"[::1]:5353", "[::1]:5353"},
+		{"2606:4700:4700::1111", "[2606:4700:4700::1111]:53"},
+		{"1.1.1.1:", ""},
+		{"[::1]:", ""},
+		{":53", ""},
+		{"[]", ""},
+		{"1.1.1.1:dns", ""},
+		{"1.1.1.1:0", ""},
+		{"1.1.1.1:65536", ""},
+	}
+	for _, tt := range tests {
+		got, err := dnsServerAddr(tt.in)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("dnsServerAddr(%q) = %q, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("dnsServerAddr(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
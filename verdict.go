@@ -0,0 +1,335 @@
+package emailguard
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrTemporary marks failures that may succeed on retry (DNS timeouts,
+// resolver errors). Test with errors.Is.
+var ErrTemporary = errors.New("emailguard: temporary failure")
+
+// retryAfter is the suggested delay before re-checking an inconclusive address.
+const retryAfter = 1 * time.Minute
+
+// Outcome is the final result of checking an address.
+type Outcome int
+
+const (
+	OutcomeReject Outcome = iota
+	OutcomeAllow
+	// OutcomeRetryLater means the address could not be verified right now
+	// (e.g. flaky DNS). Callers should queue a re-check after
+	// Verdict.RetryAfter instead of permanently rejecting it.
+	OutcomeRetryLater
+	// OutcomeUnknown means the guard declined to guess because the verdict's
+	// Confidence fell below the WithAbstainBelow threshold.
+	OutcomeUnknown
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case OutcomeAllow:
+		return "allow"
+	case OutcomeRetryLater:
+		return "retry_later"
+	case OutcomeUnknown:
+		return "unknown"
+	default:
+		return "reject"
+	}
+}
+
+func (o Outcome) MarshalText() ([]byte, error) {
+	return []byte(o.String()), nil
+}
+
+func (o *Outcome) UnmarshalText(b []byte) error {
+	switch string(b) {
+	case "allow":
+		*o = OutcomeAllow
+	case "retry_later":
+		*o = OutcomeRetryLater
+	case "unknown":
+		*o = OutcomeUnknown
+	case "reject":
+		*o = OutcomeReject
+	default:
+		return fmt.Errorf("unknown outcome %q", b)
+	}
+	return nil
+}
+
+// Verdict is the detailed result of checking an address. With WithPrivacy,
+// Email holds the pseudonymized address.
+type Verdict struct {
+	Email             string         `json:"email"`
+	Domain            string         `json:"domain"`
+	RegistrableDomain string         `json:"registrable_domain,omitempty"` // eTLD+1, e.g. example.co.uk
+	PublicSuffix      string         `json:"public_suffix,omitempty"`      // e.g. co.uk or github.io
+	PrivateSuffix     bool           `json:"private_suffix,omitempty"`     // PublicSuffix is a platform's, not ICANN's
+	Outcome           Outcome        `json:"outcome"`
+	Allowed           bool           `json:"allowed"`              // Outcome == OutcomeAllow
+	Reason            Reason         `json:"reason,omitempty"`     // machine-readable cause
+	Layer             string         `json:"layer,omitempty"`      // precedence layer that decided
+	Match             string         `json:"match,omitempty"`      // blocklist entry, MX host or rule that matched
+	Detail            string         `json:"detail,omitempty"`     // short human-readable reason
+	MXHosts           []string       `json:"mx_hosts,omitempty"`   // MX hosts inspected, preference order
+	MXDomain          string         `json:"mx_domain,omitempty"`  // domain whose MX hosts those are; see WithParentDomainMX
+	MXRecords         []MXRecord     `json:"mx_records,omitempty"` // MXHosts with their preferences, when known
+	RetryAfter        time.Duration  `json:"-"`                    // suggested delay when Outcome is OutcomeRetryLater
+	Flags             []string       `json:"flags,omitempty"`      // annotations for review, independent of Outcome
+	MXCount           int            `json:"mx_count"`             // distinct MX hosts, when known
+	Signals           []Signal       `json:"signals,omitempty"`
+	SMTP              *SMTPInfo      `json:"smtp,omitempty"`           // deep verification of the primary MX
+	Relay             *RelayInfo     `json:"relay,omitempty"`          // open-relay probe, when one ran
+	SPF               *SPFInfo       `json:"spf,omitempty"`            // with WithSPFCheck
+	DMARC             *DMARCInfo     `json:"dmarc,omitempty"`          // with WithDMARCCheck
+	DKIM              *DKIMInfo      `json:"dkim,omitempty"`           // with WithDKIMProbe
+	DNSBL             []DNSBLHit     `json:"dnsbl,omitempty"`          // with WithDNSBL
+	PTR               []PTRInfo      `json:"ptr,omitempty"`            // with WithReverseDNS
+	Trace             []StageTrace   `json:"trace,omitempty"`          // stages that ran, with the Traced option
+	Evaluated         time.Time      `json:"evaluated,omitzero"`       // last full evaluation of the domain, for cached layers
+	Suggestion        string         `json:"suggestion,omitempty"`     // provider domain the user probably meant, e.g. gmail.com for gmial.com
+	Classification    Classification `json:"classification"`           // corporate, free, disposable, platform or unknown
+	Confidence        float64        `json:"confidence"`               // 0 to 1; see WithAbstainBelow
+	CorrelationID     string         `json:"correlation_id,omitempty"` // the caller's, from the CorrelationID option
+	Campaign          string         `json:"campaign,omitempty"`       // from the Campaign option
+}
+
+// Check evaluates email with the default guard.
+func Check(email string) (Verdict, error) {
+	return defaultGuard.Load().Check(email)
+}
+
+// Validate evaluates email with the default guard, bounded by ctx.
+func Validate(ctx context.Context, email string, opts ...CheckOption) (Verdict, error) {
+	return defaultGuard.Load().Validate(ctx, email, opts...)
+}
+
+// Check evaluates email and returns a detailed verdict. The error is non-nil
+// only when the verdict is OutcomeRetryLater; it wraps ErrTemporary and the
+// underlying cause. Such domains are queued for RunRechecks.
+func (g *Guard) Check(email string) (Verdict, error) {
+	return g.Validate(context.Background(), email)
+}
+
+// Validate is Check bounded by ctx. The context's deadline replaces the
+// internal per-lookup DNS timeout, and cancelling it aborts pending DNS and
+// SMTP work; the verdict is then OutcomeRetryLater. opts tune this call only.
+func (g *Guard) Validate(ctx context.Context, email string, opts ...CheckOption) (Verdict, error) {
+	ctx = withCheckOptions(ctx, opts)
+	local, domain, ok := splitEmail(email)
+	v := Verdict{Email: g.redactEmail(email, local, domain), Classification: ClassUnknown, Confidence: 1}
+	v.CorrelationID, v.Campaign = checkOptsFrom(ctx).corrID, checkOptsFrom(ctx).campaign
+	if !ok {
+		v.Reason, v.Detail = ReasonSyntax, "malformed address"
+		g.recordDecision(v)
+		return g.shadowed(v, nil)
+	}
+	v.Domain = domain
+	err := checkAddress(local, domain)
+	if err == nil {
+		err = g.checkProviderLocal(local, domain)
+	}
+	if err != nil {
+		v.Layer, v.Reason, v.Detail = LayerSyntax, ReasonSyntax, err.Error()
+		g.recordDecision(v)
+		return g.shadowed(v, nil)
+	}
+	fillSuffix(&v)
+	if g.cfg.subaddress == SubaddressReject {
+		if how, ok := g.subaddressed(local, domain); ok {
+			v.Layer, v.Reason, v.Detail = LayerSubaddress, ReasonSubaddressed, "subaddressed: "+how
+			g.recordDecision(v)
+			return g.shadowed(v, nil)
+		}
+	}
+
+	var r LayerResult
+	if !runHooks(g.cfg.preHooks, LayerPreHook, &v) {
+		var trace *[]StageTrace
+		if checkOptsFrom(ctx).trace {
+			trace = &v.Trace
+		}
+		r = g.run(ctx, domain, trace)
+		fillVerdict(&v, r)
+	}
+	r.Err = g.conclude(ctx, &v, r, local)
+	g.stats.record(v, g.cfg.keyring != nil)
+	g.recordDecision(v)
+	if r.Err != nil {
+		g.scheduleRecheck(v)
+		return g.shadowed(v, r.Err)
+	}
+	if r.Stale {
+		v.RetryAfter = retryAfter
+		g.scheduleRecheck(v)
+	}
+	return g.shadowed(v, nil)
+}
+
+// conclude completes a verdict filled from the pipeline's result r, as
+// every verdict the guard reports is: annotations, flags, post hooks and
+// confidence. It returns r.Err, or nil if a post hook decided.
+func (g *Guard) conclude(ctx context.Context, v *Verdict, r LayerResult, local string) error {
+	g.annotate(ctx, v, local)
+	if g.quarantined(v.Domain) {
+		v.Flags = append(v.Flags, FlagQuarantined)
+	}
+	if r.Stale {
+		v.Flags = append(v.Flags, FlagStale)
+	}
+	if runHooks(g.cfg.postHooks, LayerPostHook, v) {
+		r.Err = nil
+	}
+	g.settle(v)
+	return r.Err
+}
+
+// annotate adds flags and signals that inform review without changing the
+// outcome.
+func (g *Guard) annotate(ctx context.Context, v *Verdict, local string) {
+	v.Flags = append(v.Flags, g.tldFlags(v.Domain)...)
+	if _, ok := g.platformSuffix(v.Domain); ok {
+		v.Flags = append(v.Flags, FlagPlatformSubdomain)
+	}
+	v.Suggestion = g.suggestDomain(v.Domain, checkOptsFrom(ctx).locale)
+	if g.sequenceFlag(local, v.Domain) {
+		v.Flags = append(v.Flags, FlagSequentialLocalPart)
+	}
+	if local != "" && isRoleAccount(local, g.cfg.roleAccounts) {
+		v.Flags = append(v.Flags, FlagRoleAccount)
+	}
+	if g.cfg.subaddress == SubaddressFlag && local != "" {
+		if _, ok := g.subaddressed(local, v.Domain); ok {
+			v.Flags = append(v.Flags, FlagSubaddressed)
+		}
+	}
+	mxd := v.Domain
+	if d := g.mxDomain(ctx, v.Domain); d != "" {
+		mxd, v.MXDomain = d, d
+	}
+	if hosts, ok := g.knownMX(ctx, mxd); ok {
+		v.MXHosts = slices.Clone(hosts) // cached slice is shared
+		v.MXRecords = g.mxRecords(ctx, mxd)
+	}
+	count, signals := g.mxSignals(ctx, mxd)
+	v.MXCount, v.Signals = count, append(v.Signals, signals...)
+	v.Classification = g.classify(v)
+	var lookups []postCheck
+	for _, check := range []postCheck{
+		{CheckSPF, g.cfg.spf, true, g.inspectSPF}, {CheckDMARC, g.cfg.dmarc, true, g.inspectDMARC},
+		{CheckDKIM, len(g.cfg.dkimSelectors) > 0, true, g.probeDKIM}, {LayerParked, g.cfg.parked && g.cfg.parkedAction == ActionNone, true, g.flagParked},
+		{CheckDNSBL, len(g.cfg.dnsbls) > 0, true, g.checkDNSBL},
+		{CheckPTR, g.cfg.reverseDNS, true, g.checkPTR},
+		{LayerReputation, len(g.cfg.domainLists) > 0 && g.cfg.domainListAction == ActionNone, true, g.flagListedDomain},
+		{CheckSMTP, g.cfg.smtp != nil, false, g.deepVerify},
+	} {
+		switch {
+		case !check.on || v.Outcome != OutcomeAllow:
+		case g.switchedOff(check.name):
+			if checkOptsFrom(ctx).trace {
+				v.Trace = append(v.Trace, StageTrace{Stage: check.name, Detail: "switched off", Disabled: true})
+			}
+		case check.dns:
+			lookups = append(lookups, check)
+		default:
+			g.gatherDNS(ctx, v, lookups)
+			lookups = nil
+			check.run(ctx, v)
+		}
+	}
+	g.gatherDNS(ctx, v, lookups)
+}
+
+// postCheck is a check of allowed verdicts. dns checks only look records
+// up, and run together.
+type postCheck struct {
+	name string
+	on   bool
+	dns  bool
+	run  func(context.Context, *Verdict)
+}
+
+// gatherDNS runs checks concurrently under one lookup deadline, so that
+// enabling SPF, DMARC, DKIM, DNSBL, reverse DNS, parked and domain blocklist
+// checks together costs about as much as the slowest of them. Each works on its own
+// copy of v; what they add is merged in order, so v reads as if they had run
+// one after another.
+func (g *Guard) gatherDNS(ctx context.Context, v *Verdict, checks []postCheck) {
+	if len(checks) == 0 {
+		return
+	}
+	ctx, cancel := g.dnsContext(ctx)
+	defer cancel()
+	if len(checks) == 1 {
+		checks[0].run(ctx, v)
+		return
+	}
+	parts := make([]Verdict, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		parts[i] = *v
+		parts[i].Flags, parts[i].Signals = nil, nil
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			check.run(ctx, &parts[i])
+		}()
+	}
+	wg.Wait()
+	for _, p := range parts {
+		v.Flags, v.Signals = append(v.Flags, p.Flags...), append(v.Signals, p.Signals...)
+		v.SPF, v.DMARC, v.DKIM = cmp.Or(p.SPF, v.SPF), cmp.Or(p.DMARC, v.DMARC), cmp.Or(p.DKIM, v.DKIM)
+		v.DNSBL, v.PTR = append(v.DNSBL, p.DNSBL...), append(v.PTR, p.PTR...)
+	}
+}
+
+// deepVerify runs the SMTP-level checks against the primary MX.
+func (g *Guard) deepVerify(ctx context.Context, v *Verdict) {
+	hosts, ok := g.knownMX(ctx, v.MXDomain)
+	if !ok || len(hosts) == 0 {
+		return
+	}
+	primary := normDomain(hosts[0])
+	start := time.Now()
+	info := g.inspectSMTP(ctx, primary)
+	v.SMTP = &info
+	if checkOptsFrom(ctx).trace {
+		v.Trace = append(v.Trace, StageTrace{Stage: CheckSMTP, Detail: info.traceDetail(), Error: info.Error, Family: addrFamily(info.Addr), Duration: time.Since(start)})
+	}
+	v.Signals = append(v.Signals, smtpSignals(info)...)
+
+	if g.relayLimit == nil {
+		return
+	}
+	if relay, ok := g.probeRelay(ctx, primary); ok {
+		v.Relay = &relay
+		if relay.Open {
+			v.Flags = append(v.Flags, FlagOpenRelay)
+			v.Signals = append(v.Signals, Signal{Name: SignalSMTPOpenRelay, Weight: 30, Detail: primary + " relays for outside recipients"})
+		}
+	}
+}
+
+// fillVerdict translates a precedence result into v.
+func fillVerdict(v *Verdict, r LayerResult) {
+	v.Layer, v.Reason, v.Match, v.Detail = r.Layer, r.Reason, r.Match, r.Detail
+	v.Evaluated = r.Evaluated
+	v.Allowed, v.RetryAfter = false, 0
+	switch {
+	case r.Err != nil:
+		v.Outcome = OutcomeRetryLater
+		v.RetryAfter = retryAfter
+	case r.Action == ActionAllow:
+		v.Outcome, v.Allowed = OutcomeAllow, true
+	default:
+		v.Outcome = OutcomeReject
+	}
+}